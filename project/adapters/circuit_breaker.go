@@ -0,0 +1,156 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+)
+
+var (
+	circuitBreakerStateGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "circuit_breaker",
+			Name:      "state",
+			Help:      "The state of the circuit breaker: 0 - closed, 1 - half-open, 2 - open",
+		},
+		[]string{"name"},
+	)
+	circuitBreakerStateChangesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "circuit_breaker",
+			Name:      "state_changes_total",
+			Help:      "The total number of circuit breaker state changes",
+		},
+		[]string{"name", "to"},
+	)
+	circuitBreakerRejectedCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "circuit_breaker",
+			Name:      "rejected_requests_total",
+			Help:      "The total number of requests rejected without calling the service",
+		},
+		[]string{"name"},
+	)
+)
+
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailures opens the breaker
+	ConsecutiveFailures uint32 `yaml:"consecutive_failures"`
+	// OpenTimeout is how long the breaker stays open before letting trial requests through
+	OpenTimeout time.Duration `yaml:"open_timeout"`
+	// HalfOpenMaxRequests is the number of trial requests that have to succeed to close the breaker
+	HalfOpenMaxRequests uint32 `yaml:"half_open_max_requests"`
+}
+
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	ConsecutiveFailures: 5,
+	OpenTimeout:         30 * time.Second,
+	HalfOpenMaxRequests: 1,
+}
+
+// DefaultCircuitBreakerConfigs are circuit breakers of external services by name.
+var DefaultCircuitBreakerConfigs = map[string]CircuitBreakerConfig{
+	SpreadsheetsService: DefaultCircuitBreakerConfig,
+	ReceiptsService:     DefaultCircuitBreakerConfig,
+	FilesService:        DefaultCircuitBreakerConfig,
+	DeadNationService:   DefaultCircuitBreakerConfig,
+	PaymentsService:     DefaultCircuitBreakerConfig,
+	// the VIP bundle steps waiting for flights and taxis time out, so the service is probed more often
+	TransportationService: {
+		ConsecutiveFailures: 5,
+		OpenTimeout:         10 * time.Second,
+		HalfOpenMaxRequests: 1,
+	},
+}
+
+// halfOpenRetryAfter is when a request rejected by a half-open breaker is tried again,
+// the trial requests should be done by then.
+const halfOpenRetryAfter = time.Second
+
+// CircuitBreaker stops calling a service that keeps failing, so handlers fail fast instead of waiting for it.
+type CircuitBreaker struct {
+	breaker *gobreaker.CircuitBreaker
+	// openUntil is the UnixNano time when the open breaker lets trial requests through
+	openUntil *atomic.Int64
+}
+
+func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	if name == "" {
+		panic("missing circuit breaker name")
+	}
+	if config.ConsecutiveFailures == 0 {
+		panic("ConsecutiveFailures must be greater than 0")
+	}
+
+	circuitBreakerStateGauge.WithLabelValues(name).Set(float64(gobreaker.StateClosed))
+
+	openUntil := &atomic.Int64{}
+
+	return &CircuitBreaker{
+		openUntil: openUntil,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: config.HalfOpenMaxRequests,
+			Timeout:     config.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= config.ConsecutiveFailures
+			},
+			IsSuccessful: func(err error) bool {
+				// the service responded, it just rejected the request
+				return err == nil || ticketsEntity.IsPermanentError(err) || errors.Is(err, context.Canceled)
+			},
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				if to == gobreaker.StateOpen {
+					openUntil.Store(time.Now().Add(config.OpenTimeout).UnixNano())
+				}
+				circuitBreakerStateGauge.WithLabelValues(name).Set(float64(to))
+				circuitBreakerStateChangesCounter.WithLabelValues(name, to.String()).Inc()
+			},
+		}),
+	}
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.breaker.Name()
+}
+
+// State returns closed, half-open or open.
+func (b *CircuitBreaker) State() string {
+	return b.breaker.State().String()
+}
+
+func (b *CircuitBreaker) call(fn func() error) error {
+	_, err := execute(b, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+func execute[T any](b *CircuitBreaker, fn func() (T, error)) (T, error) {
+	result, err := b.breaker.Execute(func() (any, error) {
+		return fn()
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		circuitBreakerRejectedCounter.WithLabelValues(b.Name()).Inc()
+
+		retryAfter := time.Until(time.Unix(0, b.openUntil.Load()))
+		if retryAfter <= 0 {
+			retryAfter = halfOpenRetryAfter
+		}
+
+		var zero T
+		return zero, ticketsEntity.CircuitOpenError{Service: b.Name(), RetryAfter: retryAfter}
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result.(T), nil
+}
@@ -0,0 +1,234 @@
+package adapters
+
+import (
+	"context"
+	ticketsEntity "tickets/entities"
+)
+
+type spreadsheetsAPI interface {
+	AppendRow(ctx context.Context, spreadsheetName string, row []string) error
+}
+
+type SpreadsheetsAPIWithCircuitBreaker struct {
+	next    spreadsheetsAPI
+	breaker *CircuitBreaker
+}
+
+func NewSpreadsheetsAPIWithCircuitBreaker(
+	next spreadsheetsAPI,
+	breaker *CircuitBreaker,
+) SpreadsheetsAPIWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return SpreadsheetsAPIWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (s SpreadsheetsAPIWithCircuitBreaker) AppendRow(ctx context.Context, spreadsheetName string, row []string) error {
+	return s.breaker.call(func() error {
+		return s.next.AppendRow(ctx, spreadsheetName, row)
+	})
+}
+
+type receiptsService interface {
+	IssueReceipt(
+		ctx context.Context,
+		request ticketsEntity.IssueReceiptRequest,
+	) (ticketsEntity.IssueReceiptResponse, error)
+	RefundReceipt(ctx context.Context, command ticketsEntity.RefundTicket) error
+}
+
+type ReceiptsServiceWithCircuitBreaker struct {
+	next    receiptsService
+	breaker *CircuitBreaker
+}
+
+func NewReceiptsServiceWithCircuitBreaker(
+	next receiptsService,
+	breaker *CircuitBreaker,
+) ReceiptsServiceWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return ReceiptsServiceWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (s ReceiptsServiceWithCircuitBreaker) IssueReceipt(
+	ctx context.Context,
+	request ticketsEntity.IssueReceiptRequest,
+) (ticketsEntity.IssueReceiptResponse, error) {
+	return execute(s.breaker, func() (ticketsEntity.IssueReceiptResponse, error) {
+		return s.next.IssueReceipt(ctx, request)
+	})
+}
+
+func (s ReceiptsServiceWithCircuitBreaker) RefundReceipt(
+	ctx context.Context,
+	command ticketsEntity.RefundTicket,
+) error {
+	return s.breaker.call(func() error {
+		return s.next.RefundReceipt(ctx, command)
+	})
+}
+
+type filesService interface {
+	UpLoadFile(ctx context.Context, ticketFile string, body string) error
+}
+
+type FileServiceWithCircuitBreaker struct {
+	next    filesService
+	breaker *CircuitBreaker
+}
+
+func NewFileServiceWithCircuitBreaker(
+	next filesService,
+	breaker *CircuitBreaker,
+) FileServiceWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return FileServiceWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (s FileServiceWithCircuitBreaker) UpLoadFile(ctx context.Context, ticketFile string, body string) error {
+	return s.breaker.call(func() error {
+		return s.next.UpLoadFile(ctx, ticketFile, body)
+	})
+}
+
+type paymentsService interface {
+	RefundPayment(ctx context.Context, refundPayment ticketsEntity.PaymentRefund) error
+}
+
+type PaymentsServiceWithCircuitBreaker struct {
+	next    paymentsService
+	breaker *CircuitBreaker
+}
+
+func NewPaymentsServiceWithCircuitBreaker(
+	next paymentsService,
+	breaker *CircuitBreaker,
+) PaymentsServiceWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return PaymentsServiceWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (s PaymentsServiceWithCircuitBreaker) RefundPayment(
+	ctx context.Context,
+	refundPayment ticketsEntity.PaymentRefund,
+) error {
+	return s.breaker.call(func() error {
+		return s.next.RefundPayment(ctx, refundPayment)
+	})
+}
+
+type deadNationService interface {
+	CallDeadNation(ctx context.Context, booking ticketsEntity.DeadNationBooking) error
+}
+
+type DeadNationServiceWithCircuitBreaker struct {
+	next    deadNationService
+	breaker *CircuitBreaker
+}
+
+func NewDeadNationServiceWithCircuitBreaker(
+	next deadNationService,
+	breaker *CircuitBreaker,
+) DeadNationServiceWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return DeadNationServiceWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (s DeadNationServiceWithCircuitBreaker) CallDeadNation(
+	ctx context.Context,
+	booking ticketsEntity.DeadNationBooking,
+) error {
+	return s.breaker.call(func() error {
+		return s.next.CallDeadNation(ctx, booking)
+	})
+}
+
+type transportationService interface {
+	BookFlight(
+		ctx context.Context,
+		request ticketsEntity.BookFlightTicketRequest,
+	) (ticketsEntity.BookFlightTicketResponse, error)
+	BookTaxi(
+		ctx context.Context,
+		request ticketsEntity.BookTaxiRequest,
+	) (ticketsEntity.BookTaxiResponse, error)
+	CancelFlightTickets(
+		ctx context.Context,
+		request ticketsEntity.CancelFlightTicketsRequest,
+	) error
+}
+
+type TransportationWithCircuitBreaker struct {
+	next    transportationService
+	breaker *CircuitBreaker
+}
+
+func NewTransportationWithCircuitBreaker(
+	next transportationService,
+	breaker *CircuitBreaker,
+) TransportationWithCircuitBreaker {
+	if next == nil {
+		panic("next is nil")
+	}
+	if breaker == nil {
+		panic("breaker is nil")
+	}
+
+	return TransportationWithCircuitBreaker{next: next, breaker: breaker}
+}
+
+func (t TransportationWithCircuitBreaker) BookFlight(
+	ctx context.Context,
+	request ticketsEntity.BookFlightTicketRequest,
+) (ticketsEntity.BookFlightTicketResponse, error) {
+	return execute(t.breaker, func() (ticketsEntity.BookFlightTicketResponse, error) {
+		return t.next.BookFlight(ctx, request)
+	})
+}
+
+func (t TransportationWithCircuitBreaker) BookTaxi(
+	ctx context.Context,
+	request ticketsEntity.BookTaxiRequest,
+) (ticketsEntity.BookTaxiResponse, error) {
+	return execute(t.breaker, func() (ticketsEntity.BookTaxiResponse, error) {
+		return t.next.BookTaxi(ctx, request)
+	})
+}
+
+func (t TransportationWithCircuitBreaker) CancelFlightTickets(
+	ctx context.Context,
+	request ticketsEntity.CancelFlightTicketsRequest,
+) error {
+	return t.breaker.call(func() error {
+		return t.next.CancelFlightTickets(ctx, request)
+	})
+}
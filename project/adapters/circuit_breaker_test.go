@@ -0,0 +1,56 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tickets/adapters"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	spreadsheets := &spreadsheetsAPIStub{}
+	breaker := adapters.NewCircuitBreaker("spreadsheets_test", adapters.CircuitBreakerConfig{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         time.Minute,
+		HalfOpenMaxRequests: 1,
+	})
+	api := adapters.NewSpreadsheetsAPIWithCircuitBreaker(spreadsheets, breaker)
+	ctx := context.Background()
+
+	spreadsheets.err = ticketsEntity.NewPermanentError(errors.New("unexpected status code 400"))
+	for range 3 {
+		require.Error(t, api.AppendRow(ctx, "tickets-to-print", nil))
+	}
+	assert.Equal(t, "closed", breaker.State(), "rejected requests should not open the breaker")
+
+	spreadsheets.err = errors.New("unexpected status code 503")
+	for range 2 {
+		require.Error(t, api.AppendRow(ctx, "tickets-to-print", nil))
+	}
+	assert.Equal(t, "open", breaker.State())
+
+	calls := spreadsheets.calls
+	err := api.AppendRow(ctx, "tickets-to-print", nil)
+	assert.ErrorIs(t, err, ticketsEntity.ErrCircuitOpen)
+	assert.Equal(t, calls, spreadsheets.calls, "open breaker should not call the service")
+
+	var circuitOpen ticketsEntity.CircuitOpenError
+	require.ErrorAs(t, err, &circuitOpen)
+	assert.Equal(t, "spreadsheets_test", circuitOpen.Service)
+	assert.InDelta(t, time.Minute, circuitOpen.RetryAfter, float64(time.Second), "should retry when the breaker lets requests through")
+}
+
+type spreadsheetsAPIStub struct {
+	err   error
+	calls int
+}
+
+func (f *spreadsheetsAPIStub) AppendRow(ctx context.Context, spreadsheetName string, row []string) error {
+	f.calls++
+	return f.err
+}
@@ -46,6 +46,9 @@ func (c DeadNationServiceClient) CallDeadNation(
 		// receipt already exists
 		return nil
 	default:
-		return fmt.Errorf("unexpected status code for call Dead Nation: %d", resp.StatusCode())
+		return unexpectedStatusCodeError(
+			resp.StatusCode(),
+			fmt.Errorf("unexpected status code for call Dead Nation: %d", resp.StatusCode()),
+		)
 	}
 }
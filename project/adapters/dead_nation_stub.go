@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"tickets/entities"
+)
+
+type DeadNationServiceStub struct {
+	CallDeadNationFailures Failures
+
+	lock     sync.Mutex
+	bookings []entities.DeadNationBooking
+}
+
+func (s *DeadNationServiceStub) CallDeadNation(ctx context.Context, booking entities.DeadNationBooking) error {
+	if err := s.CallDeadNationFailures.call(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.bookings = append(s.bookings, booking)
+
+	return nil
+}
+
+func (s *DeadNationServiceStub) Bookings() []entities.DeadNationBooking {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.bookings)
+}
@@ -0,0 +1,19 @@
+package adapters
+
+import (
+	"net/http"
+	ticketsEntity "tickets/entities"
+)
+
+// unexpectedStatusCodeError marks errors of requests rejected by the API as permanent,
+// sending the same request again won't change the response.
+func unexpectedStatusCodeError(statusCode int, err error) error {
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return err
+	case statusCode >= 400 && statusCode < 500:
+		return ticketsEntity.NewPermanentError(err)
+	default:
+		return err
+	}
+}
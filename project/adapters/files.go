@@ -39,6 +39,9 @@ func (c FileServiceClient) UpLoadFile(ctx context.Context, ticketFile string, bo
 		log.FromContext(ctx).With("file", ticketFile).Info("file already exists")
 		return nil
 	default:
-		return fmt.Errorf("unexpected status code for POST File Service: %d", resp.StatusCode())
+		return unexpectedStatusCodeError(
+			resp.StatusCode(),
+			fmt.Errorf("unexpected status code for POST File Service: %d", resp.StatusCode()),
+		)
 	}
 }
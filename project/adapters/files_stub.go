@@ -7,11 +7,17 @@ import (
 )
 
 type FilesApiStub struct {
+	UpLoadFileFailures Failures
+
 	lock  sync.Mutex
 	files map[string]string
 }
 
 func (c *FilesApiStub) UpLoadFile(ctx context.Context, ticketFile string, body string) error {
+	if err := c.UpLoadFileFailures.call(); err != nil {
+		return err
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
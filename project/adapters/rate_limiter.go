@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var rateLimiterWaitDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "rate_limiter",
+		Name:      "wait_duration_seconds",
+		Help:      "The time calls waited for the rate limiter before calling the service",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	},
+	[]string{"name"},
+)
+
+type RateLimiterConfig struct {
+	// RequestsPerSecond is the rate at which the bucket is refilled, zero means no limit
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the size of the bucket
+	Burst int `yaml:"burst"`
+}
+
+// DefaultRateLimiterConfigs are limits of external services by name, other services are not limited.
+var DefaultRateLimiterConfigs = map[string]RateLimiterConfig{
+	// the spreadsheets API has a strict quota
+	SpreadsheetsService: {RequestsPerSecond: 10, Burst: 10},
+	// Dead Nation rejects bursts of requests
+	DeadNationService: {RequestsPerSecond: 20, Burst: 1},
+}
+
+// RateLimiter is a token bucket for calls to an external service.
+// Calls over the limit wait, slowing down the handler instead of failing it.
+type RateLimiter struct {
+	name    string
+	limiter *rate.Limiter
+}
+
+func NewRateLimiter(name string, config RateLimiterConfig) *RateLimiter {
+	if name == "" {
+		panic("missing rate limiter name")
+	}
+
+	limit := rate.Inf
+	if config.RequestsPerSecond > 0 {
+		limit = rate.Limit(config.RequestsPerSecond)
+		if config.Burst <= 0 {
+			panic("Burst must be greater than 0")
+		}
+	}
+
+	return &RateLimiter{
+		name:    name,
+		limiter: rate.NewLimiter(limit, config.Burst),
+	}
+}
+
+func (l *RateLimiter) wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.limiter.Wait(ctx)
+	rateLimiterWaitDuration.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("%s rate limiter: %w", l.name, err)
+	}
+
+	return nil
+}
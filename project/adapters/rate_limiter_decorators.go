@@ -0,0 +1,213 @@
+package adapters
+
+import (
+	"context"
+	ticketsEntity "tickets/entities"
+)
+
+type SpreadsheetsAPIWithRateLimiter struct {
+	next    spreadsheetsAPI
+	limiter *RateLimiter
+}
+
+func NewSpreadsheetsAPIWithRateLimiter(
+	next spreadsheetsAPI,
+	limiter *RateLimiter,
+) SpreadsheetsAPIWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return SpreadsheetsAPIWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (s SpreadsheetsAPIWithRateLimiter) AppendRow(ctx context.Context, spreadsheetName string, row []string) error {
+	if err := s.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return s.next.AppendRow(ctx, spreadsheetName, row)
+}
+
+type ReceiptsServiceWithRateLimiter struct {
+	next    receiptsService
+	limiter *RateLimiter
+}
+
+func NewReceiptsServiceWithRateLimiter(
+	next receiptsService,
+	limiter *RateLimiter,
+) ReceiptsServiceWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return ReceiptsServiceWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (s ReceiptsServiceWithRateLimiter) IssueReceipt(
+	ctx context.Context,
+	request ticketsEntity.IssueReceiptRequest,
+) (ticketsEntity.IssueReceiptResponse, error) {
+	if err := s.limiter.wait(ctx); err != nil {
+		return ticketsEntity.IssueReceiptResponse{}, err
+	}
+
+	return s.next.IssueReceipt(ctx, request)
+}
+
+func (s ReceiptsServiceWithRateLimiter) RefundReceipt(
+	ctx context.Context,
+	command ticketsEntity.RefundTicket,
+) error {
+	if err := s.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return s.next.RefundReceipt(ctx, command)
+}
+
+type FileServiceWithRateLimiter struct {
+	next    filesService
+	limiter *RateLimiter
+}
+
+func NewFileServiceWithRateLimiter(
+	next filesService,
+	limiter *RateLimiter,
+) FileServiceWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return FileServiceWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (s FileServiceWithRateLimiter) UpLoadFile(ctx context.Context, ticketFile string, body string) error {
+	if err := s.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return s.next.UpLoadFile(ctx, ticketFile, body)
+}
+
+type PaymentsServiceWithRateLimiter struct {
+	next    paymentsService
+	limiter *RateLimiter
+}
+
+func NewPaymentsServiceWithRateLimiter(
+	next paymentsService,
+	limiter *RateLimiter,
+) PaymentsServiceWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return PaymentsServiceWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (s PaymentsServiceWithRateLimiter) RefundPayment(
+	ctx context.Context,
+	refundPayment ticketsEntity.PaymentRefund,
+) error {
+	if err := s.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return s.next.RefundPayment(ctx, refundPayment)
+}
+
+type DeadNationServiceWithRateLimiter struct {
+	next    deadNationService
+	limiter *RateLimiter
+}
+
+func NewDeadNationServiceWithRateLimiter(
+	next deadNationService,
+	limiter *RateLimiter,
+) DeadNationServiceWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return DeadNationServiceWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (s DeadNationServiceWithRateLimiter) CallDeadNation(
+	ctx context.Context,
+	booking ticketsEntity.DeadNationBooking,
+) error {
+	if err := s.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return s.next.CallDeadNation(ctx, booking)
+}
+
+type TransportationWithRateLimiter struct {
+	next    transportationService
+	limiter *RateLimiter
+}
+
+func NewTransportationWithRateLimiter(
+	next transportationService,
+	limiter *RateLimiter,
+) TransportationWithRateLimiter {
+	if next == nil {
+		panic("next is nil")
+	}
+	if limiter == nil {
+		panic("limiter is nil")
+	}
+
+	return TransportationWithRateLimiter{next: next, limiter: limiter}
+}
+
+func (t TransportationWithRateLimiter) BookFlight(
+	ctx context.Context,
+	request ticketsEntity.BookFlightTicketRequest,
+) (ticketsEntity.BookFlightTicketResponse, error) {
+	if err := t.limiter.wait(ctx); err != nil {
+		return ticketsEntity.BookFlightTicketResponse{}, err
+	}
+
+	return t.next.BookFlight(ctx, request)
+}
+
+func (t TransportationWithRateLimiter) BookTaxi(
+	ctx context.Context,
+	request ticketsEntity.BookTaxiRequest,
+) (ticketsEntity.BookTaxiResponse, error) {
+	if err := t.limiter.wait(ctx); err != nil {
+		return ticketsEntity.BookTaxiResponse{}, err
+	}
+
+	return t.next.BookTaxi(ctx, request)
+}
+
+func (t TransportationWithRateLimiter) CancelFlightTickets(
+	ctx context.Context,
+	request ticketsEntity.CancelFlightTicketsRequest,
+) error {
+	if err := t.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	return t.next.CancelFlightTickets(ctx, request)
+}
@@ -0,0 +1,36 @@
+package adapters_test
+
+import (
+	"context"
+	"testing"
+	"tickets/adapters"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	spreadsheets := &spreadsheetsAPIStub{}
+	api := adapters.NewSpreadsheetsAPIWithRateLimiter(
+		spreadsheets,
+		adapters.NewRateLimiter("spreadsheets_test", adapters.RateLimiterConfig{
+			RequestsPerSecond: 20,
+			Burst:             1,
+		}),
+	)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, api.AppendRow(ctx, "tickets-to-print", nil), "calls over the limit should wait instead of failing")
+	}
+
+	assert.Equal(t, 3, spreadsheets.calls)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, api.AppendRow(canceledCtx, "tickets-to-print", nil))
+	assert.Equal(t, 3, spreadsheets.calls)
+}
@@ -44,7 +44,10 @@ func (c ReceiptsServiceClient) RefundReceipt(
 		// receipt was created
 		return nil
 	default:
-		return fmt.Errorf("unexpected status code for refund receipt: %d", resp.StatusCode())
+		return unexpectedStatusCodeError(
+			resp.StatusCode(),
+			fmt.Errorf("unexpected status code for refund receipt: %d", resp.StatusCode()),
+		)
 	}
 }
 
@@ -79,6 +82,9 @@ func (c ReceiptsServiceClient) IssueReceipt(
 			IssuedAt:      resp.JSON201.IssuedAt,
 		}, nil
 	default:
-		return ticketsEntity.IssueReceiptResponse{}, fmt.Errorf("unexpected status code for POST receipts-api/receipts: %d", resp.StatusCode())
+		return ticketsEntity.IssueReceiptResponse{}, unexpectedStatusCodeError(
+			resp.StatusCode(),
+			fmt.Errorf("unexpected status code for POST receipts-api/receipts: %d", resp.StatusCode()),
+		)
 	}
 }
@@ -2,21 +2,63 @@ package adapters
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"tickets/entities"
+	"time"
 )
 
 type ReceiptsServiceStub struct {
-	lock           sync.Mutex
-	IssuedReceipts map[string]entities.IssueReceiptRequest
+	IssueReceiptFailures  Failures
+	RefundReceiptFailures Failures
+
+	lock             sync.Mutex
+	issuedReceipts   []entities.IssueReceiptRequest
+	refundedReceipts []entities.RefundTicket
 }
 
 func (s *ReceiptsServiceStub) IssueReceipt(
 	ctx context.Context,
 	request entities.IssueReceiptRequest,
-) error {
+) (entities.IssueReceiptResponse, error) {
+	if err := s.IssueReceiptFailures.call(); err != nil {
+		return entities.IssueReceiptResponse{}, err
+	}
+
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.IssuedReceipts[request.TicketID] = request
+
+	s.issuedReceipts = append(s.issuedReceipts, request)
+
+	return entities.IssueReceiptResponse{
+		ReceiptNumber: "mocked-receipt-number",
+		IssuedAt:      time.Now(),
+	}, nil
+}
+
+func (s *ReceiptsServiceStub) RefundReceipt(ctx context.Context, command entities.RefundTicket) error {
+	if err := s.RefundReceiptFailures.call(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.refundedReceipts = append(s.refundedReceipts, command)
+
 	return nil
 }
+
+func (s *ReceiptsServiceStub) IssuedReceipts() []entities.IssueReceiptRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.issuedReceipts)
+}
+
+func (s *ReceiptsServiceStub) RefundedReceipts() []entities.RefundTicket {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.refundedReceipts)
+}
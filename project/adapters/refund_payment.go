@@ -36,7 +36,10 @@ func (c PaymentsServiceClient) RefundPayment(ctx context.Context, refundPayment
 	}
 
 	if resp.StatusCode() != http.StatusOK {
-		return fmt.Errorf("unexpected for /payments-api/refunds status code: %d", resp.StatusCode())
+		return unexpectedStatusCodeError(
+			resp.StatusCode(),
+			fmt.Errorf("unexpected for /payments-api/refunds status code: %d", resp.StatusCode()),
+		)
 	}
 
 	return nil
@@ -0,0 +1,35 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"tickets/entities"
+)
+
+type PaymentsServiceStub struct {
+	RefundPaymentFailures Failures
+
+	lock    sync.Mutex
+	refunds []entities.PaymentRefund
+}
+
+func (s *PaymentsServiceStub) RefundPayment(ctx context.Context, refundPayment entities.PaymentRefund) error {
+	if err := s.RefundPaymentFailures.call(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.refunds = append(s.refunds, refundPayment)
+
+	return nil
+}
+
+func (s *PaymentsServiceStub) Refunds() []entities.PaymentRefund {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.refunds)
+}
@@ -0,0 +1,20 @@
+package adapters
+
+// Names of external services, used by circuit breakers and rate limiters.
+const (
+	SpreadsheetsService   = "spreadsheets"
+	ReceiptsService       = "receipts"
+	FilesService          = "files"
+	DeadNationService     = "dead_nation"
+	PaymentsService       = "payments"
+	TransportationService = "transportation"
+)
+
+var Services = []string{
+	SpreadsheetsService,
+	ReceiptsService,
+	FilesService,
+	DeadNationService,
+	PaymentsService,
+	TransportationService,
+}
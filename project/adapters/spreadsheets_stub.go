@@ -2,12 +2,15 @@ package adapters
 
 import (
 	"context"
+	"slices"
 	"sync"
 )
 
 type SpreadsheetsAPIStub struct {
+	AppendRowFailures Failures
+
 	lock sync.Mutex
-	Rows map[string][][]string
+	rows map[string][][]string
 }
 
 func (c *SpreadsheetsAPIStub) AppendRow(
@@ -15,14 +18,26 @@ func (c *SpreadsheetsAPIStub) AppendRow(
 	sheetName string,
 	row []string,
 ) error {
+	if err := c.AppendRowFailures.call(); err != nil {
+		return err
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	if c.Rows == nil {
-		c.Rows = make(map[string][][]string)
+	if c.rows == nil {
+		c.rows = make(map[string][][]string)
 	}
 
-	c.Rows[sheetName] = append(c.Rows[sheetName], row)
+	c.rows[sheetName] = append(c.rows[sheetName], row)
 
 	return nil
 }
+
+// Rows returns the rows appended to the sheet, nil if nothing was appended.
+func (c *SpreadsheetsAPIStub) Rows(sheetName string) [][]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return slices.Clone(c.rows[sheetName])
+}
@@ -0,0 +1,61 @@
+package adapters
+
+import "sync"
+
+// Failures scripts the errors returned by a stub method, so tests can exercise
+// the retry and compensation paths without a real service. Calls are counted from 1.
+type Failures struct {
+	lock     sync.Mutex
+	calls    int
+	nthCalls map[int]error
+	allCalls error
+}
+
+// FailCall makes the nth call of the method return err.
+func (f *Failures) FailCall(n int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.nthCalls == nil {
+		f.nthCalls = make(map[int]error)
+	}
+	f.nthCalls[n] = err
+}
+
+// FailAllCalls makes every following call of the method return err.
+func (f *Failures) FailAllCalls(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.allCalls = err
+}
+
+// Reset removes the scripted failures, calls made so far are still counted.
+func (f *Failures) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.nthCalls = nil
+	f.allCalls = nil
+}
+
+// Calls returns how many times the method was called, failed calls included.
+func (f *Failures) Calls() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.calls
+}
+
+func (f *Failures) call() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.calls++
+
+	if err, ok := f.nthCalls[f.calls]; ok {
+		return err
+	}
+
+	return f.allCalls
+}
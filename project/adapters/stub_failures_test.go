@@ -0,0 +1,35 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tickets/adapters"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailures(t *testing.T) {
+	ctx := context.Background()
+	serviceUnavailable := errors.New("unexpected status code 503")
+	spreadsheets := &adapters.SpreadsheetsAPIStub{}
+
+	spreadsheets.AppendRowFailures.FailCall(2, serviceUnavailable)
+	assert.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"1"}))
+	assert.ErrorIs(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"2"}), serviceUnavailable)
+	assert.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"3"}))
+
+	spreadsheets.AppendRowFailures.FailAllCalls(serviceUnavailable)
+	assert.ErrorIs(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"4"}), serviceUnavailable)
+
+	spreadsheets.AppendRowFailures.Reset()
+	assert.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"5"}))
+
+	assert.Equal(t, 5, spreadsheets.AppendRowFailures.Calls())
+	assert.Equal(
+		t,
+		[][]string{{"1"}, {"3"}, {"5"}},
+		spreadsheets.Rows("tickets-to-print"),
+		"failed calls should not append rows",
+	)
+}
@@ -50,7 +50,7 @@ func (t TransportationClient) BookFlight(
 
 	switch resp.StatusCode() {
 	case http.StatusConflict:
-		return entities.BookFlightTicketResponse{}, ErrNoFlightTicketsAvailable
+		return entities.BookFlightTicketResponse{}, entities.NewPermanentError(ErrNoFlightTicketsAvailable)
 	case http.StatusCreated:
 		return entities.BookFlightTicketResponse{
 			TicketIds: lo.Map(
@@ -60,9 +60,12 @@ func (t TransportationClient) BookFlight(
 			),
 		}, nil
 	default:
-		return entities.BookFlightTicketResponse{}, fmt.Errorf(
-			"unexpected status code for PUT transportation-api/transportation/flight-tickets: %d",
+		return entities.BookFlightTicketResponse{}, unexpectedStatusCodeError(
 			resp.StatusCode(),
+			fmt.Errorf(
+				"unexpected status code for PUT transportation-api/transportation/flight-tickets: %d",
+				resp.StatusCode(),
+			),
 		)
 	}
 }
@@ -86,7 +89,7 @@ func (t TransportationClient) BookTaxi(
 
 	switch resp.StatusCode() {
 	case http.StatusConflict:
-		return entities.BookTaxiResponse{}, ErrWhileBookingTaxi
+		return entities.BookTaxiResponse{}, entities.NewPermanentError(ErrWhileBookingTaxi)
 
 	case http.StatusCreated:
 		return entities.BookTaxiResponse{
@@ -94,9 +97,12 @@ func (t TransportationClient) BookTaxi(
 		}, nil
 
 	default:
-		return entities.BookTaxiResponse{}, fmt.Errorf(
-			"unexpected status code for PUT transportation-api/transportation/taxi-tickets: %d",
+		return entities.BookTaxiResponse{}, unexpectedStatusCodeError(
 			resp.StatusCode(),
+			fmt.Errorf(
+				"unexpected status code for PUT transportation-api/transportation/taxi-tickets: %d",
+				resp.StatusCode(),
+			),
 		)
 	}
 }
@@ -115,10 +121,13 @@ func (t TransportationClient) CancelFlightTickets(
 		case http.StatusNoContent:
 			continue
 		default:
-			return fmt.Errorf(
-				"unexpected status code for DELETE transportation-api/transportation/flight-tickets for ticket %s: %d",
-				ticketID,
+			return unexpectedStatusCodeError(
 				resp.StatusCode(),
+				fmt.Errorf(
+					"unexpected status code for DELETE transportation-api/transportation/flight-tickets for ticket %s: %d",
+					ticketID,
+					resp.StatusCode(),
+				),
 			)
 		}
 	}
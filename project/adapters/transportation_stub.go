@@ -0,0 +1,131 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"tickets/entities"
+
+	"github.com/google/uuid"
+)
+
+type TransportationServiceStub struct {
+	BookFlightFailures          Failures
+	BookTaxiFailures            Failures
+	CancelFlightTicketsFailures Failures
+
+	lock                  sync.Mutex
+	soldOutFlights        map[uuid.UUID]struct{}
+	flightBookings        []entities.BookFlightTicketRequest
+	flightTickets         map[uuid.UUID][]uuid.UUID
+	taxiBookings          []entities.BookTaxiRequest
+	canceledFlightTickets []uuid.UUID
+}
+
+// SoldOutFlight makes bookings of the flight fail the same way the API rejects them with 409 Conflict.
+func (s *TransportationServiceStub) SoldOutFlight(flightID uuid.UUID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.soldOutFlights == nil {
+		s.soldOutFlights = make(map[uuid.UUID]struct{})
+	}
+	s.soldOutFlights[flightID] = struct{}{}
+}
+
+// NoTaxisAvailable makes taxi bookings fail the same way the API rejects them with 409 Conflict.
+func (s *TransportationServiceStub) NoTaxisAvailable() {
+	s.BookTaxiFailures.FailAllCalls(entities.NewPermanentError(ErrWhileBookingTaxi))
+}
+
+func (s *TransportationServiceStub) BookFlight(
+	ctx context.Context,
+	request entities.BookFlightTicketRequest,
+) (entities.BookFlightTicketResponse, error) {
+	if err := s.BookFlightFailures.call(); err != nil {
+		return entities.BookFlightTicketResponse{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.soldOutFlights[request.FlightID]; ok {
+		return entities.BookFlightTicketResponse{}, entities.NewPermanentError(ErrNoFlightTicketsAvailable)
+	}
+
+	ticketIDs := make([]uuid.UUID, len(request.PassengerNames))
+	for i := range ticketIDs {
+		ticketIDs[i] = uuid.New()
+	}
+
+	if s.flightTickets == nil {
+		s.flightTickets = make(map[uuid.UUID][]uuid.UUID)
+	}
+	s.flightBookings = append(s.flightBookings, request)
+	s.flightTickets[request.FlightID] = append(s.flightTickets[request.FlightID], ticketIDs...)
+
+	return entities.BookFlightTicketResponse{TicketIds: ticketIDs}, nil
+}
+
+func (s *TransportationServiceStub) BookTaxi(
+	ctx context.Context,
+	request entities.BookTaxiRequest,
+) (entities.BookTaxiResponse, error) {
+	if err := s.BookTaxiFailures.call(); err != nil {
+		return entities.BookTaxiResponse{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.taxiBookings = append(s.taxiBookings, request)
+
+	return entities.BookTaxiResponse{TaxiBookingId: uuid.New()}, nil
+}
+
+func (s *TransportationServiceStub) CancelFlightTickets(
+	ctx context.Context,
+	request entities.CancelFlightTicketsRequest,
+) error {
+	if err := s.CancelFlightTicketsFailures.call(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.canceledFlightTickets = append(s.canceledFlightTickets, request.TicketIds...)
+
+	return nil
+}
+
+// FlightBookings returns the successful flight bookings.
+func (s *TransportationServiceStub) FlightBookings() []entities.BookFlightTicketRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.flightBookings)
+}
+
+// FlightTickets returns the IDs of all tickets booked for the flight.
+func (s *TransportationServiceStub) FlightTickets(flightID uuid.UUID) []uuid.UUID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.flightTickets[flightID])
+}
+
+// TaxiBookings returns the successful taxi bookings.
+func (s *TransportationServiceStub) TaxiBookings() []entities.BookTaxiRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.taxiBookings)
+}
+
+func (s *TransportationServiceStub) CanceledFlightTickets() []uuid.UUID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return slices.Clone(s.canceledFlightTickets)
+}
@@ -0,0 +1,46 @@
+// Command gateway runs a local stand-in for the gateway of the external services.
+// Point GATEWAY_ADDR of the service at it to work without network access.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+
+	"tickets/gateway"
+)
+
+func main() {
+	log.Init(slog.LevelInfo)
+
+	flags := flag.NewFlagSet("gateway", flag.ExitOnError)
+	addr := flags.String("addr", envOrDefault("GATEWAY_LISTEN_ADDR", ":8888"), "address to listen on")
+	solutionURL := flags.String(
+		"solution-url",
+		envOrDefault("SOLUTION_BASE_URL", "http://localhost:8080/"),
+		"base URL of the service receiving webhooks, empty disables webhooks",
+	)
+	_ = flags.Parse(os.Args[1:])
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	log.FromContext(ctx).With("addr", *addr, "solution_url", *solutionURL).Info("Starting gateway")
+
+	err := gateway.NewServer(*solutionURL).Run(ctx, *addr)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func envOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"tickets/adapters"
+	ticketsMessage "tickets/message"
+	"tickets/message/broker"
+	ticketsOutbox "tickets/message/outbox"
+	"time"
+)
+
+type Config struct {
+	// InMemory runs the service without Postgres and the broker, all state is lost on restart
+	InMemory bool `yaml:"in_memory"`
+
+	PostgresURL string        `yaml:"postgres_url"`
+	Broker      broker.Config `yaml:"broker"`
+	GatewayAddr string        `yaml:"gateway_addr"`
+	// JaegerEndpoint defaults to the Jaeger API exposed by the gateway
+	JaegerEndpoint string `yaml:"jaeger_endpoint"`
+	HTTPAddr       string `yaml:"http_addr"`
+
+	ConsumerGroups ConsumerGroups `yaml:"consumer_groups"`
+	Outbox         Outbox         `yaml:"outbox"`
+	VipBundle      VipBundle      `yaml:"vip_bundle"`
+
+	// CircuitBreakers are circuit breakers of external services by the service name
+	CircuitBreakers map[string]adapters.CircuitBreakerConfig `yaml:"circuit_breakers"`
+	// RateLimits are limits of calls to external services by the service name
+	RateLimits map[string]adapters.RateLimiterConfig `yaml:"rate_limits"`
+	// RetryPolicies are retry policies of message handlers by the handler name, a configured policy replaces
+	// the default one of the handler as a whole
+	RetryPolicies ticketsMessage.RetryPolicies `yaml:"retry_policies"`
+}
+
+type ConsumerGroups struct {
+	EventsSplitter string `yaml:"events_splitter"`
+	EventsStore    string `yaml:"events_store"`
+	// EventHandlersPrefix is followed by the handler name
+	EventHandlersPrefix string `yaml:"event_handlers_prefix"`
+	// CommandHandlersPrefix is followed by the handler name
+	CommandHandlersPrefix string `yaml:"command_handlers_prefix"`
+}
+
+type Outbox struct {
+	// Retention of forwarded messages, zero disables the cleanup
+	Retention time.Duration `yaml:"retention"`
+}
+
+type VipBundle struct {
+	// StepTimeout is how long each step of the bundle can take before the bundle is compensated
+	StepTimeout            time.Duration `yaml:"step_timeout"`
+	DeadlinesCheckInterval time.Duration `yaml:"deadlines_check_interval"`
+}
+
+func Default() Config {
+	return Config{
+		HTTPAddr: ":8080",
+		Broker: broker.Config{
+			Kind: broker.KindRedis,
+		},
+		ConsumerGroups: ConsumerGroups{
+			EventsSplitter:        "events_splitter",
+			EventsStore:           "events_store",
+			EventHandlersPrefix:   "svc-tickets.events.",
+			CommandHandlersPrefix: "svc-tickets.commands.",
+		},
+		Outbox: Outbox{
+			Retention: ticketsOutbox.DefaultRetention,
+		},
+		VipBundle: VipBundle{
+			StepTimeout:            10 * time.Minute,
+			DeadlinesCheckInterval: 10 * time.Second,
+		},
+		CircuitBreakers: maps.Clone(adapters.DefaultCircuitBreakerConfigs),
+		RateLimits:      maps.Clone(adapters.DefaultRateLimiterConfigs),
+		RetryPolicies:   ticketsMessage.DefaultRetryPolicies(),
+	}
+}
+
+// TracingEndpoint is empty when traces are not exported.
+func (c Config) TracingEndpoint() string {
+	if c.JaegerEndpoint != "" {
+		return c.JaegerEndpoint
+	}
+	if c.GatewayAddr == "" {
+		return ""
+	}
+
+	return c.GatewayAddr + "/jaeger-api/api/traces"
+}
+
+// Validate checks the config needed to run the service.
+func (c Config) Validate() error {
+	var errs []error
+
+	if !c.InMemory {
+		errs = append(errs, c.ValidateDatabase())
+		errs = append(errs, c.validateBroker())
+	}
+	if c.GatewayAddr == "" {
+		errs = append(errs, errors.New("gateway_addr (GATEWAY_ADDR) is required"))
+	}
+	if c.HTTPAddr == "" {
+		errs = append(errs, errors.New("http_addr (HTTP_ADDR) is required"))
+	}
+
+	if c.ConsumerGroups.EventsSplitter == "" || c.ConsumerGroups.EventsStore == "" ||
+		c.ConsumerGroups.EventHandlersPrefix == "" || c.ConsumerGroups.CommandHandlersPrefix == "" {
+		errs = append(errs, errors.New("consumer_groups can't be empty"))
+	}
+	if c.ConsumerGroups.EventsSplitter == c.ConsumerGroups.EventsStore {
+		// both consume the same topic, they would split the messages between themselves
+		errs = append(errs, errors.New("consumer_groups.events_splitter and consumer_groups.events_store must be different"))
+	}
+
+	if c.Outbox.Retention < 0 {
+		errs = append(errs, errors.New("outbox.retention (OUTBOX_RETENTION) can't be negative"))
+	}
+
+	if c.VipBundle.StepTimeout <= 0 {
+		errs = append(errs, errors.New("vip_bundle.step_timeout (VIP_BUNDLE_STEP_TIMEOUT) must be positive"))
+	}
+	if c.VipBundle.DeadlinesCheckInterval <= 0 {
+		errs = append(errs, errors.New("vip_bundle.deadlines_check_interval (VIP_BUNDLE_DEADLINES_CHECK_INTERVAL) must be positive"))
+	}
+
+	for _, service := range adapters.Services {
+		breaker, ok := c.CircuitBreakers[service]
+		if !ok {
+			errs = append(errs, fmt.Errorf("circuit_breakers.%s is required", service))
+			continue
+		}
+		if breaker.ConsecutiveFailures == 0 {
+			errs = append(errs, fmt.Errorf("circuit_breakers.%s.consecutive_failures must be positive", service))
+		}
+		if breaker.OpenTimeout < 0 {
+			errs = append(errs, fmt.Errorf("circuit_breakers.%s.open_timeout can't be negative", service))
+		}
+	}
+	for _, service := range slices.Sorted(maps.Keys(c.CircuitBreakers)) {
+		if !slices.Contains(adapters.Services, service) {
+			errs = append(errs, fmt.Errorf("circuit_breakers: unknown service %q, expected one of %v", service, adapters.Services))
+		}
+	}
+
+	for _, service := range slices.Sorted(maps.Keys(c.RateLimits)) {
+		limit := c.RateLimits[service]
+		if !slices.Contains(adapters.Services, service) {
+			errs = append(errs, fmt.Errorf("rate_limits: unknown service %q, expected one of %v", service, adapters.Services))
+			continue
+		}
+		if limit.RequestsPerSecond < 0 {
+			errs = append(errs, fmt.Errorf("rate_limits.%s.requests_per_second can't be negative", service))
+		}
+		if limit.RequestsPerSecond > 0 && limit.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("rate_limits.%s.burst must be positive", service))
+		}
+	}
+
+	for _, handler := range slices.Sorted(maps.Keys(c.RetryPolicies)) {
+		policy := c.RetryPolicies[handler]
+		if policy.MaxRetries < 0 {
+			errs = append(errs, fmt.Errorf("retry_policies.%s.max_retries can't be negative", handler))
+		}
+		if policy.InitialInterval <= 0 {
+			errs = append(errs, fmt.Errorf("retry_policies.%s.initial_interval must be positive", handler))
+		}
+		if policy.MaxInterval < policy.InitialInterval {
+			errs = append(errs, fmt.Errorf("retry_policies.%s.max_interval can't be less than initial_interval", handler))
+		}
+		if policy.Multiplier < 1 {
+			errs = append(errs, fmt.Errorf("retry_policies.%s.multiplier must be at least 1", handler))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) validateBroker() error {
+	switch c.Broker.Kind {
+	case broker.KindRedis:
+		if c.Broker.RedisAddr == "" {
+			return errors.New("broker.redis_addr (REDIS_ADDR) is required")
+		}
+	case broker.KindKafka:
+		if len(c.Broker.KafkaBrokers) == 0 {
+			return errors.New("broker.kafka_brokers (KAFKA_BROKERS) is required")
+		}
+	case broker.KindSQL:
+		// uses postgres_url
+	default:
+		return fmt.Errorf("broker.kind (BROKER) must be one of %v, got %q", broker.Kinds, c.Broker.Kind)
+	}
+
+	return nil
+}
+
+// ValidateDatabase checks the config needed by commands that only use the database.
+func (c Config) ValidateDatabase() error {
+	if c.PostgresURL == "" {
+		return errors.New("postgres_url (POSTGRES_URL) is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"tickets/config"
+	ticketsMessage "tickets/message"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte(`
+postgres_url: postgres://file
+broker:
+  redis_addr: file:6379
+gateway_addr: http://file
+outbox:
+  retention: 24h
+vip_bundle:
+  step_timeout: 30m
+rate_limits:
+  receipts:
+    requests_per_second: 5
+    burst: 2
+circuit_breakers:
+  payments:
+    consecutive_failures: 3
+    open_timeout: 5s
+    half_open_max_requests: 2
+retry_policies:
+  IssueReceipt:
+    max_retries: 3
+    initial_interval: 1s
+    max_interval: 5s
+    multiplier: 1.5
+`), 0o644)
+	require.NoError(t, err)
+
+	env := map[string]string{
+		"CONFIG_FILE":                           configFile,
+		"REDIS_ADDR":                            "env:6379",
+		"GATEWAY_ADDR":                          "http://env",
+		"PAYMENTS_CIRCUIT_BREAKER_OPEN_TIMEOUT": "15s",
+	}
+
+	cfg, args, err := config.Load(
+		[]string{"-gateway-addr", "http://flag", "migrate", "up"},
+		func(name string) string { return env[name] },
+	)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	assert.Equal(t, "postgres://file", cfg.PostgresURL)
+	assert.Equal(t, "redis", cfg.Broker.Kind)
+	assert.Equal(t, "env:6379", cfg.Broker.RedisAddr)
+	assert.Equal(t, "http://flag", cfg.GatewayAddr)
+	assert.Equal(t, "http://flag/jaeger-api/api/traces", cfg.TracingEndpoint())
+	assert.Equal(t, 24*time.Hour, cfg.Outbox.Retention)
+	assert.Equal(t, 30*time.Minute, cfg.VipBundle.StepTimeout)
+	assert.Equal(t, 5.0, cfg.RateLimits["receipts"].RequestsPerSecond)
+	assert.Equal(t, ":8080", cfg.HTTPAddr, "defaults should be kept")
+	assert.Contains(t, cfg.RateLimits, "spreadsheets", "defaults should be kept")
+	assert.Equal(t, uint32(3), cfg.CircuitBreakers["payments"].ConsecutiveFailures)
+	assert.Equal(t, 15*time.Second, cfg.CircuitBreakers["payments"].OpenTimeout)
+	assert.Equal(t, 10*time.Second, cfg.CircuitBreakers["transportation"].OpenTimeout, "defaults should be kept")
+	assert.Equal(t, ticketsMessage.RetryPolicy{
+		MaxRetries:      3,
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      1.5,
+	}, cfg.RetryPolicies.ForHandler("IssueReceipt"))
+	assert.Equal(
+		t,
+		ticketsMessage.DefaultRetryPolicies()["BookFlight"],
+		cfg.RetryPolicies.ForHandler("BookFlight"),
+		"defaults should be kept",
+	)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	env := map[string]string{
+		"OUTBOX_RETENTION":                           "-1h",
+		"VIP_BUNDLE_STEP_TIMEOUT":                    "0s",
+		"DEAD_NATION_RATE_LIMIT_BURST":               "0",
+		"FILES_CIRCUIT_BREAKER_CONSECUTIVE_FAILURES": "0",
+	}
+
+	cfg, _, err := config.Load(nil, func(name string) string { return env[name] })
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	for _, expected := range []string{
+		"postgres_url (POSTGRES_URL) is required",
+		"broker.redis_addr (REDIS_ADDR) is required",
+		"gateway_addr (GATEWAY_ADDR) is required",
+		"outbox.retention (OUTBOX_RETENTION) can't be negative",
+		"vip_bundle.step_timeout (VIP_BUNDLE_STEP_TIMEOUT) must be positive",
+		"rate_limits.dead_nation.burst must be positive",
+		"circuit_breakers.files.consecutive_failures must be positive",
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+
+	cfg, _, err = config.Load(nil, func(string) string { return "" })
+	require.NoError(t, err)
+	cfg.RetryPolicies["BookTaxi"] = ticketsMessage.RetryPolicy{MaxRetries: 3}
+	assert.ErrorContains(t, cfg.Validate(), "retry_policies.BookTaxi.initial_interval must be positive")
+
+	cfg, _, err = config.Load([]string{"-broker", "kafka"}, func(name string) string { return env[name] })
+	require.NoError(t, err)
+	assert.ErrorContains(t, cfg.Validate(), "broker.kafka_brokers (KAFKA_BROKERS) is required")
+
+	cfg, _, err = config.Load([]string{"-in-memory", "-gateway-addr", "http://localhost:8888"}, func(string) string { return "" })
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate(), "postgres and the broker are not needed in memory")
+
+	_, _, err = config.Load(nil, func(name string) string {
+		return map[string]string{"RECEIPTS_CIRCUIT_BREAKER_OPEN_TIMEOUT": "soon"}[name]
+	})
+	assert.ErrorContains(t, err, "invalid RECEIPTS_CIRCUIT_BREAKER_OPEN_TIMEOUT")
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"tickets/adapters"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads the config from the YAML file, environment variables and flags, each overriding the previous one.
+// The file is set with the -config flag or CONFIG_FILE. Arguments after the flags (like subcommands) are returned.
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	flags := flag.NewFlagSet("tickets", flag.ContinueOnError)
+	configFile := flags.String("config", getenv("CONFIG_FILE"), "path to the YAML config file")
+	inMemory := flags.Bool("in-memory", false, "run without Postgres and the message broker, keeping all state in memory")
+	postgresURL := flags.String("postgres-url", "", "PostgreSQL connection URL")
+	brokerKind := flags.String("broker", "", "message broker, one of redis, kafka or sql")
+	redisAddr := flags.String("redis-addr", "", "Redis address")
+	gatewayAddr := flags.String("gateway-addr", "", "gateway address")
+	jaegerEndpoint := flags.String("jaeger-endpoint", "", "Jaeger traces endpoint")
+	httpAddr := flags.String("http-addr", "", "address of the HTTP server")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	cfg := Default()
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(getenv); err != nil {
+		return Config{}, nil, err
+	}
+
+	// only flags that were passed override the config
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "in-memory":
+			cfg.InMemory = *inMemory
+		case "postgres-url":
+			cfg.PostgresURL = *postgresURL
+		case "broker":
+			cfg.Broker.Kind = *brokerKind
+		case "redis-addr":
+			cfg.Broker.RedisAddr = *redisAddr
+		case "gateway-addr":
+			cfg.GatewayAddr = *gatewayAddr
+		case "jaeger-endpoint":
+			cfg.JaegerEndpoint = *jaegerEndpoint
+		case "http-addr":
+			cfg.HTTPAddr = *httpAddr
+		}
+	})
+
+	return cfg, flags.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv(getenv func(string) string) error {
+	var errs []error
+
+	setString := func(name string, target *string) {
+		if value := getenv(name); value != "" {
+			*target = value
+		}
+	}
+	setDuration := func(name string, target *time.Duration) {
+		value := getenv(name)
+		if value == "" {
+			return
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+			return
+		}
+		*target = parsed
+	}
+
+	if value := getenv("IN_MEMORY"); value != "" {
+		inMemory, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid IN_MEMORY: %w", err))
+		}
+		c.InMemory = inMemory
+	}
+	setString("POSTGRES_URL", &c.PostgresURL)
+	setString("BROKER", &c.Broker.Kind)
+	setString("REDIS_ADDR", &c.Broker.RedisAddr)
+	if value := getenv("KAFKA_BROKERS"); value != "" {
+		c.Broker.KafkaBrokers = strings.Split(value, ",")
+	}
+	setString("GATEWAY_ADDR", &c.GatewayAddr)
+	setString("JAEGER_ENDPOINT", &c.JaegerEndpoint)
+	setString("HTTP_ADDR", &c.HTTPAddr)
+
+	setDuration("OUTBOX_RETENTION", &c.Outbox.Retention)
+	setDuration("VIP_BUNDLE_STEP_TIMEOUT", &c.VipBundle.StepTimeout)
+	setDuration("VIP_BUNDLE_DEADLINES_CHECK_INTERVAL", &c.VipBundle.DeadlinesCheckInterval)
+
+	// <SERVICE>_CIRCUIT_BREAKER_CONSECUTIVE_FAILURES and <SERVICE>_CIRCUIT_BREAKER_OPEN_TIMEOUT
+	for _, service := range adapters.Services {
+		name := strings.ToUpper(service) + "_CIRCUIT_BREAKER"
+		configured, ok := c.CircuitBreakers[service]
+		if !ok {
+			configured = adapters.DefaultCircuitBreakerConfig
+		}
+		breaker := configured
+
+		if value := getenv(name + "_CONSECUTIVE_FAILURES"); value != "" {
+			failures, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s_CONSECUTIVE_FAILURES: %w", name, err))
+			}
+			breaker.ConsecutiveFailures = uint32(failures)
+		}
+		setDuration(name+"_OPEN_TIMEOUT", &breaker.OpenTimeout)
+
+		if breaker != configured {
+			if c.CircuitBreakers == nil {
+				c.CircuitBreakers = map[string]adapters.CircuitBreakerConfig{}
+			}
+			c.CircuitBreakers[service] = breaker
+		}
+	}
+
+	// <SERVICE>_RATE_LIMIT (requests per second) and <SERVICE>_RATE_LIMIT_BURST
+	for _, service := range adapters.Services {
+		name := strings.ToUpper(service) + "_RATE_LIMIT"
+		limit := c.RateLimits[service]
+
+		if value := getenv(name); value != "" {
+			requestsPerSecond, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+			}
+			limit.RequestsPerSecond = requestsPerSecond
+			if limit.Burst == 0 {
+				limit.Burst = 1
+			}
+		}
+		if value := getenv(name + "_BURST"); value != "" {
+			burst, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s_BURST: %w", name, err))
+			}
+			limit.Burst = burst
+		}
+
+		if limit != (adapters.RateLimiterConfig{}) {
+			if c.RateLimits == nil {
+				c.RateLimits = map[string]adapters.RateLimiterConfig{}
+			}
+			c.RateLimits[service] = limit
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package data_lake_export
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+)
+
+const CommandName = "export-data-lake"
+
+// RunCommand parses the export-data-lake arguments and runs the export.
+func RunCommand(ctx context.Context, eventsRepository EventsRepository, args []string) error {
+	flags := flag.NewFlagSet(CommandName, flag.ContinueOnError)
+	dir := flags.String("dir", "data_lake_export", "directory to export events to")
+	format := flags.String("format", string(FormatNDJSON), "output format: ndjson or parquet")
+	from := flags.String("from", "", "export events published at or after (RFC3339 or YYYY-MM-DD)")
+	to := flags.String("to", "", "export events published before (RFC3339 or YYYY-MM-DD)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := Options{Dir: *dir}
+
+	var err error
+	if opts.Format, err = ParseFormat(*format); err != nil {
+		return err
+	}
+	if opts.PublishedFrom, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if opts.PublishedTo, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+
+	run, err := NewExporter(eventsRepository).Export(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).With(
+		"run_id", run.RunID,
+		"events", run.Events,
+		"files", len(run.Files),
+	).Info("Data lake exported")
+
+	return nil
+}
+
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid -%s value %q, expected RFC3339 or YYYY-MM-DD", name, value)
+}
@@ -0,0 +1,322 @@
+package data_lake_export
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
+)
+
+const exportBatchSize = 1000
+
+type EventsRepository interface {
+	StreamEvents(
+		ctx context.Context,
+		filter ticketsEntity.DataLakeFilter,
+		batchSize int,
+	) iter.Seq2[ticketsEntity.DataLakeEvent, error]
+}
+
+type Options struct {
+	Dir    string
+	Format Format
+
+	// PublishedFrom (inclusive) and PublishedTo (exclusive) limit exported events, zero values are ignored.
+	PublishedFrom time.Time
+	PublishedTo   time.Time
+}
+
+// Exporter writes data lake events to files partitioned by day and event name:
+//
+//	<dir>/date=2006-01-02/event_name=BookingMade_v1/part-<run id>.ndjson
+//
+// Events already exported according to the manifest are skipped, so repeated exports are incremental.
+type Exporter struct {
+	eventsRepository EventsRepository
+}
+
+func NewExporter(eventsRepository EventsRepository) Exporter {
+	if eventsRepository == nil {
+		panic("missing eventsRepository")
+	}
+
+	return Exporter{eventsRepository: eventsRepository}
+}
+
+func (e Exporter) Export(ctx context.Context, opts Options) (ManifestRun, error) {
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return ManifestRun{}, fmt.Errorf("could not create export directory: %w", err)
+	}
+
+	manifest, err := readManifest(opts.Dir)
+	if err != nil {
+		return ManifestRun{}, err
+	}
+	for _, run := range manifest.Runs {
+		// the previous export may have failed after its manifest was written
+		if err := commitFiles(opts.Dir, run.Files); err != nil {
+			return ManifestRun{}, err
+		}
+	}
+	if manifest.Format == "" {
+		manifest.Format = opts.Format
+	}
+	if manifest.Format != opts.Format {
+		return ManifestRun{}, fmt.Errorf(
+			"directory %s contains %s export, can't export %s to it",
+			opts.Dir, manifest.Format, opts.Format,
+		)
+	}
+
+	run := ManifestRun{
+		RunID:     time.Now().UTC().Format("20060102T150405Z") + "-" + uuid.NewString()[:8],
+		StartedAt: time.Now().UTC(),
+	}
+	if !opts.PublishedFrom.IsZero() {
+		run.PublishedFrom = &opts.PublishedFrom
+	}
+	if !opts.PublishedTo.IsZero() {
+		run.PublishedTo = &opts.PublishedTo
+	}
+
+	partitions := newPartitions(opts.Dir, opts.Format, run.RunID)
+
+	checkpoint, err := e.export(ctx, manifest, opts, partitions)
+	if err != nil {
+		partitions.abort()
+		return ManifestRun{}, err
+	}
+
+	files, err := partitions.close()
+	if err != nil {
+		partitions.abort()
+		return ManifestRun{}, err
+	}
+	if len(files) == 0 {
+		log.FromContext(ctx).Info("No new events to export")
+		return run, nil
+	}
+
+	run.Files = files
+	for _, file := range files {
+		run.Events += file.Events
+	}
+	run.Checkpoint = checkpoint
+	run.FinishedAt = time.Now().UTC()
+
+	// events before PublishedFrom may be not exported yet, the checkpoint can't skip them
+	if opts.PublishedFrom.IsZero() ||
+		(manifest.Checkpoint != nil && !opts.PublishedFrom.After(manifest.Checkpoint.PublishedAt)) {
+		manifest.Checkpoint = checkpoint
+	}
+	manifest.Runs = append(manifest.Runs, run)
+
+	// the manifest commits the export, files listed in it are renamed by the next export if renaming them fails now
+	if err := writeManifest(opts.Dir, manifest); err != nil {
+		partitions.abort()
+		return ManifestRun{}, err
+	}
+	if err := commitFiles(opts.Dir, files); err != nil {
+		return ManifestRun{}, err
+	}
+
+	return run, nil
+}
+
+// export writes events not exported yet and returns the checkpoint of the last one.
+func (e Exporter) export(
+	ctx context.Context,
+	manifest Manifest,
+	opts Options,
+	partitions *partitions,
+) (*ticketsEntity.DataLakeCheckpoint, error) {
+	events := e.eventsRepository.StreamEvents(
+		ctx,
+		ticketsEntity.DataLakeFilter{
+			After:         manifest.Checkpoint,
+			PublishedFrom: opts.PublishedFrom,
+			PublishedTo:   opts.PublishedTo,
+		},
+		exportBatchSize,
+	)
+
+	var checkpoint *ticketsEntity.DataLakeCheckpoint
+	for event, err := range events {
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest.exported(event) {
+			continue
+		}
+
+		if err := partitions.write(event); err != nil {
+			return nil, err
+		}
+
+		checkpoint = &ticketsEntity.DataLakeCheckpoint{
+			PublishedAt: event.PublishedAt,
+			EventID:     event.EventID,
+		}
+	}
+
+	return checkpoint, nil
+}
+
+type partitionKey struct {
+	date      string
+	eventName string
+}
+
+type partition struct {
+	file   *os.File
+	writer eventsWriter
+	events int64
+	closed bool
+}
+
+// partitions keeps a file open for each day and event name; files are written under a temporary
+// name and renamed only after the manifest of the whole export is written.
+type partitions struct {
+	dir    string
+	format Format
+	runID  string
+
+	open map[partitionKey]*partition
+	// events are ordered by publish time, so partitions of previous days are closed as soon as a new day starts
+	currentDate string
+}
+
+func newPartitions(dir string, format Format, runID string) *partitions {
+	return &partitions{
+		dir:    dir,
+		format: format,
+		runID:  runID,
+		open:   map[partitionKey]*partition{},
+	}
+}
+
+func (p *partitions) path(key partitionKey) string {
+	return filepath.Join(
+		"date="+key.date,
+		"event_name="+key.eventName,
+		"part-"+p.runID+"."+string(p.format),
+	)
+}
+
+func (p *partitions) write(event ticketsEntity.DataLakeEvent) error {
+	key := partitionKey{
+		date:      event.PublishedAt.UTC().Format(time.DateOnly),
+		eventName: event.EventName,
+	}
+
+	if key.date != p.currentDate {
+		for k, part := range p.open {
+			if k.date == p.currentDate {
+				if err := part.close(); err != nil {
+					return err
+				}
+			}
+		}
+		p.currentDate = key.date
+	}
+
+	part, ok := p.open[key]
+	if !ok {
+		path := filepath.Join(p.dir, p.path(key))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("could not create partition directory: %w", err)
+		}
+
+		file, err := os.Create(path + ".tmp")
+		if err != nil {
+			return fmt.Errorf("could not create partition file: %w", err)
+		}
+
+		part = &partition{
+			file:   file,
+			writer: newEventsWriter(p.format, file),
+		}
+		p.open[key] = part
+	}
+
+	if err := part.writer.Write(event); err != nil {
+		return fmt.Errorf("could not write event %s: %w", event.EventID, err)
+	}
+	part.events++
+
+	return nil
+}
+
+// close flushes all partition files, they are still under the temporary name.
+func (p *partitions) close() ([]ManifestFile, error) {
+	var files []ManifestFile
+
+	for key, part := range p.open {
+		if err := part.close(); err != nil {
+			return nil, err
+		}
+
+		files = append(files, ManifestFile{
+			Path:      filepath.ToSlash(p.path(key)),
+			EventName: key.eventName,
+			Date:      key.date,
+			Events:    part.events,
+		})
+	}
+
+	slices.SortFunc(files, func(a, b ManifestFile) int {
+		if a.Date != b.Date {
+			return cmp.Compare(a.Date, b.Date)
+		}
+		return cmp.Compare(a.EventName, b.EventName)
+	})
+
+	return files, nil
+}
+
+// commitFiles renames files of the export to their final names, files already renamed are skipped.
+func commitFiles(dir string, files []ManifestFile) error {
+	for _, file := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(file.Path))
+
+		err := os.Rename(fullPath+".tmp", fullPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not rename partition file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// abort removes files of the failed export; the manifest is not updated, so the next export starts over.
+func (p *partitions) abort() {
+	for key, part := range p.open {
+		_ = part.close()
+		fullPath := filepath.Join(p.dir, p.path(key))
+		_ = os.Remove(fullPath + ".tmp")
+		_ = os.Remove(fullPath)
+	}
+}
+
+func (p *partition) close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	err := p.writer.Close()
+	return errors.Join(err, p.file.Close())
+}
@@ -0,0 +1,245 @@
+package data_lake_export_test
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	dataLakeExport "tickets/data_lake_export"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporter_NDJSON_incremental(t *testing.T) {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &eventsRepositoryStub{
+		events: []ticketsEntity.DataLakeEvent{
+			newEvent("00000000-0000-0000-0000-000000000001", day, "BookingMade_v1"),
+			newEvent("00000000-0000-0000-0000-000000000002", day.Add(time.Minute), "TicketPrinted_v1"),
+			newEvent("00000000-0000-0000-0000-000000000003", day.Add(24*time.Hour), "BookingMade_v1"),
+		},
+	}
+
+	dir := t.TempDir()
+	exporter := dataLakeExport.NewExporter(repo)
+	opts := dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatNDJSON}
+
+	run, err := exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, run.Events)
+	require.Len(t, run.Files, 3)
+	assert.Equal(t, "2025-03-10", run.Files[0].Date)
+	assert.Equal(t, "BookingMade_v1", run.Files[0].EventName)
+
+	content, err := os.ReadFile(filepath.Join(dir, run.Files[0].Path))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1)
+
+	var line struct {
+		EventID      string          `json:"event_id"`
+		EventPayload json.RawMessage `json:"event_payload"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", line.EventID)
+	assert.Equal(t, `{"booking_id": "00000000-0000-0000-0000-000000000001"}`, string(line.EventPayload))
+
+	repo.events = append(
+		repo.events,
+		newEvent("00000000-0000-0000-0000-000000000004", day.Add(25*time.Hour), "TicketPrinted_v1"),
+	)
+
+	run, err = exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, run.Events)
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "00000000-0000-0000-0000-000000000004")
+
+	_, err = exporter.Export(
+		context.Background(),
+		dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatParquet},
+	)
+	assert.Error(t, err, "format can't change between exports")
+}
+
+func TestExporter_Parquet(t *testing.T) {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &eventsRepositoryStub{
+		events: []ticketsEntity.DataLakeEvent{
+			newEvent("00000000-0000-0000-0000-000000000001", day, "BookingMade_v1"),
+			newEvent("00000000-0000-0000-0000-000000000002", day.Add(time.Minute), "BookingMade_v1"),
+		},
+	}
+
+	dir := t.TempDir()
+	run, err := dataLakeExport.NewExporter(repo).Export(
+		context.Background(),
+		dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatParquet},
+	)
+	require.NoError(t, err)
+	require.Len(t, run.Files, 1)
+
+	type row struct {
+		EventID      string `parquet:"event_id"`
+		EventPayload []byte `parquet:"event_payload"`
+	}
+	rows, err := parquet.ReadFile[row](filepath.Join(dir, run.Files[0].Path))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", rows[1].EventID)
+	assert.Equal(t, repo.events[1].EventPayload, rows[1].EventPayload)
+}
+
+func TestExporter_from_keeps_earlier_events(t *testing.T) {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &eventsRepositoryStub{
+		events: []ticketsEntity.DataLakeEvent{
+			newEvent("00000000-0000-0000-0000-000000000001", day, "BookingMade_v1"),
+			newEvent("00000000-0000-0000-0000-000000000002", day.Add(48*time.Hour), "BookingMade_v1"),
+		},
+	}
+
+	dir := t.TempDir()
+	exporter := dataLakeExport.NewExporter(repo)
+
+	run, err := exporter.Export(context.Background(), dataLakeExport.Options{
+		Dir:           dir,
+		Format:        dataLakeExport.FormatNDJSON,
+		PublishedFrom: day.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000002"}, exportedEventIDs(t, dir, run))
+
+	opts := dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatNDJSON}
+
+	run, err = exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{"00000000-0000-0000-0000-000000000001"},
+		exportedEventIDs(t, dir, run),
+		"events before -from should be exported, without the ones exported already",
+	)
+
+	run, err = exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Zero(t, run.Events)
+}
+
+func TestExporter_manifest_not_written(t *testing.T) {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &eventsRepositoryStub{
+		events: []ticketsEntity.DataLakeEvent{
+			newEvent("00000000-0000-0000-0000-000000000001", day, "BookingMade_v1"),
+		},
+	}
+
+	dir := t.TempDir()
+	exporter := dataLakeExport.NewExporter(repo)
+	opts := dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatNDJSON}
+
+	// the manifest can't replace a directory
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "manifest.json.tmp"), 0o755))
+	_, err := exporter.Export(context.Background(), opts)
+	require.Error(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "date=*", "*", "*"))
+	require.NoError(t, err)
+	assert.Empty(t, files, "files of the uncommitted export should be removed")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "manifest.json.tmp")))
+	run, err := exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, exportedEventIDs(t, dir, run))
+}
+
+func TestExporter_renames_files_of_committed_export(t *testing.T) {
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &eventsRepositoryStub{
+		events: []ticketsEntity.DataLakeEvent{
+			newEvent("00000000-0000-0000-0000-000000000001", day, "BookingMade_v1"),
+		},
+	}
+
+	dir := t.TempDir()
+	exporter := dataLakeExport.NewExporter(repo)
+	opts := dataLakeExport.Options{Dir: dir, Format: dataLakeExport.FormatNDJSON}
+
+	committed, err := exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, committed.Files, 1)
+
+	// as if the export failed after writing the manifest
+	path := filepath.Join(dir, committed.Files[0].Path)
+	require.NoError(t, os.Rename(path, path+".tmp"))
+
+	run, err := exporter.Export(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Zero(t, run.Events, "events of the committed export should not be exported again")
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, exportedEventIDs(t, dir, committed))
+}
+
+func exportedEventIDs(t *testing.T, dir string, run dataLakeExport.ManifestRun) []string {
+	t.Helper()
+
+	var eventIDs []string
+	for _, file := range run.Files {
+		content, err := os.ReadFile(filepath.Join(dir, file.Path))
+		require.NoError(t, err)
+
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			var event struct {
+				EventID string `json:"event_id"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			eventIDs = append(eventIDs, event.EventID)
+		}
+	}
+
+	return eventIDs
+}
+
+func newEvent(id string, publishedAt time.Time, name string) ticketsEntity.DataLakeEvent {
+	return ticketsEntity.DataLakeEvent{
+		EventID:      id,
+		PublishedAt:  publishedAt,
+		EventName:    name,
+		EventPayload: []byte(`{"booking_id": "` + id + `"}`),
+	}
+}
+
+type eventsRepositoryStub struct {
+	events []ticketsEntity.DataLakeEvent
+}
+
+func (s *eventsRepositoryStub) StreamEvents(
+	_ context.Context,
+	filter ticketsEntity.DataLakeFilter,
+	_ int,
+) iter.Seq2[ticketsEntity.DataLakeEvent, error] {
+	return func(yield func(ticketsEntity.DataLakeEvent, error) bool) {
+		for _, event := range s.events {
+			if filter.After != nil && !event.PublishedAt.After(filter.After.PublishedAt) {
+				continue
+			}
+			if !filter.PublishedFrom.IsZero() && event.PublishedAt.Before(filter.PublishedFrom) {
+				continue
+			}
+			if !filter.PublishedTo.IsZero() && !event.PublishedAt.Before(filter.PublishedTo) {
+				continue
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
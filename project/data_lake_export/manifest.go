@@ -0,0 +1,108 @@
+package data_lake_export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	ticketsEntity "tickets/entities"
+	"time"
+)
+
+const manifestFileName = "manifest.json"
+
+// Manifest describes what was already exported to the directory, so the next export continues after the Checkpoint.
+// Runs limited by PublishedFrom don't move the Checkpoint, as events before them may be not exported yet,
+// later exports skip the events these runs exported instead.
+type Manifest struct {
+	Format     Format                            `json:"format"`
+	Checkpoint *ticketsEntity.DataLakeCheckpoint `json:"checkpoint"`
+	Runs       []ManifestRun                     `json:"runs"`
+}
+
+type ManifestRun struct {
+	RunID         string         `json:"run_id"`
+	StartedAt     time.Time      `json:"started_at"`
+	FinishedAt    time.Time      `json:"finished_at"`
+	PublishedFrom *time.Time     `json:"published_from,omitempty"`
+	PublishedTo   *time.Time     `json:"published_to,omitempty"`
+	Events        int64          `json:"events"`
+	Files         []ManifestFile `json:"files"`
+
+	// Checkpoint is the last event exported by the run.
+	Checkpoint *ticketsEntity.DataLakeCheckpoint `json:"checkpoint,omitempty"`
+}
+
+type ManifestFile struct {
+	// Path is relative to the export directory.
+	Path      string `json:"path"`
+	EventName string `json:"event_name"`
+	Date      string `json:"date"`
+	Events    int64  `json:"events"`
+}
+
+// exported returns true if the event was exported by a run limited by PublishedFrom.
+func (m Manifest) exported(event ticketsEntity.DataLakeEvent) bool {
+	for _, run := range m.Runs {
+		if run.PublishedFrom == nil || run.Checkpoint == nil {
+			continue
+		}
+		if event.PublishedAt.Before(*run.PublishedFrom) {
+			continue
+		}
+		if run.PublishedTo != nil && !event.PublishedAt.Before(*run.PublishedTo) {
+			continue
+		}
+		if !isAfterCheckpoint(event, *run.Checkpoint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAfterCheckpoint compares events in the (published_at, event_id) order they are exported in.
+func isAfterCheckpoint(event ticketsEntity.DataLakeEvent, checkpoint ticketsEntity.DataLakeCheckpoint) bool {
+	if !event.PublishedAt.Equal(checkpoint.PublishedAt) {
+		return event.PublishedAt.After(checkpoint.PublishedAt)
+	}
+
+	return event.EventID > checkpoint.EventID
+}
+
+func readManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("could not read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("could not unmarshal manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// writeManifest replaces the manifest atomically, so an interrupted export never leaves it half-written.
+func writeManifest(dir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal manifest: %w", err)
+	}
+
+	tmp := filepath.Join(dir, manifestFileName+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("could not write manifest: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(dir, manifestFileName)); err != nil {
+		return fmt.Errorf("could not replace manifest: %w", err)
+	}
+
+	return nil
+}
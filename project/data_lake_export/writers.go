@@ -0,0 +1,113 @@
+package data_lake_export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type Format string
+
+const (
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatNDJSON, FormatParquet:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, expected %s or %s", s, FormatNDJSON, FormatParquet)
+	}
+}
+
+type eventsWriter interface {
+	Write(event ticketsEntity.DataLakeEvent) error
+	// Close flushes buffered events, it doesn't close the underlying writer.
+	Close() error
+}
+
+func newEventsWriter(format Format, w io.Writer) eventsWriter {
+	switch format {
+	case FormatParquet:
+		return parquetWriter{w: parquet.NewGenericWriter[parquetEvent](w)}
+	default:
+		return ndjsonWriter{w: bufio.NewWriter(w)}
+	}
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+func (n ndjsonWriter) Write(event ticketsEntity.DataLakeEvent) error {
+	header, err := json.Marshal(
+		struct {
+			EventID     string    `json:"event_id"`
+			PublishedAt time.Time `json:"published_at"`
+			EventName   string    `json:"event_name"`
+		}{
+			EventID:     event.EventID,
+			PublishedAt: event.PublishedAt,
+			EventName:   event.EventName,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// the payload is written as stored in the data lake, json.Marshal would re-encode it
+	payload := event.EventPayload
+	if bytes.ContainsAny(payload, "\r\n") {
+		// new lines would break NDJSON
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, payload); err != nil {
+			return fmt.Errorf("invalid payload of event %s: %w", event.EventID, err)
+		}
+		payload = compacted.Bytes()
+	}
+
+	n.w.Write(header[:len(header)-1])
+	n.w.WriteString(`,"event_payload":`)
+	n.w.Write(payload)
+	_, err = n.w.WriteString("}\n")
+
+	return err
+}
+
+func (n ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
+
+type parquetEvent struct {
+	EventID      string    `parquet:"event_id"`
+	PublishedAt  time.Time `parquet:"published_at,timestamp(microsecond)"`
+	EventName    string    `parquet:"event_name,dict"`
+	EventPayload []byte    `parquet:"event_payload,json"`
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[parquetEvent]
+}
+
+func (p parquetWriter) Write(event ticketsEntity.DataLakeEvent) error {
+	_, err := p.w.Write([]parquetEvent{{
+		EventID:      event.EventID,
+		PublishedAt:  event.PublishedAt,
+		EventName:    event.EventName,
+		EventPayload: event.EventPayload,
+	}})
+
+	return err
+}
+
+func (p parquetWriter) Close() error {
+	return p.w.Close()
+}
@@ -8,6 +8,7 @@ import (
 	ticketsEntity "tickets/entities"
 	ticketsEvent "tickets/message/event"
 	ticketsOutbox "tickets/message/outbox"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/ThreeDotsLabs/watermill"
@@ -16,9 +17,19 @@ import (
 
 var (
 	ErrBookingAlreadyExists = errors.New("booking already exists")
+	ErrBookingNotFound      = errors.New("booking not found")
 	ErrNoPlacesLeft         = errors.New("no places left")
 )
 
+// remainingSeatsColumn calculates seats left for the show; canceled bookings give their seats back.
+// It's shared by the capacity check in AddBooking and the shows catalogue, so both always agree.
+const remainingSeatsColumn = `
+	shows.number_of_tickets - COALESCE((
+		SELECT SUM(bookings.number_of_tickets)
+		FROM bookings
+		WHERE bookings.show_id = shows.show_id AND bookings.canceled_at IS NULL
+	), 0) AS remaining_seats`
+
 type BookingRepository struct {
 	db *sqlx.DB
 }
@@ -38,11 +49,11 @@ func (t BookingRepository) AddBooking(ctx context.Context, booking ticketsEntity
 		t.db,
 		sql.LevelSerializable,
 		func(ctx context.Context, tx *sqlx.Tx) error {
-			availableSeats := 0
+			remainingSeats := 0
 			err := tx.GetContext(
-				ctx, &availableSeats, `
+				ctx, &remainingSeats, `
 				SELECT
-					number_of_tickets AS available_seats
+					`+remainingSeatsColumn+`
 				FROM
 					shows
 				WHERE
@@ -50,25 +61,10 @@ func (t BookingRepository) AddBooking(ctx context.Context, booking ticketsEntity
 			`, booking.ShowID,
 			)
 			if err != nil {
-				return fmt.Errorf("could not get available seats: %w", err)
+				return fmt.Errorf("could not get remaining seats: %w", err)
 			}
 
-			alreadyBookedSeats := 0
-			err = tx.GetContext(
-				ctx, &alreadyBookedSeats, `
-				SELECT
-					COALESCE(SUM(number_of_tickets), 0) AS already_booked_seats
-				FROM
-					bookings
-				WHERE
-					show_id = $1
-			`, booking.ShowID,
-			)
-			if err != nil {
-				return fmt.Errorf("could not get already booked seats: %w", err)
-			}
-
-			if availableSeats-alreadyBookedSeats < booking.NumberOfTickets {
+			if remainingSeats < booking.NumberOfTickets {
 				// this is usually a bad idea, learn more here:
 				// https://threedots.tech/post/introducing-clean-architecture/
 				// we'll improve it later
@@ -117,3 +113,85 @@ func (t BookingRepository) AddBooking(ctx context.Context, booking ticketsEntity
 		},
 	)
 }
+
+// BookingCanceledAt is nil when the booking is not canceled, or when it was not made by the service.
+func (t BookingRepository) BookingCanceledAt(ctx context.Context, bookingID string) (*time.Time, error) {
+	var canceledAt *time.Time
+	err := t.db.GetContext(
+		ctx, &canceledAt, `
+		SELECT canceled_at FROM bookings WHERE booking_id = $1
+	`, bookingID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get booking: %w", err)
+	}
+
+	return canceledAt, nil
+}
+
+// CancelBooking marks the booking as canceled, so its seats are available again,
+// and publishes BookingCanceled_v1 in the same transaction.
+// Canceling an already canceled booking is a no-op.
+func (t BookingRepository) CancelBooking(ctx context.Context, bookingID string) error {
+	return updateInTx(
+		ctx,
+		t.db,
+		sql.LevelSerializable,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			var booking ticketsEntity.Booking
+			err := tx.GetContext(
+				ctx, &booking, `
+				SELECT
+					booking_id,
+					show_id,
+					number_of_tickets,
+					customer_email,
+					canceled_at
+				FROM
+					bookings
+				WHERE
+					booking_id = $1
+				FOR UPDATE
+			`, bookingID,
+			)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrBookingNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("could not get booking: %w", err)
+			}
+
+			if booking.CanceledAt != nil {
+				return nil
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				`UPDATE bookings SET canceled_at = now() WHERE booking_id = $1`,
+				bookingID,
+			)
+			if err != nil {
+				return fmt.Errorf("could not cancel booking: %w", err)
+			}
+
+			outboxPublisher, err := ticketsOutbox.NewPublisherForDb(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("could not create event bus: %w", err)
+			}
+
+			bus := ticketsEvent.NewEventBus(outboxPublisher, watermill.NewSlogLogger(log.FromContext(ctx)))
+			return bus.Publish(
+				ctx, ticketsEntity.BookingCanceled_v1{
+					Header:          ticketsEntity.NewMessageHeader(),
+					BookingID:       booking.BookingID,
+					ShowID:          booking.ShowID,
+					NumberOfTickets: booking.NumberOfTickets,
+					CustomerEmail:   booking.CustomerEmail,
+				},
+			)
+		},
+	)
+}
@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"iter"
+	"strings"
 	ticketsEntity "tickets/entities"
 
 	"github.com/jmoiron/sqlx"
@@ -28,38 +31,205 @@ func (s EventsRepository) SaveEvents(
 	eventName string,
 	payload []byte,
 ) error {
-	_, err := s.db.ExecContext(
+	return updateInTx(
 		ctx,
-		`
-        INSERT INTO events (
-            event_id,
-            published_at,
-            event_name,
-            event_payload
-        )
-        VALUES ($1, $2, $3, $4)
-        ON CONFLICT DO NOTHING`,
-		event.Header.ID,
-		event.Header.PublishedAt,
-		eventName,
-		payload,
+		s.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				`
+				INSERT INTO events (
+					event_id,
+					published_at,
+					event_name,
+					event_payload
+				)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING`,
+				event.Header.ID,
+				event.Header.PublishedAt,
+				eventName,
+				payload,
+			)
+			var postgresError *pq.Error
+			if errors.As(err, &postgresError) && postgresError.Code.Name() == "unique_violation" {
+				// handling re-delivery
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not store %s event in data lake: %w", event.Header.ID, err)
+			}
+
+			return nil
+		},
 	)
-	var postgresError *pq.Error
-	if errors.As(err, &postgresError) && postgresError.Code.Name() == "unique_violation" {
-		// handling re-delivery
-		return nil
+}
+
+const defaultDataLakeBatchSize = 1000
+
+// StreamEvents iterates over the data lake in (published_at, event_id) order.
+// Events are read in batches, so the whole table is never loaded into memory.
+// Iteration stops after the first error.
+func (d EventsRepository) StreamEvents(
+	ctx context.Context,
+	filter ticketsEntity.DataLakeFilter,
+	batchSize int,
+) iter.Seq2[ticketsEntity.DataLakeEvent, error] {
+	if batchSize <= 0 {
+		batchSize = defaultDataLakeBatchSize
+	}
+
+	return func(yield func(ticketsEntity.DataLakeEvent, error) bool) {
+		// the cursor is local, so the sequence can be iterated again from the start
+		batchFilter := filter
+		for {
+			events, err := d.getEventsBatch(ctx, batchFilter, batchSize)
+			if err != nil {
+				yield(ticketsEntity.DataLakeEvent{}, err)
+				return
+			}
+
+			for _, event := range events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+
+			if len(events) < batchSize {
+				return
+			}
+
+			last := events[len(events)-1]
+			batchFilter.After = &ticketsEntity.DataLakeCheckpoint{
+				PublishedAt: last.PublishedAt,
+				EventID:     last.EventID,
+			}
+		}
+	}
+}
+
+// StreamStoredEvents iterates over the data lake in the order the events were stored in.
+// Unless the filter includes unsettled events, events stored by transactions newer than the oldest running one
+// are skipped, so no event can be stored before the last streamed one anymore.
+// Iteration stops after the first error.
+func (d EventsRepository) StreamStoredEvents(
+	ctx context.Context,
+	filter ticketsEntity.StoredEventsFilter,
+	batchSize int,
+) iter.Seq2[ticketsEntity.StoredDataLakeEvent, error] {
+	if batchSize <= 0 {
+		batchSize = defaultDataLakeBatchSize
+	}
+
+	return func(yield func(ticketsEntity.StoredDataLakeEvent, error) bool) {
+		after := filter.After
+		for {
+			events, err := d.getStoredEventsBatch(ctx, after, filter.Unsettled, batchSize)
+			if err != nil {
+				yield(ticketsEntity.StoredDataLakeEvent{}, err)
+				return
+			}
+
+			for _, event := range events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+
+			if len(events) < batchSize {
+				return
+			}
+
+			after = &events[len(events)-1].StoredEventsCheckpoint
+		}
+	}
+}
+
+func (d EventsRepository) getStoredEventsBatch(
+	ctx context.Context,
+	after *ticketsEntity.StoredEventsCheckpoint,
+	unsettled bool,
+	limit int,
+) ([]ticketsEntity.StoredDataLakeEvent, error) {
+	var conditions []string
+	var args []any
+
+	if !unsettled {
+		conditions = append(conditions, "stored_xid < pg_snapshot_xmin(pg_current_snapshot())")
 	}
-	if err != nil {
-		return fmt.Errorf("could not store %s event in data lake: %w", event.Header.ID, err)
+	if after != nil {
+		args = append(args, after.TransactionID, after.Sequence)
+		conditions = append(conditions, fmt.Sprintf(
+			"(stored_xid, stored_seq) > ($%d::text::xid8, $%d)", len(args)-1, len(args),
+		))
 	}
-	return nil
 
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT
+			event_id, published_at, event_name, event_payload, stored_xid::text::bigint AS stored_xid, stored_seq
+		FROM events
+		%s
+		ORDER BY events.stored_xid, events.stored_seq
+		LIMIT $%d
+	`, where, len(args))
+
+	var events []ticketsEntity.StoredDataLakeEvent
+	if err := d.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, fmt.Errorf("could not get stored events from data lake: %w", err)
+	}
+
+	return events, nil
 }
 
-func (d EventsRepository) GetEvents(ctx context.Context) ([]ticketsEntity.DataLakeEvent, error) {
+func (d EventsRepository) getEventsBatch(
+	ctx context.Context,
+	filter ticketsEntity.DataLakeFilter,
+	limit int,
+) ([]ticketsEntity.DataLakeEvent, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.EventNames) > 0 {
+		addCondition("event_name = ANY($%d)", pq.StringArray(filter.EventNames))
+	}
+	if !filter.PublishedFrom.IsZero() {
+		addCondition("published_at >= $%d", filter.PublishedFrom)
+	}
+	if !filter.PublishedTo.IsZero() {
+		addCondition("published_at < $%d", filter.PublishedTo)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.PublishedAt, filter.After.EventID)
+		conditions = append(conditions, fmt.Sprintf("(published_at, event_id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT event_id, published_at, event_name, event_payload FROM events
+		%s
+		ORDER BY published_at, event_id
+		LIMIT $%d
+	`, where, len(args))
+
 	var events []ticketsEntity.DataLakeEvent
-	err := d.db.SelectContext(ctx, &events, "SELECT * FROM events ORDER BY published_at ASC")
-	if err != nil {
+	if err := d.db.SelectContext(ctx, &events, query, args...); err != nil {
 		return nil, fmt.Errorf("could not get events from data lake: %w", err)
 	}
 
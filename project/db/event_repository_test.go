@@ -0,0 +1,52 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	"tickets/entities"
+)
+
+func TestEventsRepository_StreamEvents_iterated_twice(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewEventsRepository(db)
+
+	eventName := "TestEvent_" + uuid.NewString()
+	publishedAt := time.Now().UTC().Truncate(time.Microsecond)
+	var eventIDs []string
+	for i := range 3 {
+		eventID := uuid.NewString()
+		require.NoError(t, repo.SaveEvents(
+			ctx,
+			entities.ExternalEvent{Header: entities.MessageHeader{ID: eventID, PublishedAt: publishedAt.Add(time.Duration(i) * time.Second)}},
+			eventName,
+			[]byte(`{}`),
+		))
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	// batches smaller than the number of events, so the cursor is moved between them
+	events := repo.StreamEvents(ctx, entities.DataLakeFilter{EventNames: []string{eventName}}, 2)
+
+	for range 2 {
+		var streamed []string
+		for event, err := range events {
+			require.NoError(t, err)
+			streamed = append(streamed, event.EventID)
+		}
+
+		assert.Equal(t, eventIDs, streamed, "each iteration should start from the beginning")
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyLockTimeout is how long the key stays reserved by a request that never completed
+// (for example, because the instance crashed), before another request can take it over.
+const IdempotencyLockTimeout = time.Minute
+
+type IdempotencyKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyKeyRepository(db *sqlx.DB) IdempotencyKeyRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return IdempotencyKeyRepository{db: db}
+}
+
+// Reserve tries to reserve the key for the request.
+// If the key was already used, reserved is false and the stored request is returned.
+func (r IdempotencyKeyRepository) Reserve(
+	ctx context.Context,
+	idempotencyKey string,
+	requestFingerprint string,
+) (existing ticketsEntity.IdempotentRequest, reserved bool, err error) {
+	res, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO
+			idempotency_keys (idempotency_key, request_fingerprint, locked_at)
+		VALUES
+			($1, $2, now())
+		ON CONFLICT (idempotency_key) DO UPDATE SET locked_at = now()
+		WHERE
+			idempotency_keys.response_status IS NULL
+			AND idempotency_keys.request_fingerprint = excluded.request_fingerprint
+			AND idempotency_keys.locked_at < now() - make_interval(secs => $3)
+	`, idempotencyKey, requestFingerprint, IdempotencyLockTimeout.Seconds(),
+	)
+	if err != nil {
+		return ticketsEntity.IdempotentRequest{}, false, fmt.Errorf("could not reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return ticketsEntity.IdempotentRequest{}, false, fmt.Errorf("could get rows affected: %w", err)
+	}
+	if rowsAffected == 1 {
+		return ticketsEntity.IdempotentRequest{}, true, nil
+	}
+
+	err = r.db.GetContext(
+		ctx, &existing, `
+		SELECT
+			idempotency_key,
+			request_fingerprint,
+			response_status,
+			response_content_type,
+			response_body
+		FROM
+			idempotency_keys
+		WHERE
+			idempotency_key = $1
+	`, idempotencyKey,
+	)
+	if err != nil {
+		return ticketsEntity.IdempotentRequest{}, false, fmt.Errorf("could not get idempotency key: %w", err)
+	}
+
+	return existing, false, nil
+}
+
+func (r IdempotencyKeyRepository) SaveResponse(
+	ctx context.Context,
+	idempotencyKey string,
+	status int,
+	contentType string,
+	body []byte,
+) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		UPDATE
+			idempotency_keys
+		SET
+			response_status = $2,
+			response_content_type = $3,
+			response_body = $4,
+			completed_at = now()
+		WHERE
+			idempotency_key = $1
+	`, idempotencyKey, status, contentType, body,
+	)
+	if err != nil {
+		return fmt.Errorf("could not save response for idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the reservation, so the request can be retried with the same key.
+func (r IdempotencyKeyRepository) Release(ctx context.Context, idempotencyKey string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE idempotency_key = $1 AND response_status IS NULL`,
+		idempotencyKey,
+	)
+	if err != nil {
+		return fmt.Errorf("could not release idempotency key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrMessageAlreadyProcessed is returned from a transaction of a handler that already processed the message.
+var ErrMessageAlreadyProcessed = errors.New("message already processed")
+
+// InboxMessage is the message being handled. When it's in the context, the first transaction
+// started by the handler records it as processed, so the handling and the record are atomic.
+type InboxMessage struct {
+	HandlerName string
+	MessageID   string
+
+	recorded bool
+}
+
+// Recorded returns true if the message was recorded as processed in a handler's transaction.
+func (m *InboxMessage) Recorded() bool {
+	return m.recorded
+}
+
+type inboxMessageCtxKey struct{}
+
+func ContextWithInboxMessage(ctx context.Context, msg *InboxMessage) context.Context {
+	return context.WithValue(ctx, inboxMessageCtxKey{}, msg)
+}
+
+func inboxMessageFromContext(ctx context.Context) *InboxMessage {
+	msg, _ := ctx.Value(inboxMessageCtxKey{}).(*InboxMessage)
+	return msg
+}
+
+type InboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewInboxRepository(db *sqlx.DB) InboxRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return InboxRepository{db: db}
+}
+
+func (r InboxRepository) IsProcessed(ctx context.Context, handlerName string, messageID string) (bool, error) {
+	var processed bool
+
+	err := r.db.GetContext(
+		ctx,
+		&processed,
+		`SELECT EXISTS (SELECT 1 FROM processed_messages WHERE handler_name = $1 AND message_id = $2)`,
+		handlerName, messageID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("could not check if message %s was processed: %w", messageID, err)
+	}
+
+	return processed, nil
+}
+
+func (r InboxRepository) MarkProcessed(ctx context.Context, handlerName string, messageID string) error {
+	_, err := markMessageProcessed(ctx, r.db, handlerName, messageID)
+	return err
+}
+
+// Unmark removes the record, so the message is processed again when it's redelivered.
+func (r InboxRepository) Unmark(ctx context.Context, handlerName string, messageID string) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM processed_messages WHERE handler_name = $1 AND message_id = $2`,
+		handlerName, messageID,
+	)
+	if err != nil {
+		return fmt.Errorf("could not unmark message %s: %w", messageID, err)
+	}
+
+	return nil
+}
+
+func markMessageProcessed(ctx context.Context, db dbExecutor, handlerName string, messageID string) (bool, error) {
+	res, err := db.ExecContext(
+		ctx,
+		`
+		INSERT INTO processed_messages (handler_name, message_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`,
+		handlerName, messageID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("could not mark message %s as processed: %w", messageID, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted > 0, nil
+}
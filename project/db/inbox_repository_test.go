@@ -0,0 +1,107 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	"tickets/entities"
+)
+
+func TestInbox_recorded_with_handler_writes(t *testing.T) {
+	db := getDb()
+	require.NoError(t, ticketsDb.InitializeDatabaseSchema(db))
+
+	tickets := ticketsDb.NewTicketsRepository(db)
+	events := ticketsDb.NewEventsRepository(db)
+	opsReadModel := ticketsDb.NewOpsBookingReadModel(db, nil)
+
+	ticket := entities.Ticket{
+		TicketID:      uuid.NewString(),
+		Price:         entities.Money{Amount: "30.00", Currency: "EUR"},
+		CustomerEmail: "foo@bar.com",
+	}
+	header := entities.NewMessageHeader()
+
+	testCases := []struct {
+		name   string
+		handle func(ctx context.Context) error
+	}{
+		{
+			name: "store ticket",
+			handle: func(ctx context.Context) error {
+				return tickets.Add(ctx, ticket)
+			},
+		},
+		{
+			name: "remove canceled ticket",
+			handle: func(ctx context.Context) error {
+				return tickets.Remove(ctx, ticket)
+			},
+		},
+		{
+			name: "store event",
+			handle: func(ctx context.Context) error {
+				return events.SaveEvents(ctx, entities.ExternalEvent{Header: header}, "TestEvent_v1", []byte(`{}`))
+			},
+		},
+		{
+			name: "create ops read model",
+			handle: func(ctx context.Context) error {
+				return opsReadModel.OnBookingMade(ctx, &entities.BookingMade_v1{
+					Header:    entities.NewMessageHeader(),
+					BookingID: uuid.NewString(),
+					ShowID:    uuid.NewString(),
+				})
+			},
+		},
+	}
+
+	inbox := ticketsDb.NewInboxRepository(db)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			handlerName := "inbox_test_" + uuid.NewString()
+			messageID := uuid.NewString()
+
+			inboxMsg := &ticketsDb.InboxMessage{HandlerName: handlerName, MessageID: messageID}
+			require.NoError(t, tc.handle(ticketsDb.ContextWithInboxMessage(ctx, inboxMsg)))
+			assert.True(t, inboxMsg.Recorded(), "message should be recorded in the handler's transaction")
+
+			processed, err := inbox.IsProcessed(ctx, handlerName, messageID)
+			require.NoError(t, err)
+			assert.True(t, processed)
+
+			redelivered := &ticketsDb.InboxMessage{HandlerName: handlerName, MessageID: messageID}
+			err = tc.handle(ticketsDb.ContextWithInboxMessage(ctx, redelivered))
+			assert.ErrorIs(t, err, ticketsDb.ErrMessageAlreadyProcessed)
+		})
+	}
+}
+
+func TestInbox_not_recorded_when_handler_write_fails(t *testing.T) {
+	ctx := context.Background()
+	db := getDb()
+	require.NoError(t, ticketsDb.InitializeDatabaseSchema(db))
+
+	handlerName := "inbox_test_" + uuid.NewString()
+	messageID := uuid.NewString()
+	inboxMsg := &ticketsDb.InboxMessage{HandlerName: handlerName, MessageID: messageID}
+
+	// the ticket doesn't exist, so the transaction is rolled back
+	err := ticketsDb.NewTicketsRepository(db).Remove(
+		ticketsDb.ContextWithInboxMessage(ctx, inboxMsg),
+		entities.Ticket{TicketID: uuid.NewString()},
+	)
+	require.Error(t, err)
+	assert.False(t, inboxMsg.Recorded())
+
+	processed, err := ticketsDb.NewInboxRepository(db).IsProcessed(ctx, handlerName, messageID)
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
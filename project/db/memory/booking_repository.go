@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	ticketsDB "tickets/db"
+	ticketsEntity "tickets/entities"
+	"time"
+)
+
+type BookingRepository struct {
+	db       *DB
+	eventBus EventBus
+}
+
+func NewBookingRepository(db *DB, eventBus EventBus) BookingRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+
+	return BookingRepository{db: db, eventBus: eventBus}
+}
+
+func (t BookingRepository) AddBooking(ctx context.Context, booking ticketsEntity.Booking) error {
+	err := func() error {
+		t.db.lock.Lock()
+		defer t.db.lock.Unlock()
+
+		show, ok := t.db.shows[booking.ShowID]
+		if !ok {
+			return fmt.Errorf("could not get remaining seats: %w", sql.ErrNoRows)
+		}
+		if t.db.remainingSeats(show) < booking.NumberOfTickets {
+			return ticketsDB.ErrNoPlacesLeft
+		}
+
+		if _, ok := t.db.bookings[booking.BookingID]; !ok {
+			t.db.bookings[booking.BookingID] = booking
+		}
+
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	return t.eventBus.Publish(
+		ctx, ticketsEntity.BookingMade_v1{
+			Header:          ticketsEntity.NewMessageHeader(),
+			NumberOfTickets: booking.NumberOfTickets,
+			BookingID:       booking.BookingID,
+			CustomerEmail:   booking.CustomerEmail,
+			ShowID:          booking.ShowID,
+		},
+	)
+}
+
+// BookingCanceledAt is nil when the booking is not canceled, or when it was not made by the service.
+func (t BookingRepository) BookingCanceledAt(ctx context.Context, bookingID string) (*time.Time, error) {
+	t.db.lock.RLock()
+	defer t.db.lock.RUnlock()
+
+	return t.db.bookings[bookingID].CanceledAt, nil
+}
+
+// CancelBooking marks the booking as canceled and publishes BookingCanceled_v1.
+// Canceling an already canceled booking is a no-op.
+func (t BookingRepository) CancelBooking(ctx context.Context, bookingID string) error {
+	booking, canceled, err := func() (ticketsEntity.Booking, bool, error) {
+		t.db.lock.Lock()
+		defer t.db.lock.Unlock()
+
+		booking, ok := t.db.bookings[bookingID]
+		if !ok {
+			return ticketsEntity.Booking{}, false, ticketsDB.ErrBookingNotFound
+		}
+		if booking.CanceledAt != nil {
+			return booking, false, nil
+		}
+
+		now := time.Now()
+		booking.CanceledAt = &now
+		t.db.bookings[bookingID] = booking
+
+		return booking, true, nil
+	}()
+	if err != nil || !canceled {
+		return err
+	}
+
+	return t.eventBus.Publish(
+		ctx, ticketsEntity.BookingCanceled_v1{
+			Header:          ticketsEntity.NewMessageHeader(),
+			BookingID:       booking.BookingID,
+			ShowID:          booking.ShowID,
+			NumberOfTickets: booking.NumberOfTickets,
+			CustomerEmail:   booking.CustomerEmail,
+		},
+	)
+}
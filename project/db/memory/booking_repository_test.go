@@ -0,0 +1,64 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	ticketsMemory "tickets/db/memory"
+	"tickets/entities"
+)
+
+type eventBusStub struct {
+	events []any
+}
+
+func (e *eventBusStub) Publish(ctx context.Context, event any) error {
+	e.events = append(e.events, event)
+	return nil
+}
+
+func TestBookingRepository_seats(t *testing.T) {
+	ctx := context.Background()
+	db := ticketsMemory.NewDB()
+	eventBus := &eventBusStub{}
+
+	shows := ticketsMemory.NewShowsRepository(db)
+	bookings := ticketsMemory.NewBookingRepository(db, eventBus)
+
+	show := entities.Show{ShowID: uuid.NewString(), NumberOfTickets: 3}
+	require.NoError(t, shows.AddShow(ctx, show))
+
+	booking := entities.Booking{BookingID: uuid.NewString(), ShowID: show.ShowID, NumberOfTickets: 2}
+	require.NoError(t, bookings.AddBooking(ctx, booking))
+
+	err := bookings.AddBooking(ctx, entities.Booking{BookingID: uuid.NewString(), ShowID: show.ShowID, NumberOfTickets: 2})
+	assert.ErrorIs(t, err, ticketsDb.ErrNoPlacesLeft)
+
+	canceledAt, err := bookings.BookingCanceledAt(ctx, booking.BookingID)
+	require.NoError(t, err)
+	assert.Nil(t, canceledAt)
+
+	require.NoError(t, bookings.CancelBooking(ctx, booking.BookingID))
+	require.NoError(t, bookings.CancelBooking(ctx, booking.BookingID), "canceling twice should be a no-op")
+
+	canceledAt, err = bookings.BookingCanceledAt(ctx, booking.BookingID)
+	require.NoError(t, err)
+	assert.NotNil(t, canceledAt)
+
+	canceledAt, err = bookings.BookingCanceledAt(ctx, uuid.NewString())
+	require.NoError(t, err)
+	assert.Nil(t, canceledAt, "bookings not made by the service are never canceled")
+
+	availability, err := shows.ShowAvailabilityByID(ctx, show.ShowID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, availability.RemainingSeats, "canceled booking should give the seats back")
+
+	require.Len(t, eventBus.events, 2)
+	assert.IsType(t, entities.BookingMade_v1{}, eventBus.events[0])
+	assert.IsType(t, entities.BookingCanceled_v1{}, eventBus.events[1])
+}
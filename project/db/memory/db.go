@@ -0,0 +1,75 @@
+// Package memory keeps the state of the service in memory, so it can run without Postgres.
+// Repositories have the same behaviour as the ones in the db package,
+// but events are published directly instead of through the outbox.
+package memory
+
+import (
+	"context"
+	"sync"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventBus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+// DB is shared by all repositories, like a database.
+type DB struct {
+	lock sync.RWMutex
+
+	tickets      map[string]ticketRow
+	ticketsOrder []string
+	shows        map[string]ticketsEntity.Show
+	bookings     map[string]ticketsEntity.Booking
+	events       map[string]ticketsEntity.DataLakeEvent
+	vipBundles   map[ticketsEntity.VipBundleID][]byte
+	// vipBundleIDs indexes bundles by the booking ID
+	vipBundleIDs    map[uuid.UUID]ticketsEntity.VipBundleID
+	opsBookings     map[uuid.UUID][]byte
+	inbox           map[inboxKey]struct{}
+	idempotencyKeys map[string]idempotencyKeyRow
+}
+
+type ticketRow struct {
+	ticket  ticketsEntity.Ticket
+	deleted bool
+}
+
+type inboxKey struct {
+	handlerName string
+	messageID   string
+}
+
+type idempotencyKeyRow struct {
+	request  ticketsEntity.IdempotentRequest
+	lockedAt time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		tickets:         map[string]ticketRow{},
+		shows:           map[string]ticketsEntity.Show{},
+		bookings:        map[string]ticketsEntity.Booking{},
+		events:          map[string]ticketsEntity.DataLakeEvent{},
+		vipBundles:      map[ticketsEntity.VipBundleID][]byte{},
+		vipBundleIDs:    map[uuid.UUID]ticketsEntity.VipBundleID{},
+		opsBookings:     map[uuid.UUID][]byte{},
+		inbox:           map[inboxKey]struct{}{},
+		idempotencyKeys: map[string]idempotencyKeyRow{},
+	}
+}
+
+// remainingSeats must be called with the lock held.
+func (db *DB) remainingSeats(show ticketsEntity.Show) int {
+	remaining := show.NumberOfTickets
+	for _, booking := range db.bookings {
+		if booking.ShowID == show.ShowID && booking.CanceledAt == nil {
+			remaining -= booking.NumberOfTickets
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	ticketsEntity "tickets/entities"
+)
+
+type EventsRepository struct {
+	db *DB
+}
+
+func NewEventsRepository(db *DB) EventsRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return EventsRepository{db: db}
+}
+
+func (s EventsRepository) SaveEvents(
+	ctx context.Context,
+	event ticketsEntity.ExternalEvent,
+	eventName string,
+	payload []byte,
+) error {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	// handling re-delivery
+	if _, ok := s.db.events[event.Header.ID]; ok {
+		return nil
+	}
+
+	s.db.events[event.Header.ID] = ticketsEntity.DataLakeEvent{
+		EventID:      event.Header.ID,
+		PublishedAt:  event.Header.PublishedAt,
+		EventName:    eventName,
+		EventPayload: payload,
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	ticketsDB "tickets/db"
+	ticketsEntity "tickets/entities"
+	"time"
+)
+
+type IdempotencyKeyRepository struct {
+	db *DB
+}
+
+func NewIdempotencyKeyRepository(db *DB) IdempotencyKeyRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return IdempotencyKeyRepository{db: db}
+}
+
+// Reserve tries to reserve the key for the request.
+// If the key was already used, reserved is false and the stored request is returned.
+func (r IdempotencyKeyRepository) Reserve(
+	ctx context.Context,
+	idempotencyKey string,
+	requestFingerprint string,
+) (existing ticketsEntity.IdempotentRequest, reserved bool, err error) {
+	r.db.lock.Lock()
+	defer r.db.lock.Unlock()
+
+	row, ok := r.db.idempotencyKeys[idempotencyKey]
+	canTakeOver := ok &&
+		row.request.ResponseStatus == nil &&
+		row.request.RequestFingerprint == requestFingerprint &&
+		time.Since(row.lockedAt) > ticketsDB.IdempotencyLockTimeout
+
+	if ok && !canTakeOver {
+		return row.request, false, nil
+	}
+
+	r.db.idempotencyKeys[idempotencyKey] = idempotencyKeyRow{
+		request: ticketsEntity.IdempotentRequest{
+			IdempotencyKey:     idempotencyKey,
+			RequestFingerprint: requestFingerprint,
+		},
+		lockedAt: time.Now(),
+	}
+
+	return ticketsEntity.IdempotentRequest{}, true, nil
+}
+
+func (r IdempotencyKeyRepository) SaveResponse(
+	ctx context.Context,
+	idempotencyKey string,
+	status int,
+	contentType string,
+	body []byte,
+) error {
+	r.db.lock.Lock()
+	defer r.db.lock.Unlock()
+
+	row, ok := r.db.idempotencyKeys[idempotencyKey]
+	if !ok {
+		return nil
+	}
+
+	row.request.ResponseStatus = &status
+	row.request.ResponseContentType = contentType
+	row.request.ResponseBody = body
+	r.db.idempotencyKeys[idempotencyKey] = row
+
+	return nil
+}
+
+// Release removes the reservation, so the request can be retried with the same key.
+func (r IdempotencyKeyRepository) Release(ctx context.Context, idempotencyKey string) error {
+	r.db.lock.Lock()
+	defer r.db.lock.Unlock()
+
+	if row, ok := r.db.idempotencyKeys[idempotencyKey]; ok && row.request.ResponseStatus == nil {
+		delete(r.db.idempotencyKeys, idempotencyKey)
+	}
+
+	return nil
+}
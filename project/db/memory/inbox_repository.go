@@ -0,0 +1,42 @@
+package memory
+
+import "context"
+
+type InboxRepository struct {
+	db *DB
+}
+
+func NewInboxRepository(db *DB) InboxRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return InboxRepository{db: db}
+}
+
+func (r InboxRepository) IsProcessed(ctx context.Context, handlerName string, messageID string) (bool, error) {
+	r.db.lock.RLock()
+	defer r.db.lock.RUnlock()
+
+	_, processed := r.db.inbox[inboxKey{handlerName: handlerName, messageID: messageID}]
+
+	return processed, nil
+}
+
+func (r InboxRepository) MarkProcessed(ctx context.Context, handlerName string, messageID string) error {
+	r.db.lock.Lock()
+	defer r.db.lock.Unlock()
+
+	r.db.inbox[inboxKey{handlerName: handlerName, messageID: messageID}] = struct{}{}
+
+	return nil
+}
+
+func (r InboxRepository) Unmark(ctx context.Context, handlerName string, messageID string) error {
+	r.db.lock.Lock()
+	defer r.db.lock.Unlock()
+
+	delete(r.db.inbox, inboxKey{handlerName: handlerName, messageID: messageID})
+
+	return nil
+}
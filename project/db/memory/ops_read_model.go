@@ -0,0 +1,365 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
+
+	ticketsEntity "tickets/entities"
+)
+
+type OpsBookingReadModel struct {
+	db       *DB
+	eventBus EventBus
+}
+
+func NewOpsBookingReadModel(db *DB, eventBus EventBus) OpsBookingReadModel {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return OpsBookingReadModel{db: db, eventBus: eventBus}
+}
+
+func (r OpsBookingReadModel) Bookings(
+	ctx context.Context,
+	filter ticketsEntity.OpsBookingsFilter,
+) ([]ticketsEntity.OpsBooking, *ticketsEntity.OpsBookingsCursor, error) {
+	r.db.lock.RLock()
+	defer r.db.lock.RUnlock()
+
+	result := []ticketsEntity.OpsBooking{}
+	for _, payload := range r.db.opsBookings {
+		rm, err := unmarshalOpsBooking(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if opsBookingMatches(rm, filter) {
+			result = append(result, rm)
+		}
+	}
+
+	slices.SortFunc(result, compareOpsBookings)
+
+	if len(result) <= filter.Limit {
+		return result, nil, nil
+	}
+
+	result = result[:filter.Limit]
+	if len(result) == 0 {
+		return result, nil, nil
+	}
+	last := result[len(result)-1]
+
+	return result, &ticketsEntity.OpsBookingsCursor{
+		BookedAt:  last.BookedAt,
+		BookingID: last.BookingID,
+	}, nil
+}
+
+func opsBookingMatches(rm ticketsEntity.OpsBooking, filter ticketsEntity.OpsBookingsFilter) bool {
+	if filter.BookedFrom != nil && rm.BookedAt.Before(*filter.BookedFrom) {
+		return false
+	}
+	if filter.BookedTo != nil && !rm.BookedAt.Before(*filter.BookedTo) {
+		return false
+	}
+	if filter.ShowID != "" && rm.ShowID != filter.ShowID {
+		return false
+	}
+	if filter.CustomerEmail != "" && !strings.EqualFold(rm.CustomerEmail, filter.CustomerEmail) {
+		return false
+	}
+	if filter.After != nil {
+		after := ticketsEntity.OpsBooking{BookedAt: filter.After.BookedAt, BookingID: filter.After.BookingID}
+		if compareOpsBookings(rm, after) <= 0 {
+			return false
+		}
+	}
+
+	var hasReceiptNumber, hasReceiptIssueDate, hasRefunded, hasPrinted bool
+	for _, ticket := range rm.Tickets {
+		hasReceiptNumber = hasReceiptNumber || ticket.ReceiptNumber == filter.ReceiptNumber
+		hasReceiptIssueDate = hasReceiptIssueDate ||
+			(!ticket.ReceiptIssuedAt.IsZero() && ticket.ReceiptIssuedAt.Format(time.DateOnly) == filter.ReceiptIssueDate)
+		hasRefunded = hasRefunded || !ticket.RefundedAt.IsZero()
+		hasPrinted = hasPrinted || !ticket.PrintedAt.IsZero()
+	}
+
+	if filter.ReceiptNumber != "" && !hasReceiptNumber {
+		return false
+	}
+	if filter.ReceiptIssueDate != "" && !hasReceiptIssueDate {
+		return false
+	}
+	if filter.Refunded != nil && hasRefunded != *filter.Refunded {
+		return false
+	}
+	if filter.Printed != nil && hasPrinted != *filter.Printed {
+		return false
+	}
+
+	return true
+}
+
+// compareOpsBookings orders bookings like the Postgres read model, by (booked_at, booking_id).
+func compareOpsBookings(a, b ticketsEntity.OpsBooking) int {
+	return cmp.Or(a.BookedAt.Compare(b.BookedAt), strings.Compare(a.BookingID.String(), b.BookingID.String()))
+}
+
+// ReservationReadModel returns sql.ErrNoRows when the booking doesn't exist, like the Postgres read model.
+func (r OpsBookingReadModel) ReservationReadModel(
+	ctx context.Context,
+	bookingID string,
+) (ticketsEntity.OpsBooking, error) {
+	id, err := uuid.Parse(bookingID)
+	if err != nil {
+		return ticketsEntity.OpsBooking{}, fmt.Errorf("invalid booking id %q: %w", bookingID, err)
+	}
+
+	r.db.lock.RLock()
+	defer r.db.lock.RUnlock()
+
+	payload, ok := r.db.opsBookings[id]
+	if !ok {
+		return ticketsEntity.OpsBooking{}, sql.ErrNoRows
+	}
+
+	return unmarshalOpsBooking(payload)
+}
+
+func (r OpsBookingReadModel) OnBookingMade(ctx context.Context, bookingMade *ticketsEntity.BookingMade_v1) error {
+	// this is the first event that should arrive, so we create the read model
+	bookingID, err := uuid.Parse(bookingMade.BookingID)
+	if err != nil {
+		return ticketsEntity.NewPermanentError(fmt.Errorf("invalid booking id %q: %w", bookingMade.BookingID, err))
+	}
+
+	err = func() error {
+		r.db.lock.Lock()
+		defer r.db.lock.Unlock()
+
+		if _, ok := r.db.opsBookings[bookingID]; ok {
+			// read model may be already updated by another event - we don't want to override
+			return nil
+		}
+
+		return r.save(ticketsEntity.OpsBooking{
+			BookingID:     bookingID,
+			LastUpdate:    time.Now(),
+			BookedAt:      bookingMade.Header.PublishedAt,
+			ShowID:        bookingMade.ShowID,
+			CustomerEmail: bookingMade.CustomerEmail,
+		})
+	}()
+	if err != nil {
+		return err
+	}
+
+	return r.publishUpdated(ctx, bookingID)
+}
+
+func (r OpsBookingReadModel) OnBookingCanceled(ctx context.Context, event *ticketsEntity.BookingCanceled_v1) error {
+	return r.updateReadModelByBookingID(
+		ctx,
+		event.BookingID,
+		func(rm ticketsEntity.OpsBooking) (ticketsEntity.OpsBooking, error) {
+			rm.CanceledAt = &event.Header.PublishedAt
+
+			return rm, nil
+		},
+	)
+}
+
+func (r OpsBookingReadModel) OnTicketBookingConfirmed(
+	ctx context.Context,
+	event *ticketsEntity.TicketBookingConfirmed_v1,
+) error {
+	return r.updateReadModelByBookingID(
+		ctx,
+		event.BookingID,
+		func(rm ticketsEntity.OpsBooking) (ticketsEntity.OpsBooking, error) {
+			ticket, ok := rm.Tickets[event.TicketID]
+			if !ok {
+				log.FromContext(ctx).With("ticket_id", event.TicketID).Debug("Creating ticket read model")
+			}
+
+			ticket.PriceAmount = event.Price.Amount
+			ticket.PriceCurrency = event.Price.Currency
+			ticket.CustomerEmail = event.CustomerEmail
+			ticket.ConfirmedAt = event.Header.PublishedAt
+
+			rm.Tickets[event.TicketID] = ticket
+
+			return rm, nil
+		},
+	)
+}
+
+func (r OpsBookingReadModel) OnTicketRefunded(ctx context.Context, event *ticketsEntity.TicketRefunded_v1) error {
+	return r.updateReadModelByTicketID(
+		ctx,
+		event.TicketID,
+		func(rm ticketsEntity.OpsTicket) (ticketsEntity.OpsTicket, error) {
+			rm.RefundedAt = event.Header.PublishedAt
+
+			return rm, nil
+		},
+	)
+}
+
+func (r OpsBookingReadModel) OnTicketPrinted(ctx context.Context, event *ticketsEntity.TicketPrinted_v1) error {
+	return r.updateReadModelByTicketID(
+		ctx,
+		event.TicketID,
+		func(rm ticketsEntity.OpsTicket) (ticketsEntity.OpsTicket, error) {
+			rm.PrintedAt = event.Header.PublishedAt
+			rm.PrintedFileName = event.FileName
+
+			return rm, nil
+		},
+	)
+}
+
+func (r OpsBookingReadModel) OnTicketReceiptIssued(
+	ctx context.Context,
+	issued *ticketsEntity.TicketReceiptIssued_v1,
+) error {
+	return r.updateReadModelByTicketID(
+		ctx,
+		issued.TicketID,
+		func(rm ticketsEntity.OpsTicket) (ticketsEntity.OpsTicket, error) {
+			rm.ReceiptIssuedAt = issued.IssuedAt
+			rm.ReceiptNumber = issued.ReceiptNumber
+
+			return rm, nil
+		},
+	)
+}
+
+func (r OpsBookingReadModel) updateReadModelByBookingID(
+	ctx context.Context,
+	bookingID string,
+	updateFunc func(ticket ticketsEntity.OpsBooking) (ticketsEntity.OpsBooking, error),
+) error {
+	id, err := uuid.Parse(bookingID)
+	if err != nil {
+		return ticketsEntity.NewPermanentError(fmt.Errorf("invalid booking id %q: %w", bookingID, err))
+	}
+
+	err = func() error {
+		r.db.lock.Lock()
+		defer r.db.lock.Unlock()
+
+		payload, ok := r.db.opsBookings[id]
+		if !ok {
+			// events arrived out of order - it should spin until the read model is created
+			return fmt.Errorf("read model for booking %s not exist yet", bookingID)
+		}
+
+		rm, err := unmarshalOpsBooking(payload)
+		if err != nil {
+			return err
+		}
+
+		rm, err = updateFunc(rm)
+		if err != nil {
+			return err
+		}
+
+		return r.save(rm)
+	}()
+	if err != nil {
+		return err
+	}
+
+	return r.publishUpdated(ctx, id)
+}
+
+func (r OpsBookingReadModel) updateReadModelByTicketID(
+	ctx context.Context,
+	ticketID string,
+	updateFunc func(ticket ticketsEntity.OpsTicket) (ticketsEntity.OpsTicket, error),
+) error {
+	bookingID, err := func() (uuid.UUID, error) {
+		r.db.lock.Lock()
+		defer r.db.lock.Unlock()
+
+		for _, payload := range r.db.opsBookings {
+			rm, err := unmarshalOpsBooking(payload)
+			if err != nil {
+				return uuid.Nil, err
+			}
+
+			ticket, ok := rm.Tickets[ticketID]
+			if !ok {
+				continue
+			}
+
+			ticket, err = updateFunc(ticket)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			rm.Tickets[ticketID] = ticket
+
+			return rm.BookingID, r.save(rm)
+		}
+
+		// events arrived out of order - it should spin until the read model is created
+		return uuid.Nil, fmt.Errorf("read model for ticket %s not exist yet", ticketID)
+	}()
+	if err != nil {
+		return err
+	}
+
+	return r.publishUpdated(ctx, bookingID)
+}
+
+func (r OpsBookingReadModel) publishUpdated(ctx context.Context, bookingID uuid.UUID) error {
+	if r.eventBus == nil {
+		return nil
+	}
+
+	return r.eventBus.Publish(
+		ctx, &ticketsEntity.InternalOpsReadModelUpdated{
+			Header:    ticketsEntity.NewMessageHeader(),
+			BookingID: bookingID,
+		},
+	)
+}
+
+// save must be called with the lock held.
+func (r OpsBookingReadModel) save(rm ticketsEntity.OpsBooking) error {
+	rm.LastUpdate = time.Now()
+
+	payload, err := json.Marshal(rm)
+	if err != nil {
+		return fmt.Errorf("could not marshal read model: %w", err)
+	}
+
+	r.db.opsBookings[rm.BookingID] = payload
+
+	return nil
+}
+
+func unmarshalOpsBooking(payload []byte) (ticketsEntity.OpsBooking, error) {
+	var rm ticketsEntity.OpsBooking
+	if err := json.Unmarshal(payload, &rm); err != nil {
+		return ticketsEntity.OpsBooking{}, err
+	}
+
+	if rm.Tickets == nil {
+		rm.Tickets = map[string]ticketsEntity.OpsTicket{}
+	}
+
+	return rm, nil
+}
@@ -0,0 +1,73 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsMemory "tickets/db/memory"
+	"tickets/entities"
+)
+
+func TestOpsBookingReadModel_Bookings_printed(t *testing.T) {
+	ctx := context.Background()
+	readModel := ticketsMemory.NewOpsBookingReadModel(ticketsMemory.NewDB(), nil)
+
+	printedBookingID := addOpsBooking(t, readModel, true)
+	notPrintedBookingID := addOpsBooking(t, readModel, false)
+
+	testCases := []struct {
+		name     string
+		printed  *bool
+		expected []string
+	}{
+		{name: "printed", printed: lo.ToPtr(true), expected: []string{printedBookingID}},
+		{name: "not printed", printed: lo.ToPtr(false), expected: []string{notPrintedBookingID}},
+		{name: "any", expected: []string{printedBookingID, notPrintedBookingID}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bookings, _, err := readModel.Bookings(ctx, entities.OpsBookingsFilter{Printed: tc.printed, Limit: 10})
+			require.NoError(t, err)
+
+			var bookingIDs []string
+			for _, booking := range bookings {
+				bookingIDs = append(bookingIDs, booking.BookingID.String())
+			}
+			assert.ElementsMatch(t, tc.expected, bookingIDs)
+		})
+	}
+}
+
+func addOpsBooking(t *testing.T, readModel ticketsMemory.OpsBookingReadModel, printed bool) string {
+	t.Helper()
+	ctx := context.Background()
+
+	bookingID := uuid.NewString()
+	ticketID := uuid.NewString()
+
+	require.NoError(t, readModel.OnBookingMade(ctx, &entities.BookingMade_v1{
+		Header:    entities.NewMessageHeader(),
+		BookingID: bookingID,
+		ShowID:    uuid.NewString(),
+	}))
+	require.NoError(t, readModel.OnTicketBookingConfirmed(ctx, &entities.TicketBookingConfirmed_v1{
+		Header:    entities.NewMessageHeader(),
+		BookingID: bookingID,
+		TicketID:  ticketID,
+	}))
+	if printed {
+		require.NoError(t, readModel.OnTicketPrinted(ctx, &entities.TicketPrinted_v1{
+			Header:   entities.NewMessageHeader(),
+			TicketID: ticketID,
+			FileName: ticketID + "-ticket.html",
+		}))
+	}
+
+	return bookingID
+}
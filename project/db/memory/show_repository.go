@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	ticketsEntity "tickets/entities"
+)
+
+type ShowsRepository struct {
+	db *DB
+}
+
+func NewShowsRepository(db *DB) ShowsRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return ShowsRepository{db: db}
+}
+
+func (s ShowsRepository) AddShow(ctx context.Context, show ticketsEntity.Show) error {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	if _, ok := s.db.shows[show.ShowID]; !ok {
+		s.db.shows[show.ShowID] = show
+	}
+
+	return nil
+}
+
+// ShowByID returns sql.ErrNoRows when the show doesn't exist, like the Postgres repository.
+func (s ShowsRepository) ShowByID(ctx context.Context, showID string) (ticketsEntity.Show, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	show, ok := s.db.shows[showID]
+	if !ok {
+		return ticketsEntity.Show{}, sql.ErrNoRows
+	}
+
+	return show, nil
+}
+
+func (s ShowsRepository) ShowAvailabilityByID(ctx context.Context, showID string) (ticketsEntity.ShowAvailability, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	show, ok := s.db.shows[showID]
+	if !ok {
+		return ticketsEntity.ShowAvailability{}, sql.ErrNoRows
+	}
+
+	return ticketsEntity.ShowAvailability{
+		Show:           show,
+		RemainingSeats: s.db.remainingSeats(show),
+	}, nil
+}
+
+func (s ShowsRepository) AllShows(
+	ctx context.Context,
+	filter ticketsEntity.ShowsFilter,
+) ([]ticketsEntity.ShowAvailability, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	shows := []ticketsEntity.ShowAvailability{}
+	for _, show := range s.db.shows {
+		if filter.Venue != "" && show.Venue != filter.Venue {
+			continue
+		}
+		if filter.From != nil && show.StartTime.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !show.StartTime.Before(*filter.To) {
+			continue
+		}
+
+		shows = append(shows, ticketsEntity.ShowAvailability{
+			Show:           show,
+			RemainingSeats: s.db.remainingSeats(show),
+		})
+	}
+
+	slices.SortFunc(shows, func(a, b ticketsEntity.ShowAvailability) int {
+		return cmp.Or(a.StartTime.Compare(b.StartTime), cmp.Compare(a.ShowID, b.ShowID))
+	})
+
+	shows = shows[min(filter.Offset, len(shows)):]
+	shows = shows[:min(filter.Limit, len(shows))]
+
+	return shows, nil
+}
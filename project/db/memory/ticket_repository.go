@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"tickets/entities"
+)
+
+type TicketsRepository struct {
+	db *DB
+}
+
+func NewTicketsRepository(db *DB) TicketsRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return TicketsRepository{db: db}
+}
+
+func (t TicketsRepository) Add(ctx context.Context, ticket entities.Ticket) error {
+	t.db.lock.Lock()
+	defer t.db.lock.Unlock()
+
+	if row, ok := t.db.tickets[ticket.TicketID]; ok {
+		if row.ticket.BookingID == "" {
+			row.ticket.BookingID = ticket.BookingID
+			t.db.tickets[ticket.TicketID] = row
+		}
+		return nil
+	}
+
+	t.db.tickets[ticket.TicketID] = ticketRow{ticket: ticket}
+	t.db.ticketsOrder = append(t.db.ticketsOrder, ticket.TicketID)
+
+	return nil
+}
+
+func (t TicketsRepository) Remove(ctx context.Context, ticket entities.Ticket) error {
+	t.db.lock.Lock()
+	defer t.db.lock.Unlock()
+
+	row, ok := t.db.tickets[ticket.TicketID]
+	if !ok {
+		return fmt.Errorf("ticket with id %s not found", ticket.TicketID)
+	}
+
+	row.deleted = true
+	t.db.tickets[ticket.TicketID] = row
+
+	return nil
+}
+
+func (t TicketsRepository) FindAll(ctx context.Context) ([]entities.Ticket, error) {
+	return t.find(func(ticket entities.Ticket) bool { return true }), nil
+}
+
+func (t TicketsRepository) FindByBookingID(ctx context.Context, bookingID string) ([]entities.Ticket, error) {
+	return t.find(func(ticket entities.Ticket) bool { return ticket.BookingID == bookingID }), nil
+}
+
+func (t TicketsRepository) find(matches func(ticket entities.Ticket) bool) []entities.Ticket {
+	t.db.lock.RLock()
+	defer t.db.lock.RUnlock()
+
+	var tickets []entities.Ticket
+	for _, ticketID := range t.db.ticketsOrder {
+		row := t.db.tickets[ticketID]
+		if !row.deleted && matches(row.ticket) {
+			tickets = append(tickets, row.ticket)
+		}
+	}
+
+	return tickets
+}
@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VipBundleRepository struct {
+	db       *DB
+	eventBus EventBus
+}
+
+func NewVipBundleRepository(db *DB, eventBus EventBus) *VipBundleRepository {
+	if db == nil {
+		panic("db must be set")
+	}
+	if eventBus == nil {
+		panic("missing eventBus")
+	}
+
+	return &VipBundleRepository{db: db, eventBus: eventBus}
+}
+
+func (v VipBundleRepository) Add(ctx context.Context, vipBundle ticketsEntity.VipBundle) error {
+	payload, err := json.Marshal(vipBundle)
+	if err != nil {
+		return fmt.Errorf("could not marshal vip bundle: %w", err)
+	}
+
+	err = func() error {
+		v.db.lock.Lock()
+		defer v.db.lock.Unlock()
+
+		if _, ok := v.db.vipBundles[vipBundle.VipBundleID]; ok {
+			return fmt.Errorf("could not insert vip bundle: vip bundle %s already exists", vipBundle.VipBundleID)
+		}
+		if _, ok := v.db.vipBundleIDs[vipBundle.BookingID]; ok {
+			return fmt.Errorf("could not insert vip bundle: booking %s already has a vip bundle", vipBundle.BookingID)
+		}
+
+		v.db.vipBundles[vipBundle.VipBundleID] = payload
+		v.db.vipBundleIDs[vipBundle.BookingID] = vipBundle.VipBundleID
+
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	err = v.eventBus.Publish(
+		ctx, ticketsEntity.VipBundleInitialized_v1{
+			Header:      ticketsEntity.NewMessageHeader(),
+			VipBundleID: vipBundle.VipBundleID,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("could not publish event: %w", err)
+	}
+
+	return nil
+}
+
+func (v VipBundleRepository) Get(
+	ctx context.Context,
+	vipBundleID ticketsEntity.VipBundleID,
+) (ticketsEntity.VipBundle, error) {
+	v.db.lock.RLock()
+	defer v.db.lock.RUnlock()
+
+	return v.vipBundleByID(vipBundleID)
+}
+
+func (v VipBundleRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) (ticketsEntity.VipBundle, error) {
+	v.db.lock.RLock()
+	defer v.db.lock.RUnlock()
+
+	return v.getByBookingID(bookingID)
+}
+
+func (v VipBundleRepository) VipBundles(
+	ctx context.Context,
+	filter ticketsEntity.VipBundlesFilter,
+) ([]ticketsEntity.VipBundle, *ticketsEntity.VipBundlesCursor, error) {
+	v.db.lock.RLock()
+	defer v.db.lock.RUnlock()
+
+	result := []ticketsEntity.VipBundle{}
+	for vipBundleID := range v.db.vipBundles {
+		vb, err := v.vipBundleByID(vipBundleID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if vipBundleMatches(vb, filter) {
+			result = append(result, vb)
+		}
+	}
+
+	slices.SortFunc(result, compareVipBundles)
+
+	if len(result) <= filter.Limit {
+		return result, nil, nil
+	}
+
+	result = result[:filter.Limit]
+	if len(result) == 0 {
+		return result, nil, nil
+	}
+	last := result[len(result)-1]
+
+	return result, &ticketsEntity.VipBundlesCursor{
+		CreatedAt:   last.CreatedAt,
+		VipBundleID: last.VipBundleID.UUID,
+	}, nil
+}
+
+func vipBundleMatches(vb ticketsEntity.VipBundle, filter ticketsEntity.VipBundlesFilter) bool {
+	if filter.Status != "" && vb.Status() != filter.Status {
+		return false
+	}
+	if filter.CustomerEmail != "" && !strings.EqualFold(vb.CustomerEmail, filter.CustomerEmail) {
+		return false
+	}
+	if filter.After != nil {
+		after := ticketsEntity.VipBundle{
+			CreatedAt:   filter.After.CreatedAt,
+			VipBundleID: ticketsEntity.VipBundleID{UUID: filter.After.VipBundleID},
+		}
+		if compareVipBundles(vb, after) <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareVipBundles orders bundles like the Postgres repository, by (created_at, vip_bundle_id).
+func compareVipBundles(a, b ticketsEntity.VipBundle) int {
+	return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.VipBundleID.String(), b.VipBundleID.String()))
+}
+
+func (v VipBundleRepository) UpdateByID(
+	ctx context.Context,
+	vipBundleID ticketsEntity.VipBundleID,
+	updateFn func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error),
+) (ticketsEntity.VipBundle, error) {
+	v.db.lock.Lock()
+	defer v.db.lock.Unlock()
+
+	vb, err := v.vipBundleByID(vipBundleID)
+	if err != nil {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not update vip bundle: %w", err)
+	}
+
+	return v.update(vb, updateFn)
+}
+
+func (v VipBundleRepository) UpdateByBookingID(
+	ctx context.Context,
+	bookingID uuid.UUID,
+	updateFn func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error),
+) (ticketsEntity.VipBundle, error) {
+	v.db.lock.Lock()
+	defer v.db.lock.Unlock()
+
+	vb, err := v.getByBookingID(bookingID)
+	if err != nil {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not update vip bundle: %w", err)
+	}
+
+	return v.update(vb, updateFn)
+}
+
+func (v VipBundleRepository) TimeOutVipBundles(ctx context.Context, now time.Time, limit int) (int, error) {
+	events, timedOut, err := func() ([]ticketsEntity.InternalVipBundleStepTimedOut, int, error) {
+		v.db.lock.Lock()
+		defer v.db.lock.Unlock()
+
+		var pastDeadline []ticketsEntity.VipBundle
+		for vipBundleID := range v.db.vipBundles {
+			vb, err := v.vipBundleByID(vipBundleID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if deadline := vb.NextDeadline(); deadline != nil && !deadline.After(now) {
+				pastDeadline = append(pastDeadline, vb)
+			}
+		}
+
+		slices.SortFunc(pastDeadline, func(a, b ticketsEntity.VipBundle) int {
+			return a.NextDeadline().Compare(*b.NextDeadline())
+		})
+		if len(pastDeadline) > limit {
+			pastDeadline = pastDeadline[:limit]
+		}
+
+		var events []ticketsEntity.InternalVipBundleStepTimedOut
+		for _, vb := range pastDeadline {
+			_, err := v.update(vb, func(vb ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+				events = append(events, vb.TimeOut(now)...)
+				return vb, nil
+			})
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		return events, len(pastDeadline), nil
+	}()
+	if err != nil {
+		return 0, fmt.Errorf("could not time out vip bundles: %w", err)
+	}
+
+	// there is no outbox, a timeout is lost when it can't be published
+	for _, event := range events {
+		if err := v.eventBus.Publish(ctx, event); err != nil {
+			return timedOut, fmt.Errorf("could not publish event: %w", err)
+		}
+	}
+
+	return timedOut, nil
+}
+
+// update must be called with the lock held.
+func (v VipBundleRepository) update(
+	vb ticketsEntity.VipBundle,
+	updateFn func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error),
+) (ticketsEntity.VipBundle, error) {
+	previousBookingID := vb.BookingID
+
+	vb, err := updateFn(vb)
+	if err != nil {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not update vip bundle: %w", err)
+	}
+
+	payload, err := json.Marshal(vb)
+	if err != nil {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not marshal vip bundle: %w", err)
+	}
+
+	v.db.vipBundles[vb.VipBundleID] = payload
+	delete(v.db.vipBundleIDs, previousBookingID)
+	v.db.vipBundleIDs[vb.BookingID] = vb.VipBundleID
+
+	return vb, nil
+}
+
+// vipBundleByID must be called with the lock held.
+func (v VipBundleRepository) vipBundleByID(vipBundleID ticketsEntity.VipBundleID) (ticketsEntity.VipBundle, error) {
+	payload, ok := v.db.vipBundles[vipBundleID]
+	if !ok {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not get vip bundle: %w", sql.ErrNoRows)
+	}
+
+	var vipBundle ticketsEntity.VipBundle
+	if err := json.Unmarshal(payload, &vipBundle); err != nil {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not unmarshal vip bundle: %w", err)
+	}
+
+	return vipBundle, nil
+}
+
+// getByBookingID must be called with the lock held.
+func (v VipBundleRepository) getByBookingID(bookingID uuid.UUID) (ticketsEntity.VipBundle, error) {
+	vipBundleID, ok := v.db.vipBundleIDs[bookingID]
+	if !ok {
+		return ticketsEntity.VipBundle{}, fmt.Errorf("could not get vip bundle: %w", sql.ErrNoRows)
+	}
+
+	return v.vipBundleByID(vipBundleID)
+}
@@ -0,0 +1,51 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsMemory "tickets/db/memory"
+	"tickets/entities"
+)
+
+func TestVipBundleRepository_TimeOutVipBundles(t *testing.T) {
+	ctx := context.Background()
+	eventBus := &eventBusStub{}
+	repo := ticketsMemory.NewVipBundleRepository(ticketsMemory.NewDB(), eventBus)
+
+	now := time.Now()
+
+	timedOut := entities.VipBundle{VipBundleID: entities.VipBundleID{UUID: uuid.New()}, BookingID: uuid.New()}
+	timedOut.SetDeadline(entities.VipBundleStepTicketsConfirmed, now.Add(-time.Minute))
+	timedOut.SetDeadline(entities.VipBundleStepInboundFlightBooked, now.Add(time.Minute))
+	require.NoError(t, repo.Add(ctx, timedOut))
+
+	pending := entities.VipBundle{VipBundleID: entities.VipBundleID{UUID: uuid.New()}, BookingID: uuid.New()}
+	pending.SetDeadline(entities.VipBundleStepBookingMade, now.Add(time.Minute))
+	require.NoError(t, repo.Add(ctx, pending))
+
+	eventBus.events = nil
+
+	count, err := repo.TimeOutVipBundles(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.Len(t, eventBus.events, 1)
+	event := eventBus.events[0].(entities.InternalVipBundleStepTimedOut)
+	assert.Equal(t, timedOut.VipBundleID, event.VipBundleID)
+	assert.Equal(t, entities.VipBundleStepTicketsConfirmed, event.Step)
+
+	vb, err := repo.Get(ctx, timedOut.VipBundleID)
+	require.NoError(t, err)
+	assert.NotContains(t, vb.Deadlines, entities.VipBundleStepTicketsConfirmed, "timed out deadline should be cleared")
+	assert.Contains(t, vb.Deadlines, entities.VipBundleStepInboundFlightBooked)
+
+	count, err = repo.TimeOutVipBundles(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Zero(t, count, "step should time out once")
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS read_model_ops_bookings;
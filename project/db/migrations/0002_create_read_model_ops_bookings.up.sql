@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS read_model_ops_bookings (
+	booking_id UUID PRIMARY KEY,
+	payload JSONB NOT NULL
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS events (
+	event_id UUID PRIMARY KEY,
+	published_at TIMESTAMP NOT NULL,
+	event_name VARCHAR(255) NOT NULL,
+	event_payload JSONB NOT NULL
+);
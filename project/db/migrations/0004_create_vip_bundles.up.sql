@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS vip_bundles (
+	vip_bundle_id UUID PRIMARY KEY,
+	booking_id UUID NOT NULL UNIQUE,
+	payload JSONB NOT NULL
+);
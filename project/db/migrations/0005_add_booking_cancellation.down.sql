@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tickets_booking_id_idx;
+ALTER TABLE tickets DROP COLUMN IF EXISTS booking_id;
+ALTER TABLE bookings DROP COLUMN IF EXISTS canceled_at;
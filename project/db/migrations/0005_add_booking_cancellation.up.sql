@@ -0,0 +1,15 @@
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMP;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS booking_id UUID;
+
+-- tickets stored before booking_id existed, the booking is known from the event that confirmed the ticket
+UPDATE tickets SET
+	booking_id = (events.event_payload ->> 'booking_id')::uuid
+FROM
+	events
+WHERE
+	tickets.booking_id IS NULL
+	AND events.event_name IN ('TicketBookingConfirmed', 'TicketBookingConfirmed_v1')
+	AND events.event_payload ->> 'ticket_id' = tickets.ticket_id::text
+	AND events.event_payload ->> 'booking_id' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$';
+
+CREATE INDEX IF NOT EXISTS tickets_booking_id_idx ON tickets (booking_id);
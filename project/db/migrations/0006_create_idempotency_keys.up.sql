@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	idempotency_key VARCHAR(255) PRIMARY KEY,
+	request_fingerprint CHAR(64) NOT NULL,
+	response_status INT,
+	response_content_type VARCHAR(255) NOT NULL DEFAULT '',
+	response_body BYTEA,
+	locked_at TIMESTAMP NOT NULL,
+	completed_at TIMESTAMP
+);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS read_model_ops_bookings_receipt_issue_dates_idx;
+DROP INDEX IF EXISTS read_model_ops_bookings_receipt_numbers_idx;
+DROP INDEX IF EXISTS read_model_ops_bookings_refunded_idx;
+DROP INDEX IF EXISTS read_model_ops_bookings_customer_email_idx;
+DROP INDEX IF EXISTS read_model_ops_bookings_show_id_idx;
+DROP INDEX IF EXISTS read_model_ops_bookings_booked_at_idx;
+
+ALTER TABLE read_model_ops_bookings
+	DROP COLUMN IF EXISTS booked_at,
+	DROP COLUMN IF EXISTS show_id,
+	DROP COLUMN IF EXISTS customer_email,
+	DROP COLUMN IF EXISTS has_refunded_tickets,
+	DROP COLUMN IF EXISTS has_printed_tickets,
+	DROP COLUMN IF EXISTS receipt_numbers,
+	DROP COLUMN IF EXISTS receipt_issue_dates;
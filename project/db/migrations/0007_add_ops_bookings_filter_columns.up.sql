@@ -0,0 +1,55 @@
+ALTER TABLE read_model_ops_bookings
+	ADD COLUMN IF NOT EXISTS booked_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS show_id UUID,
+	ADD COLUMN IF NOT EXISTS customer_email VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS has_refunded_tickets BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS has_printed_tickets BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS receipt_numbers TEXT[] NOT NULL DEFAULT '{}',
+	ADD COLUMN IF NOT EXISTS receipt_issue_dates DATE[] NOT NULL DEFAULT '{}';
+
+-- backfill rows created before the columns existed
+WITH tickets AS (
+	SELECT
+		booking_id,
+		t.value AS ticket
+	FROM
+		read_model_ops_bookings,
+		jsonb_each(
+			CASE WHEN jsonb_typeof(payload -> 'tickets') = 'object' THEN payload -> 'tickets' ELSE '{}' END
+		) t
+)
+UPDATE read_model_ops_bookings rm SET
+	booked_at = (payload ->> 'booked_at')::timestamptz,
+	show_id = NULLIF(payload ->> 'show_id', '')::uuid,
+	customer_email = lower(payload ->> 'customer_email'),
+	has_refunded_tickets = EXISTS (
+		SELECT 1 FROM tickets
+		WHERE tickets.booking_id = rm.booking_id AND ticket ->> 'refunded_at' NOT LIKE '0001-01-01%'
+	),
+	has_printed_tickets = EXISTS (
+		SELECT 1 FROM tickets
+		WHERE tickets.booking_id = rm.booking_id AND ticket ->> 'printed_at' NOT LIKE '0001-01-01%'
+	),
+	receipt_numbers = ARRAY(
+		SELECT ticket ->> 'receipt_number' FROM tickets
+		WHERE tickets.booking_id = rm.booking_id AND ticket ->> 'receipt_number' <> ''
+	),
+	receipt_issue_dates = ARRAY(
+		SELECT ((ticket ->> 'receipt_issued_at')::timestamptz AT TIME ZONE 'UTC')::date FROM tickets
+		WHERE tickets.booking_id = rm.booking_id AND ticket ->> 'receipt_issued_at' NOT LIKE '0001-01-01%'
+	)
+WHERE
+	booked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_booked_at_idx
+	ON read_model_ops_bookings (booked_at, booking_id);
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_show_id_idx
+	ON read_model_ops_bookings (show_id, booked_at, booking_id);
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_customer_email_idx
+	ON read_model_ops_bookings (customer_email, booked_at, booking_id);
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_refunded_idx
+	ON read_model_ops_bookings (booked_at, booking_id) WHERE has_refunded_tickets;
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_receipt_numbers_idx
+	ON read_model_ops_bookings USING GIN (receipt_numbers);
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_receipt_issue_dates_idx
+	ON read_model_ops_bookings USING GIN (receipt_issue_dates);
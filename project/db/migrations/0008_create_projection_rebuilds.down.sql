@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS projection_rebuilds;
+DROP INDEX IF EXISTS events_published_at_idx;
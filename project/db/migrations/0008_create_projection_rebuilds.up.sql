@@ -0,0 +1,19 @@
+CREATE INDEX IF NOT EXISTS events_published_at_idx ON events (published_at, event_id);
+
+CREATE TABLE IF NOT EXISTS projection_rebuilds (
+	rebuild_id UUID PRIMARY KEY,
+	projection VARCHAR(255) NOT NULL,
+	shadow_table VARCHAR(255) NOT NULL,
+	status VARCHAR(32) NOT NULL,
+	checkpoint_published_at TIMESTAMP,
+	checkpoint_event_id UUID,
+	events_processed BIGINT NOT NULL DEFAULT 0,
+	error TEXT,
+	started_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ
+);
+
+-- only one rebuild of the projection can be running at the time
+CREATE UNIQUE INDEX IF NOT EXISTS projection_rebuilds_running_idx
+	ON projection_rebuilds (projection) WHERE status = 'running';
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS processed_messages (
+	handler_name VARCHAR(255) NOT NULL,
+	message_id VARCHAR(255) NOT NULL,
+	processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (handler_name, message_id)
+);
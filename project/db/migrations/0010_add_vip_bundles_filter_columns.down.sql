@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS vip_bundles_customer_email_idx;
+DROP INDEX IF EXISTS vip_bundles_status_idx;
+DROP INDEX IF EXISTS vip_bundles_created_at_idx;
+
+ALTER TABLE vip_bundles
+	DROP COLUMN IF EXISTS created_at,
+	DROP COLUMN IF EXISTS status,
+	DROP COLUMN IF EXISTS customer_email;
@@ -0,0 +1,33 @@
+ALTER TABLE vip_bundles
+	ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS status VARCHAR(20),
+	ADD COLUMN IF NOT EXISTS customer_email VARCHAR(255);
+
+-- backfill rows created before the columns existed, bundles didn't store when they were created
+UPDATE vip_bundles SET
+	created_at = COALESCE(
+		NULLIF(payload ->> 'created_at', '0001-01-01T00:00:00Z')::timestamptz,
+		(payload ->> 'booking_made_at')::timestamptz,
+		now()
+	),
+	-- bundles stored without is_finalized are read as not finalized
+	status = CASE
+		WHEN NOT COALESCE((payload ->> 'is_finalized')::boolean, false) THEN 'in_progress'
+		WHEN (payload ->> 'failed')::boolean THEN 'failed'
+		ELSE 'succeeded'
+	END,
+	customer_email = lower(payload ->> 'customer_email')
+WHERE
+	created_at IS NULL;
+
+ALTER TABLE vip_bundles
+	ALTER COLUMN created_at SET NOT NULL,
+	ALTER COLUMN status SET NOT NULL,
+	ALTER COLUMN customer_email SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS vip_bundles_created_at_idx
+	ON vip_bundles (created_at, vip_bundle_id);
+CREATE INDEX IF NOT EXISTS vip_bundles_status_idx
+	ON vip_bundles (status, created_at, vip_bundle_id);
+CREATE INDEX IF NOT EXISTS vip_bundles_customer_email_idx
+	ON vip_bundles (customer_email, created_at, vip_bundle_id);
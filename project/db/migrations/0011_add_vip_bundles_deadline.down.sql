@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS vip_bundles_deadline_idx;
+
+ALTER TABLE vip_bundles
+	DROP COLUMN IF EXISTS deadline;
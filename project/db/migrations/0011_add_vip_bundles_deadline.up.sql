@@ -0,0 +1,9 @@
+-- deadlines of the pending steps are kept in the payload, the column is the earliest of them,
+-- so bundles past a deadline are found without scanning the payloads
+ALTER TABLE vip_bundles
+	ADD COLUMN IF NOT EXISTS deadline TIMESTAMPTZ;
+
+-- only bundles waiting for a step have a deadline
+CREATE INDEX IF NOT EXISTS vip_bundles_deadline_idx
+	ON vip_bundles (deadline)
+	WHERE deadline IS NOT NULL;
@@ -0,0 +1,11 @@
+ALTER TABLE projection_rebuilds
+	DROP COLUMN IF EXISTS checkpoint_stored_xid,
+	DROP COLUMN IF EXISTS checkpoint_stored_seq,
+	ADD COLUMN IF NOT EXISTS checkpoint_published_at TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS checkpoint_event_id UUID;
+
+DROP INDEX IF EXISTS events_stored_idx;
+
+ALTER TABLE events
+	DROP COLUMN IF EXISTS stored_xid,
+	DROP COLUMN IF EXISTS stored_seq;
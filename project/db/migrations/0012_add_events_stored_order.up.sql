@@ -0,0 +1,37 @@
+-- published_at is set by the publisher and events are stored asynchronously, so an event can be stored
+-- after events published later than it; rebuilds follow the order the events were stored in instead
+ALTER TABLE events
+	ADD COLUMN IF NOT EXISTS stored_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+	ADD COLUMN IF NOT EXISTS stored_seq BIGINT;
+
+-- events stored before share the migration's transaction, they are numbered in the order they were published,
+-- a serial column would number them in the order the rows happen to be in the table
+UPDATE events SET
+	stored_seq = ordered.seq
+FROM (
+	SELECT event_id, row_number() OVER (ORDER BY published_at, event_id) AS seq FROM events
+) AS ordered
+WHERE
+	events.event_id = ordered.event_id;
+
+CREATE SEQUENCE IF NOT EXISTS events_stored_seq_seq OWNED BY events.stored_seq;
+SELECT setval('events_stored_seq_seq', COALESCE((SELECT max(stored_seq) FROM events), 0) + 1, false);
+
+ALTER TABLE events
+	ALTER COLUMN stored_seq SET DEFAULT nextval('events_stored_seq_seq'),
+	ALTER COLUMN stored_seq SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS events_stored_idx ON events (stored_xid, stored_seq);
+
+-- checkpoints in the published order can't be converted, unfinished rebuilds start over when resumed
+UPDATE projection_rebuilds SET
+	status = 'failed',
+	error = 'the rebuild was checkpointed before events were stored in order, resume it to start over'
+WHERE
+	status = 'running';
+
+ALTER TABLE projection_rebuilds
+	DROP COLUMN IF EXISTS checkpoint_published_at,
+	DROP COLUMN IF EXISTS checkpoint_event_id,
+	ADD COLUMN IF NOT EXISTS checkpoint_stored_xid BIGINT,
+	ADD COLUMN IF NOT EXISTS checkpoint_stored_seq BIGINT;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS read_model_ops_bookings_printed_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS read_model_ops_bookings_printed_idx
+	ON read_model_ops_bookings (has_printed_tickets, booked_at, booking_id);
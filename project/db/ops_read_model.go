@@ -6,19 +6,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	ticketsEntity "tickets/entities"
 )
 
+const OpsBookingsTable = "read_model_ops_bookings"
+
 type OpsBookingReadModel struct {
 	db       *sqlx.DB
 	eventBus *cqrs.EventBus
+	table    string
 }
 
 func NewOpsBookingReadModel(db *sqlx.DB, eventBus *cqrs.EventBus) OpsBookingReadModel {
@@ -26,76 +31,105 @@ func NewOpsBookingReadModel(db *sqlx.DB, eventBus *cqrs.EventBus) OpsBookingRead
 		panic("db is nil")
 	}
 
-	return OpsBookingReadModel{db: db, eventBus: eventBus}
+	return OpsBookingReadModel{db: db, eventBus: eventBus, table: OpsBookingsTable}
 }
 
-func (r OpsBookingReadModel) AllBookingsByDate(date string) ([]ticketsEntity.OpsBooking, error) {
-	if date == "" {
-		return r.AllBookings()
-	}
-	query := `SELECT payload FROM read_model_ops_bookings WHERE booking_id IN (
-				SELECT booking_id FROM (
-					SELECT booking_id,
-						DATE(jsonb_path_query(payload, '$.tickets.*.receipt_issued_at')::text) as receipt_issued_at
-					FROM
-						read_model_ops_bookings
-				) bookings_within_date
-				WHERE receipt_issued_at = $1
-			)`
-
-	var queryArgs []any
-	queryArgs = append(queryArgs, date)
+// WithTable returns the read model writing to another table, used to rebuild the projection in a shadow table.
+// It doesn't publish InternalOpsReadModelUpdated, as nobody reads from that table yet.
+func (r OpsBookingReadModel) WithTable(table string) OpsBookingReadModel {
+	return OpsBookingReadModel{db: r.db, table: table}
+}
 
-	rows, err := r.db.Query(query, queryArgs...)
-	if err != nil {
-		return nil, err
+func (r OpsBookingReadModel) Bookings(
+	ctx context.Context,
+	filter ticketsEntity.OpsBookingsFilter,
+) ([]ticketsEntity.OpsBooking, *ticketsEntity.OpsBookingsCursor, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
 	}
-	defer rows.Close()
-
-	var result []ticketsEntity.OpsBooking
-	for rows.Next() {
-		var payload []byte
-		if err := rows.Scan(&payload); err != nil {
-			return nil, err
-		}
 
-		var reservation ticketsEntity.OpsBooking
-		if err := json.Unmarshal(payload, &reservation); err != nil {
-			return nil, err
-		}
-
-		result = append(result, reservation)
+	if filter.BookedFrom != nil {
+		addCondition("booked_at >= $%d", *filter.BookedFrom)
+	}
+	if filter.BookedTo != nil {
+		addCondition("booked_at < $%d", *filter.BookedTo)
+	}
+	if filter.ShowID != "" {
+		addCondition("show_id = $%d", filter.ShowID)
+	}
+	if filter.CustomerEmail != "" {
+		addCondition("customer_email = $%d", strings.ToLower(filter.CustomerEmail))
+	}
+	if filter.ReceiptNumber != "" {
+		addCondition("receipt_numbers @> ARRAY[$%d::text]", filter.ReceiptNumber)
+	}
+	if filter.ReceiptIssueDate != "" {
+		addCondition("receipt_issue_dates @> ARRAY[$%d::date]", filter.ReceiptIssueDate)
+	}
+	if filter.Refunded != nil {
+		addCondition("has_refunded_tickets = $%d", *filter.Refunded)
+	}
+	if filter.Printed != nil {
+		addCondition("has_printed_tickets = $%d", *filter.Printed)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.BookedAt, filter.After.BookingID)
+		conditions = append(conditions, fmt.Sprintf("(booked_at, booking_id) > ($%d, $%d)", len(args)-1, len(args)))
 	}
 
-	return result, nil
-}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
 
-func (r OpsBookingReadModel) AllBookings() ([]ticketsEntity.OpsBooking, error) {
-	query := "SELECT payload FROM read_model_ops_bookings"
-	var queryArgs []any
+	// one more row is fetched to know if there is a next page
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT payload FROM %s
+		%s
+		ORDER BY booked_at, booking_id
+		LIMIT $%d
+	`, r.table, where, len(args))
 
-	rows, err := r.db.Query(query, queryArgs...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("could not query bookings: %w", err)
 	}
 	defer rows.Close()
 
-	var result []ticketsEntity.OpsBooking
+	result := []ticketsEntity.OpsBooking{}
 	for rows.Next() {
 		var payload []byte
 		if err := rows.Scan(&payload); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		var reservation ticketsEntity.OpsBooking
-		if err := json.Unmarshal(payload, &reservation); err != nil {
-			return nil, err
+		reservation, err := r.unmarshalReadModelFromDB(payload)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		result = append(result, reservation)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(result) <= filter.Limit {
+		return result, nil, nil
+	}
+
+	result = result[:filter.Limit]
+	last := result[len(result)-1]
 
-	return result, nil
+	return result, &ticketsEntity.OpsBookingsCursor{
+		BookedAt:  last.BookedAt,
+		BookingID: last.BookingID,
+	}, nil
 }
 
 func (r OpsBookingReadModel) ReservationReadModel(
@@ -109,14 +143,16 @@ func (r OpsBookingReadModel) OnBookingMade(ctx context.Context, bookingMade *tic
 	// this is the first event that should arrive, so we create the read model
 	bookingID, err := uuid.Parse(bookingMade.BookingID)
 	if err != nil {
-		return err
+		return ticketsEntity.NewPermanentError(fmt.Errorf("invalid booking id %q: %w", bookingMade.BookingID, err))
 	}
 	err = r.createReadModel(
 		ctx, ticketsEntity.OpsBooking{
-			BookingID:  bookingID,
-			Tickets:    nil,
-			LastUpdate: time.Now(),
-			BookedAt:   bookingMade.Header.PublishedAt,
+			BookingID:     bookingID,
+			Tickets:       nil,
+			LastUpdate:    time.Now(),
+			BookedAt:      bookingMade.Header.PublishedAt,
+			ShowID:        bookingMade.ShowID,
+			CustomerEmail: bookingMade.CustomerEmail,
 		},
 	)
 	if err != nil {
@@ -126,6 +162,18 @@ func (r OpsBookingReadModel) OnBookingMade(ctx context.Context, bookingMade *tic
 	return nil
 }
 
+func (r OpsBookingReadModel) OnBookingCanceled(ctx context.Context, event *ticketsEntity.BookingCanceled_v1) error {
+	return r.updateReadModelByBookingID(
+		ctx,
+		event.BookingID,
+		func(rm ticketsEntity.OpsBooking) (ticketsEntity.OpsBooking, error) {
+			rm.CanceledAt = &event.Header.PublishedAt
+
+			return rm, nil
+		},
+	)
+}
+
 func (r OpsBookingReadModel) OnTicketBookingConfirmed(
 	ctx context.Context,
 	event *ticketsEntity.TicketBookingConfirmed_v1,
@@ -201,26 +249,37 @@ func (r OpsBookingReadModel) createReadModel(
 	ctx context.Context,
 	booking ticketsEntity.OpsBooking,
 ) (err error) {
-	payload, err := json.Marshal(booking)
+	row, err := newOpsBookingRow(booking)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecContext(
-		ctx, `
-		INSERT INTO 
-		    read_model_ops_bookings (payload, booking_id)
-		VALUES
-			($1, $2)
-		ON CONFLICT (booking_id) DO NOTHING; -- read model may be already updated by another event - we don't want to override
-`, payload, booking.BookingID,
-	)
+	err = updateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.NamedExecContext(
+				ctx, `
+				INSERT INTO 
+					`+r.table+` (`+opsBookingColumns+`)
+				VALUES
+					(`+opsBookingValues+`)
+				ON CONFLICT (booking_id) DO NOTHING; -- read model may be already updated by another event - we don't want to override
+		`, row,
+			)
+			if err != nil {
+				return fmt.Errorf("could not create read model: %w", err)
+			}
 
+			return nil
+		},
+	)
 	if err != nil {
-		return fmt.Errorf("could not create read model: %w", err)
+		return err
 	}
 
-	return nil
+	return r.publishUpdated(ctx, booking.BookingID)
 }
 
 func (r OpsBookingReadModel) updateReadModelByBookingID(
@@ -253,12 +312,7 @@ func (r OpsBookingReadModel) updateReadModelByBookingID(
 		return err
 	}
 
-	return r.eventBus.Publish(
-		ctx, &ticketsEntity.InternalOpsReadModelUpdated{
-			Header:    ticketsEntity.NewMessageHeader(),
-			BookingID: uuid.MustParse(bookingID),
-		},
-	)
+	return r.publishUpdated(ctx, uuid.MustParse(bookingID))
 }
 
 func (r OpsBookingReadModel) updateReadModelByTicketID(
@@ -266,7 +320,9 @@ func (r OpsBookingReadModel) updateReadModelByTicketID(
 	ticketID string,
 	updateFunc func(ticket ticketsEntity.OpsTicket) (ticketsEntity.OpsTicket, error),
 ) (err error) {
-	return updateInTx(
+	var bookingID uuid.UUID
+
+	err = updateInTx(
 		ctx,
 		r.db,
 		sql.LevelRepeatableRead,
@@ -287,10 +343,30 @@ func (r OpsBookingReadModel) updateReadModelByTicketID(
 			}
 
 			rm.Tickets[ticketID] = updatedRm
+			bookingID = rm.BookingID
 
 			return r.updateReadModel(ctx, tx, rm)
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	return r.publishUpdated(ctx, bookingID)
+}
+
+// publishUpdated notifies listeners of the ops bookings streams, it's called after the update is committed.
+func (r OpsBookingReadModel) publishUpdated(ctx context.Context, bookingID uuid.UUID) error {
+	if r.eventBus == nil {
+		return nil
+	}
+
+	return r.eventBus.Publish(
+		ctx, &ticketsEntity.InternalOpsReadModelUpdated{
+			Header:    ticketsEntity.NewMessageHeader(),
+			BookingID: bookingID,
+		},
+	)
 }
 
 func (r OpsBookingReadModel) updateReadModel(
@@ -300,19 +376,27 @@ func (r OpsBookingReadModel) updateReadModel(
 ) error {
 	rm.LastUpdate = time.Now()
 
-	payload, err := json.Marshal(rm)
+	row, err := newOpsBookingRow(rm)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.ExecContext(
+	_, err = tx.NamedExecContext(
 		ctx, `
 		INSERT INTO 
-			read_model_ops_bookings (payload, booking_id)
+			`+r.table+` (`+opsBookingColumns+`)
 		VALUES
-			($1, $2)
-		ON CONFLICT (booking_id) DO UPDATE SET payload = excluded.payload;
-		`, payload, rm.BookingID,
+			(`+opsBookingValues+`)
+		ON CONFLICT (booking_id) DO UPDATE SET
+			payload = excluded.payload,
+			booked_at = excluded.booked_at,
+			show_id = excluded.show_id,
+			customer_email = excluded.customer_email,
+			has_refunded_tickets = excluded.has_refunded_tickets,
+			has_printed_tickets = excluded.has_printed_tickets,
+			receipt_numbers = excluded.receipt_numbers,
+			receipt_issue_dates = excluded.receipt_issue_dates;
+		`, row,
 	)
 	if err != nil {
 		return fmt.Errorf("could not update read model: %w", err)
@@ -321,6 +405,61 @@ func (r OpsBookingReadModel) updateReadModel(
 	return nil
 }
 
+const (
+	opsBookingColumns = `booking_id, payload, booked_at, show_id, customer_email, 
+		has_refunded_tickets, has_printed_tickets, receipt_numbers, receipt_issue_dates`
+	opsBookingValues = `:booking_id, :payload, :booked_at, NULLIF(:show_id, '')::uuid, :customer_email, 
+		:has_refunded_tickets, :has_printed_tickets, :receipt_numbers, :receipt_issue_dates`
+)
+
+// opsBookingRow keeps the payload together with the columns it's filtered by,
+// so queries can use indexes instead of scanning the JSON payload.
+type opsBookingRow struct {
+	BookingID          uuid.UUID      `db:"booking_id"`
+	Payload            []byte         `db:"payload"`
+	BookedAt           time.Time      `db:"booked_at"`
+	ShowID             string         `db:"show_id"`
+	CustomerEmail      string         `db:"customer_email"`
+	HasRefundedTickets bool           `db:"has_refunded_tickets"`
+	HasPrintedTickets  bool           `db:"has_printed_tickets"`
+	ReceiptNumbers     pq.StringArray `db:"receipt_numbers"`
+	ReceiptIssueDates  pq.StringArray `db:"receipt_issue_dates"`
+}
+
+func newOpsBookingRow(rm ticketsEntity.OpsBooking) (opsBookingRow, error) {
+	payload, err := json.Marshal(rm)
+	if err != nil {
+		return opsBookingRow{}, err
+	}
+
+	row := opsBookingRow{
+		BookingID:         rm.BookingID,
+		Payload:           payload,
+		BookedAt:          rm.BookedAt,
+		ShowID:            rm.ShowID,
+		CustomerEmail:     strings.ToLower(rm.CustomerEmail),
+		ReceiptNumbers:    pq.StringArray{},
+		ReceiptIssueDates: pq.StringArray{},
+	}
+
+	for _, ticket := range rm.Tickets {
+		if !ticket.RefundedAt.IsZero() {
+			row.HasRefundedTickets = true
+		}
+		if !ticket.PrintedAt.IsZero() {
+			row.HasPrintedTickets = true
+		}
+		if ticket.ReceiptNumber != "" {
+			row.ReceiptNumbers = append(row.ReceiptNumbers, ticket.ReceiptNumber)
+		}
+		if !ticket.ReceiptIssuedAt.IsZero() {
+			row.ReceiptIssueDates = append(row.ReceiptIssueDates, ticket.ReceiptIssuedAt.Format(time.DateOnly))
+		}
+	}
+
+	return row, nil
+}
+
 func (r OpsBookingReadModel) findReadModelByTicketID(
 	ctx context.Context,
 	ticketID string,
@@ -330,7 +469,7 @@ func (r OpsBookingReadModel) findReadModelByTicketID(
 
 	err := db.QueryRowContext(
 		ctx,
-		"SELECT payload FROM read_model_ops_bookings WHERE payload::jsonb -> 'tickets' ? $1",
+		"SELECT payload FROM "+r.table+" WHERE payload::jsonb -> 'tickets' ? $1",
 		ticketID,
 	).Scan(&payload)
 	if err != nil {
@@ -349,7 +488,7 @@ func (r OpsBookingReadModel) findReadModelByBookingID(
 
 	err := db.QueryRowContext(
 		ctx,
-		"SELECT payload FROM read_model_ops_bookings WHERE booking_id = $1",
+		"SELECT payload FROM "+r.table+" WHERE booking_id = $1",
 		bookingID,
 	).Scan(&payload)
 	if err != nil {
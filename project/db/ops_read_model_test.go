@@ -0,0 +1,81 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	"tickets/entities"
+)
+
+func TestOpsBookingReadModel_Bookings_printed(t *testing.T) {
+	ctx := context.Background()
+	db := getDb()
+	require.NoError(t, ticketsDb.InitializeDatabaseSchema(db))
+
+	readModel := ticketsDb.NewOpsBookingReadModel(db, nil)
+	showID := uuid.NewString()
+
+	printedBookingID := addOpsBooking(t, readModel, showID, true)
+	notPrintedBookingID := addOpsBooking(t, readModel, showID, false)
+
+	testCases := []struct {
+		name     string
+		printed  *bool
+		expected []string
+	}{
+		{name: "printed", printed: lo.ToPtr(true), expected: []string{printedBookingID}},
+		{name: "not printed", printed: lo.ToPtr(false), expected: []string{notPrintedBookingID}},
+		{name: "any", expected: []string{printedBookingID, notPrintedBookingID}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bookings, _, err := readModel.Bookings(ctx, entities.OpsBookingsFilter{
+				ShowID:  showID,
+				Printed: tc.printed,
+				Limit:   10,
+			})
+			require.NoError(t, err)
+
+			var bookingIDs []string
+			for _, booking := range bookings {
+				bookingIDs = append(bookingIDs, booking.BookingID.String())
+			}
+			assert.ElementsMatch(t, tc.expected, bookingIDs)
+		})
+	}
+}
+
+func addOpsBooking(t *testing.T, readModel ticketsDb.OpsBookingReadModel, showID string, printed bool) string {
+	t.Helper()
+	ctx := context.Background()
+
+	bookingID := uuid.NewString()
+	ticketID := uuid.NewString()
+
+	require.NoError(t, readModel.OnBookingMade(ctx, &entities.BookingMade_v1{
+		Header:    entities.NewMessageHeader(),
+		BookingID: bookingID,
+		ShowID:    showID,
+	}))
+	require.NoError(t, readModel.OnTicketBookingConfirmed(ctx, &entities.TicketBookingConfirmed_v1{
+		Header:    entities.NewMessageHeader(),
+		BookingID: bookingID,
+		TicketID:  ticketID,
+	}))
+	if printed {
+		require.NoError(t, readModel.OnTicketPrinted(ctx, &entities.TicketPrinted_v1{
+			Header:   entities.NewMessageHeader(),
+			TicketID: ticketID,
+			FileName: ticketID + "-ticket.html",
+		}))
+	}
+
+	return bookingID
+}
@@ -0,0 +1,438 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrRebuildAlreadyRunning = errors.New("projection rebuild is already running")
+	ErrRebuildNotFound       = errors.New("projection rebuild not found")
+)
+
+type ProjectionRebuildRepository struct {
+	db *sqlx.DB
+}
+
+func NewProjectionRebuildRepository(db *sqlx.DB) ProjectionRebuildRepository {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return ProjectionRebuildRepository{db: db}
+}
+
+type projectionRebuildRow struct {
+	RebuildID           uuid.UUID                             `db:"rebuild_id"`
+	Projection          string                                `db:"projection"`
+	ShadowTable         string                                `db:"shadow_table"`
+	Status              ticketsEntity.ProjectionRebuildStatus `db:"status"`
+	CheckpointStoredXid *int64                                `db:"checkpoint_stored_xid"`
+	CheckpointStoredSeq *int64                                `db:"checkpoint_stored_seq"`
+	EventsProcessed     int64                                 `db:"events_processed"`
+	Error               *string                               `db:"error"`
+	StartedAt           time.Time                             `db:"started_at"`
+	UpdatedAt           time.Time                             `db:"updated_at"`
+	FinishedAt          *time.Time                            `db:"finished_at"`
+}
+
+func (r projectionRebuildRow) toEntity() ticketsEntity.ProjectionRebuild {
+	rebuild := ticketsEntity.ProjectionRebuild{
+		RebuildID:       r.RebuildID,
+		Projection:      r.Projection,
+		ShadowTable:     r.ShadowTable,
+		Status:          r.Status,
+		EventsProcessed: r.EventsProcessed,
+		Error:           r.Error,
+		StartedAt:       r.StartedAt,
+		UpdatedAt:       r.UpdatedAt,
+		FinishedAt:      r.FinishedAt,
+	}
+	if r.CheckpointStoredXid != nil && r.CheckpointStoredSeq != nil {
+		rebuild.Checkpoint = &ticketsEntity.StoredEventsCheckpoint{
+			TransactionID: *r.CheckpointStoredXid,
+			Sequence:      *r.CheckpointStoredSeq,
+		}
+	}
+
+	return rebuild
+}
+
+const projectionRebuildColumns = `
+	rebuild_id,
+	projection,
+	shadow_table,
+	status,
+	checkpoint_stored_xid,
+	checkpoint_stored_seq,
+	events_processed,
+	error,
+	started_at,
+	updated_at,
+	finished_at`
+
+// Start registers a new rebuild of the projection and creates an empty shadow table with the same structure
+// as the live table. Only one rebuild of the projection can be running at the time.
+// Failed rebuilds of the projection are abandoned and their shadow tables are dropped.
+func (r ProjectionRebuildRepository) Start(
+	ctx context.Context,
+	projection string,
+	liveTable string,
+) (ticketsEntity.ProjectionRebuild, error) {
+	rebuildID := uuid.New()
+	shadowTable := liveTable + "_rebuild_" + strings.ReplaceAll(rebuildID.String(), "-", "")[:12]
+
+	var row projectionRebuildRow
+	err := updateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			err := tx.GetContext(
+				ctx, &row, `
+				INSERT INTO projection_rebuilds (rebuild_id, projection, shadow_table, status, started_at, updated_at)
+				VALUES ($1, $2, $3, $4, now(), now())
+				RETURNING `+projectionRebuildColumns,
+				rebuildID, projection, shadowTable, ticketsEntity.ProjectionRebuildRunning,
+			)
+			var postgresError *pq.Error
+			if errors.As(err, &postgresError) && postgresError.Code.Name() == "unique_violation" {
+				return ErrRebuildAlreadyRunning
+			}
+			if err != nil {
+				return fmt.Errorf("could not insert projection rebuild: %w", err)
+			}
+
+			if err := abandonFailedRebuilds(ctx, tx, projection); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(
+				ctx, fmt.Sprintf(
+					`CREATE TABLE %s (LIKE %s INCLUDING ALL)`,
+					pq.QuoteIdentifier(shadowTable),
+					pq.QuoteIdentifier(liveTable),
+				),
+			)
+			if err != nil {
+				return fmt.Errorf("could not create shadow table: %w", err)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return ticketsEntity.ProjectionRebuild{}, err
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r ProjectionRebuildRepository) Get(
+	ctx context.Context,
+	rebuildID uuid.UUID,
+) (ticketsEntity.ProjectionRebuild, error) {
+	var row projectionRebuildRow
+	err := r.db.GetContext(
+		ctx, &row,
+		`SELECT `+projectionRebuildColumns+` FROM projection_rebuilds WHERE rebuild_id = $1`,
+		rebuildID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ticketsEntity.ProjectionRebuild{}, ErrRebuildNotFound
+	}
+	if err != nil {
+		return ticketsEntity.ProjectionRebuild{}, fmt.Errorf("could not get projection rebuild: %w", err)
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r ProjectionRebuildRepository) List(
+	ctx context.Context,
+	projection string,
+) ([]ticketsEntity.ProjectionRebuild, error) {
+	var rows []projectionRebuildRow
+	err := r.db.SelectContext(
+		ctx, &rows,
+		`SELECT `+projectionRebuildColumns+` FROM projection_rebuilds WHERE projection = $1 ORDER BY started_at DESC`,
+		projection,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not list projection rebuilds: %w", err)
+	}
+
+	rebuilds := make([]ticketsEntity.ProjectionRebuild, 0, len(rows))
+	for _, row := range rows {
+		rebuilds = append(rebuilds, row.toEntity())
+	}
+
+	return rebuilds, nil
+}
+
+// ClaimStale takes over running rebuilds which weren't updated for longer than staleAfter,
+// for example because the instance running them was stopped.
+func (r ProjectionRebuildRepository) ClaimStale(
+	ctx context.Context,
+	projection string,
+	staleAfter time.Duration,
+) ([]ticketsEntity.ProjectionRebuild, error) {
+	var rows []projectionRebuildRow
+	err := r.db.SelectContext(
+		ctx, &rows, `
+		UPDATE projection_rebuilds SET updated_at = now()
+		WHERE
+			projection = $1
+			AND status = $2
+			AND updated_at < now() - make_interval(secs => $3)
+		RETURNING `+projectionRebuildColumns,
+		projection, ticketsEntity.ProjectionRebuildRunning, staleAfter.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not claim stale projection rebuilds: %w", err)
+	}
+
+	rebuilds := make([]ticketsEntity.ProjectionRebuild, 0, len(rows))
+	for _, row := range rows {
+		rebuilds = append(rebuilds, row.toEntity())
+	}
+
+	return rebuilds, nil
+}
+
+// abandonFailedRebuilds drops the shadow tables of failed rebuilds, they can't be resumed after that.
+func abandonFailedRebuilds(ctx context.Context, tx *sqlx.Tx, projection string) error {
+	var shadowTables []string
+	err := tx.SelectContext(
+		ctx, &shadowTables, `
+		UPDATE projection_rebuilds SET status = $3, updated_at = now()
+		WHERE projection = $1 AND status = $2
+		RETURNING shadow_table
+	`, projection, ticketsEntity.ProjectionRebuildFailed, ticketsEntity.ProjectionRebuildAbandoned,
+	)
+	if err != nil {
+		return fmt.Errorf("could not abandon failed projection rebuilds: %w", err)
+	}
+
+	for _, shadowTable := range shadowTables {
+		_, err := tx.ExecContext(ctx, `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(shadowTable))
+		if err != nil {
+			return fmt.Errorf("could not drop shadow table %s: %w", shadowTable, err)
+		}
+	}
+
+	return nil
+}
+
+// Resume marks a failed rebuild as running again; it continues from the last checkpoint.
+// A rebuild without a checkpoint starts over with an empty shadow table.
+func (r ProjectionRebuildRepository) Resume(
+	ctx context.Context,
+	rebuildID uuid.UUID,
+) (ticketsEntity.ProjectionRebuild, error) {
+	var row projectionRebuildRow
+	err := updateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			err := tx.GetContext(
+				ctx, &row, `
+				UPDATE projection_rebuilds SET
+					status = $2,
+					error = NULL,
+					events_processed = CASE WHEN checkpoint_stored_xid IS NULL THEN 0 ELSE events_processed END,
+					updated_at = now()
+				WHERE rebuild_id = $1 AND status = $3
+				RETURNING `+projectionRebuildColumns,
+				rebuildID, ticketsEntity.ProjectionRebuildRunning, ticketsEntity.ProjectionRebuildFailed,
+			)
+			var postgresError *pq.Error
+			if errors.As(err, &postgresError) && postgresError.Code.Name() == "unique_violation" {
+				return ErrRebuildAlreadyRunning
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRebuildNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("could not resume projection rebuild: %w", err)
+			}
+
+			if row.CheckpointStoredXid != nil {
+				return nil
+			}
+
+			_, err = tx.ExecContext(ctx, `TRUNCATE `+pq.QuoteIdentifier(row.ShadowTable))
+			if err != nil {
+				return fmt.Errorf("could not truncate shadow table: %w", err)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return ticketsEntity.ProjectionRebuild{}, err
+	}
+
+	return row.toEntity(), nil
+}
+
+func (r ProjectionRebuildRepository) SaveCheckpoint(
+	ctx context.Context,
+	rebuildID uuid.UUID,
+	checkpoint ticketsEntity.StoredEventsCheckpoint,
+	eventsProcessed int64,
+) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		UPDATE projection_rebuilds SET
+			checkpoint_stored_xid = $2,
+			checkpoint_stored_seq = $3,
+			events_processed = events_processed + $4,
+			updated_at = now()
+		WHERE rebuild_id = $1
+	`, rebuildID, checkpoint.TransactionID, checkpoint.Sequence, eventsProcessed,
+	)
+	if err != nil {
+		return fmt.Errorf("could not save projection rebuild checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+func (r ProjectionRebuildRepository) Fail(ctx context.Context, rebuildID uuid.UUID, reason error) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		UPDATE projection_rebuilds SET status = $2, error = $3, updated_at = now()
+		WHERE rebuild_id = $1
+	`, rebuildID, ticketsEntity.ProjectionRebuildFailed, reason.Error(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not mark projection rebuild as failed: %w", err)
+	}
+
+	return nil
+}
+
+// SwitchOver replaces the live table with the shadow table in one transaction.
+//
+// Writes to the live table and the data lake are blocked while catchUp applies the events stored since
+// the last checkpoint, so no update is lost between the last batch and the switch. Handlers blocked on the old
+// table fail and are retried against the new one. Reads are not blocked.
+func (r ProjectionRebuildRepository) SwitchOver(
+	ctx context.Context,
+	rebuild ticketsEntity.ProjectionRebuild,
+	liveTable string,
+	catchUp func(ctx context.Context) error,
+) error {
+	return updateInTx(
+		ctx,
+		r.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, `LOCK TABLE `+pq.QuoteIdentifier(liveTable)+` IN EXCLUSIVE MODE`)
+			if err != nil {
+				return fmt.Errorf("could not lock live table: %w", err)
+			}
+
+			// waits for the events being stored, so catchUp sees all events stored before the switch
+			_, err = tx.ExecContext(ctx, `LOCK TABLE events IN SHARE MODE`)
+			if err != nil {
+				return fmt.Errorf("could not lock events table: %w", err)
+			}
+
+			if err := catchUp(ctx); err != nil {
+				return fmt.Errorf("could not catch up shadow table: %w", err)
+			}
+
+			liveIndexes, err := tableIndexes(ctx, tx, liveTable)
+			if err != nil {
+				return err
+			}
+			shadowIndexes, err := tableIndexes(ctx, tx, rebuild.ShadowTable)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `DROP TABLE `+pq.QuoteIdentifier(liveTable))
+			if err != nil {
+				return fmt.Errorf("could not drop live table: %w", err)
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				`ALTER TABLE `+pq.QuoteIdentifier(rebuild.ShadowTable)+` RENAME TO `+pq.QuoteIdentifier(liveTable),
+			)
+			if err != nil {
+				return fmt.Errorf("could not rename shadow table: %w", err)
+			}
+
+			// indexes copied with LIKE ... INCLUDING ALL have generated names,
+			// they are renamed back, so the schema looks the same as before the rebuild
+			for definition, shadowIndex := range shadowIndexes {
+				liveIndex, ok := liveIndexes[definition]
+				if !ok {
+					continue
+				}
+
+				_, err = tx.ExecContext(
+					ctx,
+					`ALTER INDEX `+pq.QuoteIdentifier(shadowIndex)+` RENAME TO `+pq.QuoteIdentifier(liveIndex),
+				)
+				if err != nil {
+					return fmt.Errorf("could not rename index %s: %w", shadowIndex, err)
+				}
+			}
+
+			_, err = tx.ExecContext(
+				ctx, `
+				UPDATE projection_rebuilds SET status = $2, updated_at = now(), finished_at = now()
+				WHERE rebuild_id = $1
+			`, rebuild.RebuildID, ticketsEntity.ProjectionRebuildCompleted,
+			)
+			if err != nil {
+				return fmt.Errorf("could not mark projection rebuild as completed: %w", err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// tableIndexes returns index names of the table keyed by their definition without the index and table names.
+func tableIndexes(ctx context.Context, tx *sqlx.Tx, table string) (map[string]string, error) {
+	var indexes []struct {
+		Name       string `db:"indexname"`
+		Definition string `db:"indexdef"`
+	}
+	err := tx.SelectContext(
+		ctx, &indexes, `
+		SELECT indexname, indexdef FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1
+	`, table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get indexes of %s: %w", table, err)
+	}
+
+	result := make(map[string]string, len(indexes))
+	for _, index := range indexes {
+		// CREATE [UNIQUE] INDEX name ON schema.table USING btree (columns) [WHERE ...]
+		_, using, ok := strings.Cut(index.Definition, " USING ")
+		if !ok {
+			continue
+		}
+		unique := strings.HasPrefix(index.Definition, "CREATE UNIQUE")
+		result[fmt.Sprintf("%t %s", unique, using)] = index.Name
+	}
+
+	return result, nil
+}
@@ -0,0 +1,196 @@
+package db_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	"tickets/entities"
+)
+
+func TestEventsRepository_StreamStoredEvents_skips_unsettled_events(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewEventsRepository(db)
+
+	var checkpoint *entities.StoredEventsCheckpoint
+	for event, err := range repo.StreamStoredEvents(ctx, entities.StoredEventsFilter{Unsettled: true}, 1000) {
+		require.NoError(t, err)
+		checkpoint = &event.StoredEventsCheckpoint
+	}
+
+	// the event stored first is committed last
+	tx, err := db.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	lateEventID := uuid.NewString()
+	_, err = tx.ExecContext(
+		ctx, `
+		INSERT INTO events (event_id, published_at, event_name, event_payload) VALUES ($1, $2, 'TestEvent', '{}')
+	`, lateEventID, time.Now().Add(-time.Hour),
+	)
+	require.NoError(t, err)
+
+	eventID := uuid.NewString()
+	require.NoError(t, repo.SaveEvents(
+		ctx,
+		entities.ExternalEvent{Header: entities.MessageHeader{ID: eventID, PublishedAt: time.Now()}},
+		"TestEvent",
+		[]byte(`{}`),
+	))
+
+	streamEventIDs := func(filter entities.StoredEventsFilter) []string {
+		var eventIDs []string
+		for event, err := range repo.StreamStoredEvents(ctx, filter, 1000) {
+			require.NoError(t, err)
+			if event.EventID == lateEventID || event.EventID == eventID {
+				eventIDs = append(eventIDs, event.EventID)
+			}
+		}
+		return eventIDs
+	}
+
+	assert.Empty(
+		t,
+		streamEventIDs(entities.StoredEventsFilter{After: checkpoint}),
+		"events stored after the running transaction must wait for it",
+	)
+	assert.Equal(t, []string{eventID}, streamEventIDs(entities.StoredEventsFilter{After: checkpoint, Unsettled: true}))
+
+	require.NoError(t, tx.Commit())
+
+	assert.Equal(
+		t,
+		[]string{lateEventID, eventID},
+		streamEventIDs(entities.StoredEventsFilter{After: checkpoint}),
+		"events should be streamed in the stored order",
+	)
+}
+
+func TestProjectionRebuildRepository(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewProjectionRebuildRepository(db)
+
+	// the live read model is not touched, the test has its own projection
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	projection := "test_projection_" + suffix
+	liveTable := "test_live_" + suffix
+	_, err = db.ExecContext(ctx, `CREATE TABLE `+pq.QuoteIdentifier(liveTable)+` (id UUID PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(context.Background(), `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(liveTable))
+	})
+
+	failed, err := repo.Start(ctx, projection, liveTable)
+	require.NoError(t, err)
+	assert.True(t, tableExists(t, failed.ShadowTable))
+
+	_, err = repo.Start(ctx, projection, liveTable)
+	assert.ErrorIs(t, err, ticketsDb.ErrRebuildAlreadyRunning)
+
+	require.NoError(t, repo.Fail(ctx, failed.RebuildID, assert.AnError))
+
+	rebuild, err := repo.Start(ctx, projection, liveTable)
+	require.NoError(t, err)
+
+	failed, err = repo.Get(ctx, failed.RebuildID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProjectionRebuildAbandoned, failed.Status)
+	assert.False(t, tableExists(t, failed.ShadowTable), "shadow table of the failed rebuild should be dropped")
+
+	checkpoint := entities.StoredEventsCheckpoint{TransactionID: 100, Sequence: 5}
+	require.NoError(t, repo.SaveCheckpoint(ctx, rebuild.RebuildID, checkpoint, 3))
+
+	rowID := uuid.NewString()
+	err = repo.SwitchOver(ctx, rebuild, liveTable, func(ctx context.Context) error {
+		_, err := db.ExecContext(
+			ctx,
+			`INSERT INTO `+pq.QuoteIdentifier(rebuild.ShadowTable)+` (id, name) VALUES ($1, 'caught up')`,
+			rowID,
+		)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.False(t, tableExists(t, rebuild.ShadowTable), "shadow table should replace the live table")
+
+	var name string
+	err = db.GetContext(ctx, &name, `SELECT name FROM `+pq.QuoteIdentifier(liveTable)+` WHERE id = $1`, rowID)
+	require.NoError(t, err)
+	assert.Equal(t, "caught up", name)
+
+	rebuild, err = repo.Get(ctx, rebuild.RebuildID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProjectionRebuildCompleted, rebuild.Status)
+	assert.Equal(t, &checkpoint, rebuild.Checkpoint)
+	assert.EqualValues(t, 3, rebuild.EventsProcessed)
+	assert.NotNil(t, rebuild.FinishedAt)
+}
+
+func TestProjectionRebuildRepository_Resume_without_checkpoint(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewProjectionRebuildRepository(db)
+
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	liveTable := "test_live_" + suffix
+	_, err = db.ExecContext(ctx, `CREATE TABLE `+pq.QuoteIdentifier(liveTable)+` (id UUID PRIMARY KEY)`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(context.Background(), `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(liveTable))
+	})
+
+	rebuild, err := repo.Start(ctx, "test_projection_"+suffix, liveTable)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(context.Background(), `DROP TABLE IF EXISTS `+pq.QuoteIdentifier(rebuild.ShadowTable))
+	})
+
+	// events applied before the rebuild failed, without a saved checkpoint
+	_, err = db.ExecContext(ctx, `INSERT INTO `+pq.QuoteIdentifier(rebuild.ShadowTable)+` (id) VALUES ($1)`, uuid.New())
+	require.NoError(t, err)
+	require.NoError(t, repo.Fail(ctx, rebuild.RebuildID, assert.AnError))
+
+	rebuild, err = repo.Resume(ctx, rebuild.RebuildID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProjectionRebuildRunning, rebuild.Status)
+
+	var rows int
+	err = db.GetContext(ctx, &rows, `SELECT count(*) FROM `+pq.QuoteIdentifier(rebuild.ShadowTable))
+	require.NoError(t, err)
+	assert.Zero(t, rows, "rebuild without checkpoint should start over")
+}
+
+func tableExists(t *testing.T, table string) bool {
+	t.Helper()
+
+	var exists bool
+	err := getDb().Get(&exists, `SELECT to_regclass($1) IS NOT NULL`, table)
+	require.NoError(t, err)
+
+	return exists
+}
@@ -1,101 +1,13 @@
 package db
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// InitializeDatabaseSchema applies all pending migrations from the migrations directory.
 func InitializeDatabaseSchema(db *sqlx.DB) error {
-	_, err := db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS tickets (
-			ticket_id UUID PRIMARY KEY,
-			price_amount NUMERIC(10, 2) NOT NULL,
-			price_currency CHAR(3) NOT NULL,
-			customer_email VARCHAR(255) NOT NULL,
-		    deleted_at TIMESTAMP
-		);
-	`,
-	)
-	if err != nil {
-		return fmt.Errorf("could not create table tickets: %w", err)
-	}
-
-	_, err = db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS shows (
-			show_id UUID PRIMARY KEY,
-			dead_nation_id UUID NOT NULL,
-			number_of_tickets INT NOT NULL,
-			start_time TIMESTAMP NOT NULL,
-			title VARCHAR(255) NOT NULL,
-			venue VARCHAR(255) NOT NULL,
-			UNIQUE (dead_nation_id)
-		);
-	`,
-	)
-	if err != nil {
-		return fmt.Errorf("could not create table shows: %w", err)
-	}
-
-	_, err = db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS bookings (
-			booking_id UUID PRIMARY KEY,
-			show_id UUID NOT NULL,
-			number_of_tickets INT NOT NULL,
-			customer_email VARCHAR(255) NOT NULL,
-			FOREIGN KEY (show_id) REFERENCES shows(show_id)
-		);
-	`,
-	)
-	if err != nil {
-		return fmt.Errorf("could not create table shows: %w", err)
-	}
-
-	_, err = db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS read_model_ops_bookings (
-			booking_id UUID PRIMARY KEY,
-			payload JSONB NOT NULL
-		);
-	`,
-	)
-
-	if err != nil {
-		return fmt.Errorf("could not create table read_model_ops_bookings: %w", err)
-	}
-
-	_, err = db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS events (
-			event_id UUID PRIMARY KEY,
-			published_at TIMESTAMP NOT NULL,
-			event_name VARCHAR(255) NOT NULL,
-			event_payload JSONB NOT NULL
-		);
-	`,
-	)
-
-	if err != nil {
-		return fmt.Errorf("could not create table events: %w", err)
-	}
-
-	_, err = db.Exec(
-		`
-		CREATE TABLE IF NOT EXISTS vip_bundles (
-			vip_bundle_id UUID PRIMARY KEY,
-			booking_id UUID NOT NULL UNIQUE,
-			payload JSONB NOT NULL
-		); 
-
-	`,
-	)
-
-	if err != nil {
-		return fmt.Errorf("could not create table vip_bundles: %w", err)
-	}
-
-	return nil
+	_, err := NewMigrator(db).Up(context.Background())
+	return err
 }
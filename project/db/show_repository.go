@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 
@@ -62,3 +63,79 @@ func (s ShowsRepository) ShowByID(ctx context.Context, showID string) (ticketsEn
 
 	return show, nil
 }
+
+func (s ShowsRepository) ShowAvailabilityByID(ctx context.Context, showID string) (ticketsEntity.ShowAvailability, error) {
+	var show ticketsEntity.ShowAvailability
+	err := s.db.GetContext(
+		ctx, &show, `
+		SELECT
+			show_id,
+			dead_nation_id,
+			number_of_tickets,
+			start_time,
+			title,
+			venue,
+			`+remainingSeatsColumn+`
+		FROM
+			shows
+		WHERE
+			show_id = $1
+	`, showID,
+	)
+	if err != nil {
+		return ticketsEntity.ShowAvailability{}, err
+	}
+
+	return show, nil
+}
+
+func (s ShowsRepository) AllShows(
+	ctx context.Context,
+	filter ticketsEntity.ShowsFilter,
+) ([]ticketsEntity.ShowAvailability, error) {
+	var conditions []string
+	var args []any
+
+	if filter.Venue != "" {
+		args = append(args, filter.Venue)
+		conditions = append(conditions, fmt.Sprintf("venue = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("start_time >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("start_time < $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT
+			show_id,
+			dead_nation_id,
+			number_of_tickets,
+			start_time,
+			title,
+			venue,
+			%s
+		FROM
+			shows
+		%s
+		ORDER BY start_time, show_id
+		LIMIT $%d OFFSET $%d
+	`, remainingSeatsColumn, where, len(args)-1, len(args))
+
+	shows := []ticketsEntity.ShowAvailability{}
+	err := s.db.SelectContext(ctx, &shows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get shows: %w", err)
+	}
+
+	return shows, nil
+}
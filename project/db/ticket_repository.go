@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -21,41 +22,57 @@ func NewTicketsRepository(db *sqlx.DB) TicketsRepository {
 }
 
 func (t TicketsRepository) Add(ctx context.Context, ticket entities.Ticket) error {
-	_, err := t.db.NamedExecContext(
+	return updateInTx(
 		ctx,
-		`
-		INSERT INTO
-    		tickets (ticket_id, price_amount, price_currency, customer_email)
-		VALUES
-		    (:ticket_id, :price.amount, :price.currency, :customer_email)
-		ON CONFLICT DO NOTHING`,
-		ticket,
-	)
-	if err != nil {
-		return fmt.Errorf("could not save ticket: %w", err)
-	}
+		t.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.NamedExecContext(
+				ctx,
+				`
+				INSERT INTO
+					tickets (ticket_id, booking_id, price_amount, price_currency, customer_email)
+				VALUES
+					(:ticket_id, NULLIF(:booking_id, '')::uuid, :price.amount, :price.currency, :customer_email)
+				ON CONFLICT (ticket_id) DO UPDATE SET
+					-- tickets stored before booking_id existed get it when the confirmation is redelivered
+					booking_id = COALESCE(tickets.booking_id, EXCLUDED.booking_id)`,
+				ticket,
+			)
+			if err != nil {
+				return fmt.Errorf("could not save ticket: %w", err)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 func (t TicketsRepository) Remove(ctx context.Context, ticket entities.Ticket) error {
-	res, err := t.db.ExecContext(
+	return updateInTx(
 		ctx,
-		`UPDATE tickets SET deleted_at = now() WHERE ticket_id = $1`,
-		ticket.TicketID,
-	)
-	if err != nil {
-		return fmt.Errorf("could not remove ticket: %w", err)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("could get rows affected: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("ticket with id %s not found", ticket.TicketID)
-	}
+		t.db,
+		sql.LevelReadCommitted,
+		func(ctx context.Context, tx *sqlx.Tx) error {
+			res, err := tx.ExecContext(
+				ctx,
+				`UPDATE tickets SET deleted_at = now() WHERE ticket_id = $1`,
+				ticket.TicketID,
+			)
+			if err != nil {
+				return fmt.Errorf("could not remove ticket: %w", err)
+			}
+			rowsAffected, err := res.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("could get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return fmt.Errorf("ticket with id %s not found", ticket.TicketID)
+			}
 
-	return nil
+			return nil
+		},
+	)
 }
 
 func (t TicketsRepository) FindAll(ctx context.Context) ([]entities.Ticket, error) {
@@ -81,3 +98,29 @@ func (t TicketsRepository) FindAll(ctx context.Context) ([]entities.Ticket, erro
 
 	return returnTickets, nil
 }
+
+func (t TicketsRepository) FindByBookingID(ctx context.Context, bookingID string) ([]entities.Ticket, error) {
+	var returnTickets []entities.Ticket
+
+	err := t.db.SelectContext(
+		ctx,
+		&returnTickets, `
+            SELECT
+                ticket_id,
+                booking_id,
+                price_amount as "price.amount",
+                price_currency as "price.currency",
+                customer_email
+            FROM
+                tickets
+            WHERE 
+                booking_id = $1 AND deleted_at IS NULL
+        `,
+		bookingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not find tickets for booking %s: %w", bookingID, err)
+	}
+
+	return returnTickets, nil
+}
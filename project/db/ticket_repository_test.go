@@ -65,3 +65,32 @@ func TestTicketsRepository_Add_idempotency(t *testing.T) {
 		require.Len(t, foundTickets, 1)
 	}
 }
+
+func TestTicketsRepository_Add_sets_missing_booking_id(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewTicketsRepository(db)
+
+	ticket := entities.Ticket{
+		TicketID: uuid.NewString(),
+		Price: entities.Money{
+			Amount:   "30.00",
+			Currency: "EUR",
+		},
+		CustomerEmail: "foo@bar.com",
+	}
+	require.NoError(t, repo.Add(ctx, ticket))
+
+	ticket.BookingID = uuid.NewString()
+	require.NoError(t, repo.Add(ctx, ticket))
+
+	tickets, err := repo.FindByBookingID(ctx, ticket.BookingID)
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+	require.Equal(t, ticket.TicketID, tickets[0].TicketID)
+}
@@ -15,6 +15,12 @@ func updateInTx(
 	isolation sql.IsolationLevel,
 	fn func(ctx context.Context, tx *sqlx.Tx) error,
 ) (err error) {
+	// the message handled by the router is recorded as processed together with the handler's changes
+	inboxMsg := inboxMessageFromContext(ctx)
+	if inboxMsg != nil && inboxMsg.recorded {
+		inboxMsg = nil
+	}
+
 	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: isolation})
 	if err != nil {
 		return fmt.Errorf("could not begin transaction: %w", err)
@@ -29,7 +35,20 @@ func updateInTx(
 		}
 
 		err = tx.Commit()
+		if err == nil && inboxMsg != nil {
+			inboxMsg.recorded = true
+		}
 	}()
 
+	if inboxMsg != nil {
+		inserted, err := markMessageProcessed(ctx, tx, inboxMsg.HandlerName, inboxMsg.MessageID)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return ErrMessageAlreadyProcessed
+		}
+	}
+
 	return fn(ctx, tx)
 }
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	ticketsEntity "tickets/entities"
 	ticketsEvent "tickets/message/event"
 	"tickets/message/outbox"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/ThreeDotsLabs/watermill"
@@ -43,11 +45,12 @@ func (v VipBundleRepository) Add(ctx context.Context, vipBundle ticketsEntity.Vi
 		v.db,
 		sql.LevelRepeatableRead,
 		func(ctx context.Context, tx *sqlx.Tx) error {
-			_, err = v.db.ExecContext(
+			_, err = tx.ExecContext(
 				ctx, `
-				INSERT INTO vip_bundles (vip_bundle_id, booking_id, payload)
-				VALUES ($1, $2, $3)
+				INSERT INTO vip_bundles (vip_bundle_id, booking_id, payload, created_at, status, customer_email, deadline)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
 			`, vipBundle.VipBundleID, vipBundle.BookingID, payload,
+				vipBundle.CreatedAt, vipBundle.Status(), strings.ToLower(vipBundle.CustomerEmail), vipBundle.NextDeadline(),
 			)
 
 			if err != nil {
@@ -109,6 +112,84 @@ func (v VipBundleRepository) vipBundleByID(
 	return vipBundle, nil
 }
 
+func (v VipBundleRepository) VipBundles(
+	ctx context.Context,
+	filter ticketsEntity.VipBundlesFilter,
+) ([]ticketsEntity.VipBundle, *ticketsEntity.VipBundlesCursor, error) {
+	var conditions []string
+	var args []any
+
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.CustomerEmail != "" {
+		addCondition("customer_email = $%d", strings.ToLower(filter.CustomerEmail))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.VipBundleID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, vip_bundle_id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// one more row is fetched to know if there is a next page
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT payload, created_at FROM vip_bundles
+		%s
+		ORDER BY created_at, vip_bundle_id
+		LIMIT $%d
+	`, where, len(args))
+
+	rows, err := v.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not query vip bundles: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ticketsEntity.VipBundle{}
+	var createdAt []time.Time
+	for rows.Next() {
+		var payload []byte
+		var rowCreatedAt time.Time
+		if err := rows.Scan(&payload, &rowCreatedAt); err != nil {
+			return nil, nil, err
+		}
+
+		var vipBundle ticketsEntity.VipBundle
+		if err := json.Unmarshal(payload, &vipBundle); err != nil {
+			return nil, nil, fmt.Errorf("could not unmarshal vip bundle: %w", err)
+		}
+
+		result = append(result, vipBundle)
+		createdAt = append(createdAt, rowCreatedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(result) <= filter.Limit {
+		return result, nil, nil
+	}
+
+	result = result[:filter.Limit]
+	last := len(result) - 1
+
+	// bundles created before created_at was stored are ordered by the backfilled column
+	return result, &ticketsEntity.VipBundlesCursor{
+		CreatedAt:   createdAt[last],
+		VipBundleID: result[last].VipBundleID.UUID,
+	}, nil
+}
+
 func (v VipBundleRepository) GetByBookingID(ctx context.Context, bookingID uuid.UUID) (ticketsEntity.VipBundle, error) {
 	return v.getByBookingID(ctx, bookingID, v.db)
 }
@@ -165,8 +246,8 @@ func (v VipBundleRepository) UpdateByID(
 
 			_, err = tx.ExecContext(
 				ctx, `
-			UPDATE vip_bundles SET payload = $1 WHERE vip_bundle_id = $2
-		`, payload, vb.VipBundleID,
+			UPDATE vip_bundles SET payload = $1, status = $2, deadline = $3 WHERE vip_bundle_id = $4
+		`, payload, vb.Status(), vb.NextDeadline(), vb.VipBundleID,
 			)
 
 			if err != nil {
@@ -210,8 +291,8 @@ func (v VipBundleRepository) UpdateByBookingID(
 
 			_, err = tx.ExecContext(
 				ctx, `
-			UPDATE vip_bundles SET payload = $1 WHERE booking_id = $2
-		`, payload, vb.BookingID,
+			UPDATE vip_bundles SET payload = $1, status = $2, deadline = $3 WHERE booking_id = $4
+		`, payload, vb.Status(), vb.NextDeadline(), vb.BookingID,
 			)
 
 			if err != nil {
@@ -227,3 +308,67 @@ func (v VipBundleRepository) UpdateByBookingID(
 
 	return vb, nil
 }
+
+func (v VipBundleRepository) TimeOutVipBundles(ctx context.Context, now time.Time, limit int) (int, error) {
+	var timedOut int
+
+	err := updateInTx(
+		ctx, v.db, sql.LevelReadCommitted, func(ctx context.Context, tx *sqlx.Tx) error {
+			// bundles locked by a process manager handler are timed out by the next check
+			var payloads [][]byte
+			err := tx.SelectContext(
+				ctx, &payloads, `
+				SELECT payload FROM vip_bundles
+				WHERE deadline <= $1
+				ORDER BY deadline
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			`, now, limit,
+			)
+			if err != nil {
+				return fmt.Errorf("could not query vip bundles past deadline: %w", err)
+			}
+
+			outboxPublisher, err := outbox.NewPublisherForDb(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("could not create event bus: %w", err)
+			}
+			eventBus := ticketsEvent.NewEventBus(outboxPublisher, watermill.NewSlogLogger(log.FromContext(ctx)))
+
+			for _, payload := range payloads {
+				var vb ticketsEntity.VipBundle
+				if err := json.Unmarshal(payload, &vb); err != nil {
+					return fmt.Errorf("could not unmarshal vip bundle: %w", err)
+				}
+
+				for _, event := range vb.TimeOut(now) {
+					if err := eventBus.Publish(ctx, event); err != nil {
+						return fmt.Errorf("could not publish event: %w", err)
+					}
+				}
+
+				payload, err := json.Marshal(vb)
+				if err != nil {
+					return fmt.Errorf("could not marshal vip bundle: %w", err)
+				}
+
+				_, err = tx.ExecContext(
+					ctx, `
+					UPDATE vip_bundles SET payload = $1, deadline = $2 WHERE vip_bundle_id = $3
+				`, payload, vb.NextDeadline(), vb.VipBundleID,
+				)
+				if err != nil {
+					return fmt.Errorf("could not update vip bundle: %w", err)
+				}
+			}
+
+			timedOut = len(payloads)
+			return nil
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not time out vip bundles: %w", err)
+	}
+
+	return timedOut, nil
+}
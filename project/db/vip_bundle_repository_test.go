@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsDb "tickets/db"
+	"tickets/entities"
+)
+
+func TestVipBundleRepository_TimeOutVipBundles(t *testing.T) {
+	ctx := context.Background()
+
+	db := getDb()
+
+	err := ticketsDb.InitializeDatabaseSchema(db)
+	require.NoError(t, err)
+
+	repo := ticketsDb.NewVipBundleRepository(db)
+
+	now := time.Now().UTC()
+
+	vb := entities.VipBundle{
+		VipBundleID:   entities.VipBundleID{UUID: uuid.New()},
+		CreatedAt:     now,
+		BookingID:     uuid.New(),
+		CustomerEmail: "vip@example.com",
+	}
+	vb.SetDeadline(entities.VipBundleStepTicketsConfirmed, now.Add(-time.Minute))
+	vb.SetDeadline(entities.VipBundleStepInboundFlightBooked, now.Add(time.Minute))
+	require.NoError(t, repo.Add(ctx, vb))
+
+	// bundles left by other tests may time out as well
+	_, err = repo.TimeOutVipBundles(ctx, now, 1000)
+	require.NoError(t, err)
+
+	vb, err = repo.Get(ctx, vb.VipBundleID)
+	require.NoError(t, err)
+	assert.NotContains(t, vb.Deadlines, entities.VipBundleStepTicketsConfirmed, "timed out deadline should be cleared")
+	assert.Contains(t, vb.Deadlines, entities.VipBundleStepInboundFlightBooked)
+
+}
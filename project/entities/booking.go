@@ -1,12 +1,17 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Booking struct {
-	BookingID       string `json:"booking_id" db:"booking_id"`
-	ShowID          string `json:"show_id" db:"show_id"`
-	NumberOfTickets int    `json:"number_of_tickets" db:"number_of_tickets"`
-	CustomerEmail   string `json:"customer_email" db:"customer_email"`
+	BookingID       string     `json:"booking_id" db:"booking_id"`
+	ShowID          string     `json:"show_id" db:"show_id"`
+	NumberOfTickets int        `json:"number_of_tickets" db:"number_of_tickets"`
+	CustomerEmail   string     `json:"customer_email" db:"customer_email"`
+	CanceledAt      *time.Time `json:"canceled_at" db:"canceled_at"`
 }
 
 type DeadNationBooking struct {
@@ -5,3 +5,12 @@ type RefundTicket struct {
 
 	TicketID string `json:"ticket_id"`
 }
+
+// NewCanceledBookingRefund refunds a ticket of a canceled booking. The key is derived from the booking and the ticket,
+// so the ticket is refunded once, no matter how many times and by whom the refund is sent.
+func NewCanceledBookingRefund(bookingID string, ticketID string) RefundTicket {
+	return RefundTicket{
+		Header:   NewMessageHeaderWithIdempotencyKey("canceled-booking-" + bookingID + "-" + ticketID),
+		TicketID: ticketID,
+	}
+}
@@ -0,0 +1,42 @@
+package entities
+
+import "time"
+
+// DataLakeCheckpoint points to an event in the data lake; events are ordered by (published_at, event_id).
+type DataLakeCheckpoint struct {
+	PublishedAt time.Time `json:"published_at"`
+	EventID     string    `json:"event_id"`
+}
+
+type DataLakeFilter struct {
+	// After skips all events up to and including the checkpoint.
+	After *DataLakeCheckpoint
+
+	// EventNames limits events to the given names, all events are returned when empty.
+	EventNames []string
+
+	// PublishedFrom (inclusive) and PublishedTo (exclusive) limit the time window, zero values are ignored.
+	PublishedFrom time.Time
+	PublishedTo   time.Time
+}
+
+// StoredEventsCheckpoint points to an event in the order the events were stored in the data lake,
+// by the transaction that stored the event and the sequence of the event in it.
+type StoredEventsCheckpoint struct {
+	TransactionID int64 `json:"transaction_id" db:"stored_xid"`
+	Sequence      int64 `json:"sequence" db:"stored_seq"`
+}
+
+type StoredEventsFilter struct {
+	// After skips all events up to and including the checkpoint.
+	After *StoredEventsCheckpoint
+
+	// Unsettled includes events stored by transactions newer than some still running ones.
+	// Such events may be followed by events stored before them, so it's only safe when no events are being stored.
+	Unsettled bool
+}
+
+type StoredDataLakeEvent struct {
+	DataLakeEvent
+	StoredEventsCheckpoint
+}
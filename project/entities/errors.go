@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// PermanentError is an error that won't go away when the message is handled again,
+// like a malformed payload or a request rejected by an external API.
+// Messages failing with it are not retried.
+type PermanentError struct {
+	Err error
+}
+
+func NewPermanentError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return PermanentError{Err: err}
+}
+
+func (e PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanentError(err error) bool {
+	var permanentErr PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// ErrCircuitOpen is returned without calling an external service, while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is ErrCircuitOpen of a particular service.
+type CircuitOpenError struct {
+	Service string
+	// RetryAfter is when the circuit breaker lets a trial request through
+	RetryAfter time.Duration
+}
+
+func (e CircuitOpenError) Error() string {
+	return e.Service + ": " + ErrCircuitOpen.Error()
+}
+
+func (e CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
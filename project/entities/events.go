@@ -75,6 +75,19 @@ func (i BookingMade_v1) IsInternal() bool {
 	return false
 }
 
+type BookingCanceled_v1 struct {
+	Header MessageHeader `json:"header"`
+
+	BookingID       string `json:"booking_id"`
+	ShowID          string `json:"show_id"`
+	NumberOfTickets int    `json:"number_of_tickets"`
+	CustomerEmail   string `json:"customer_email"`
+}
+
+func (i BookingCanceled_v1) IsInternal() bool {
+	return false
+}
+
 type TicketReceiptIssued_v1 struct {
 	Header MessageHeader `json:"header"`
 
@@ -174,14 +187,28 @@ func (i FlightBooked_v1) IsInternal() bool {
 type VipBundleFinalized_v1 struct {
 	Header MessageHeader `json:"header"`
 
-	VipBundleID VipBundleID `json:"vip_bundle_id"`
-	Success     bool        `json:"success"`
+	VipBundleID   VipBundleID `json:"vip_bundle_id"`
+	Success       bool        `json:"success"`
+	FailureReason string      `json:"failure_reason,omitempty"`
 }
 
 func (i VipBundleFinalized_v1) IsInternal() bool {
 	return false
 }
 
+// InternalVipBundleStepTimedOut is published when the step of the bundle didn't complete before its deadline.
+type InternalVipBundleStepTimedOut struct {
+	Header MessageHeader `json:"header"`
+
+	VipBundleID VipBundleID   `json:"vip_bundle_id"`
+	Step        VipBundleStep `json:"step"`
+	Deadline    time.Time     `json:"deadline"`
+}
+
+func (i InternalVipBundleStepTimedOut) IsInternal() bool {
+	return true
+}
+
 type BookingFailed_v1 struct {
 	Header MessageHeader `json:"header"`
 
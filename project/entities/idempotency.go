@@ -0,0 +1,11 @@
+package entities
+
+type IdempotentRequest struct {
+	IdempotencyKey     string `db:"idempotency_key"`
+	RequestFingerprint string `db:"request_fingerprint"`
+
+	// ResponseStatus is nil until the first request with the key is completed
+	ResponseStatus      *int   `db:"response_status"`
+	ResponseContentType string `db:"response_content_type"`
+	ResponseBody        []byte `db:"response_body"`
+}
@@ -1,6 +1,9 @@
 package entities
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +13,11 @@ type OpsBooking struct {
 	BookingID uuid.UUID `json:"booking_id"` // from BookingMade event
 	BookedAt  time.Time `json:"booked_at"`  // from BookingMade event
 
+	ShowID        string `json:"show_id"`        // from BookingMade event
+	CustomerEmail string `json:"customer_email"` // from BookingMade event
+
+	CanceledAt *time.Time `json:"canceled_at"` // from BookingCanceled event
+
 	Tickets map[string]OpsTicket `json:"tickets"` // Tickets added/updated by TicketBookingConfirmed, TicketRefunded, TicketPrinted, TicketReceiptIssued
 
 	LastUpdate time.Time `json:"last_update"` // updated when read model is updated
@@ -29,3 +37,55 @@ type OpsTicket struct {
 	ReceiptIssuedAt time.Time `json:"receipt_issued_at"` // from TicketReceiptIssued event
 	ReceiptNumber   string    `json:"receipt_number"`    // from TicketReceiptIssued event
 }
+
+type OpsBookingsFilter struct {
+	BookedFrom *time.Time
+	BookedTo   *time.Time
+
+	ShowID           string
+	CustomerEmail    string
+	ReceiptNumber    string
+	ReceiptIssueDate string
+
+	// Refunded and Printed match bookings with at least one refunded or printed ticket (or none if false)
+	Refunded *bool
+	Printed  *bool
+
+	After *OpsBookingsCursor
+	Limit int
+}
+
+// OpsBookingsCursor points to the last booking of the page; bookings are ordered by (booked_at, booking_id).
+type OpsBookingsCursor struct {
+	BookedAt  time.Time
+	BookingID uuid.UUID
+}
+
+func (c OpsBookingsCursor) String() string {
+	raw := c.BookedAt.UTC().Format(time.RFC3339Nano) + "|" + c.BookingID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseOpsBookingsCursor(cursor string) (OpsBookingsCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return OpsBookingsCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	bookedAtRaw, bookingIDRaw, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return OpsBookingsCursor{}, fmt.Errorf("invalid cursor format")
+	}
+
+	bookedAt, err := time.Parse(time.RFC3339Nano, bookedAtRaw)
+	if err != nil {
+		return OpsBookingsCursor{}, fmt.Errorf("invalid cursor time: %w", err)
+	}
+
+	bookingID, err := uuid.Parse(bookingIDRaw)
+	if err != nil {
+		return OpsBookingsCursor{}, fmt.Errorf("invalid cursor booking id: %w", err)
+	}
+
+	return OpsBookingsCursor{BookedAt: bookedAt, BookingID: bookingID}, nil
+}
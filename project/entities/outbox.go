@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type OutboxStats struct {
+	UnforwardedMessages int64      `json:"unforwarded_messages"`
+	OldestUnforwardedAt *time.Time `json:"oldest_unforwarded_at"`
+	// OldestUnforwardedAgeSeconds is 0 when there are no unforwarded messages.
+	OldestUnforwardedAgeSeconds float64 `json:"oldest_unforwarded_age_seconds"`
+
+	// ForwardFailures and LastForwardedAt are counted by this instance since it started.
+	ForwardFailures uint64     `json:"forward_failures"`
+	LastForwardedAt *time.Time `json:"last_forwarded_at"`
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+type PoisonedMessage struct {
+	MessageID     string            `json:"message_id"`
+	Topic         string            `json:"topic"`
+	Handler       string            `json:"handler"`
+	Subscriber    string            `json:"subscriber"`
+	Reason        string            `json:"reason"`
+	CorrelationID string            `json:"correlation_id"`
+	PoisonedAt    time.Time         `json:"poisoned_at"`
+	Payload       string            `json:"payload"`
+	Metadata      map[string]string `json:"metadata"`
+}
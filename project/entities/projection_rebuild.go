@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProjectionRebuildStatus string
+
+const (
+	ProjectionRebuildRunning   ProjectionRebuildStatus = "running"
+	ProjectionRebuildFailed    ProjectionRebuildStatus = "failed"
+	ProjectionRebuildCompleted ProjectionRebuildStatus = "completed"
+	// ProjectionRebuildAbandoned is a failed rebuild superseded by a newer one, its shadow table is dropped.
+	ProjectionRebuildAbandoned ProjectionRebuildStatus = "abandoned"
+)
+
+type ProjectionRebuild struct {
+	RebuildID   uuid.UUID               `json:"rebuild_id" db:"rebuild_id"`
+	Projection  string                  `json:"projection" db:"projection"`
+	ShadowTable string                  `json:"shadow_table" db:"shadow_table"`
+	Status      ProjectionRebuildStatus `json:"status" db:"status"`
+
+	// Checkpoint is the last event applied to the shadow table, the rebuild resumes after it.
+	Checkpoint      *StoredEventsCheckpoint `json:"checkpoint"`
+	EventsProcessed int64                   `json:"events_processed" db:"events_processed"`
+
+	Error      *string    `json:"error" db:"error"`
+	StartedAt  time.Time  `json:"started_at" db:"started_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at" db:"finished_at"`
+}
@@ -10,3 +10,17 @@ type Show struct {
 	Title           string    `json:"title" db:"title"`
 	Venue           string    `json:"venue" db:"venue"`
 }
+
+type ShowAvailability struct {
+	Show
+	RemainingSeats int `json:"remaining_seats" db:"remaining_seats"`
+}
+
+type ShowsFilter struct {
+	Venue string
+	From  *time.Time
+	To    *time.Time
+
+	Limit  int
+	Offset int
+}
@@ -2,6 +2,7 @@ package entities
 
 type Ticket struct {
 	TicketID      string `json:"ticket_id" db:"ticket_id"`
+	BookingID     string `json:"booking_id" db:"booking_id"`
 	Price         Money  `json:"price" db:"price"`
 	CustomerEmail string `json:"customer_email" db:"customer_email"`
 }
@@ -1,7 +1,9 @@
 package entities
 
 import (
+	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +31,7 @@ func MustParseBundleID(id string) VipBundleID {
 
 type VipBundle struct {
 	VipBundleID VipBundleID `json:"vip_bundle_id"`
+	CreatedAt   time.Time   `json:"created_at"`
 
 	BookingID       uuid.UUID  `json:"booking_id"`
 	CustomerEmail   string     `json:"customer_email"`
@@ -36,7 +39,8 @@ type VipBundle struct {
 	ShowID          uuid.UUID  `json:"show_id"`
 	BookingMadeAt   *time.Time `json:"booking_made_at"`
 
-	TicketIDs []uuid.UUID `json:"ticket_ids"`
+	TicketIDs          []uuid.UUID `json:"ticket_ids"`
+	TicketsConfirmedAt *time.Time  `json:"tickets_confirmed_at"`
 
 	Passengers []string `json:"passengers"`
 
@@ -44,6 +48,7 @@ type VipBundle struct {
 	IsFinalized     bool      `json:"is_finalized"`
 	Failed          bool      `json:"failed"`
 
+	InboundFlightBookedAt   *time.Time  `json:"inbound_flight_booked_at"`
 	InboundFlightTicketsIDs []uuid.UUID `json:"inbound_flight_tickets_ids"`
 	ReturnFlightID          uuid.UUID   `json:"return_flight_id"`
 
@@ -52,4 +57,170 @@ type VipBundle struct {
 
 	TaxiBookedAt  *time.Time `json:"taxi_booked_at"`
 	TaxiBookingID *uuid.UUID `json:"taxi_booking_id"`
+
+	FinalizedAt   *time.Time    `json:"finalized_at"`
+	FailedStep    VipBundleStep `json:"failed_step,omitempty"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+
+	// CompensatedAt is set once the tickets and flights of the failed bundle were refunded and canceled.
+	CompensatedAt *time.Time `json:"compensated_at,omitempty"`
+
+	// Deadlines are when the pending steps time out.
+	Deadlines map[VipBundleStep]time.Time `json:"deadlines,omitempty"`
+}
+
+// SetDeadline makes the step time out at deadline, unless it's completed before.
+func (v *VipBundle) SetDeadline(step VipBundleStep, deadline time.Time) {
+	if v.Deadlines == nil {
+		v.Deadlines = make(map[VipBundleStep]time.Time)
+	}
+	v.Deadlines[step] = deadline
+}
+
+func (v *VipBundle) ClearDeadline(step VipBundleStep) {
+	delete(v.Deadlines, step)
+}
+
+// NextDeadline is the earliest deadline of the pending steps, it's nil when the bundle doesn't wait for any step.
+func (v VipBundle) NextDeadline() *time.Time {
+	var next *time.Time
+	for _, deadline := range v.Deadlines {
+		if next == nil || deadline.Before(*next) {
+			next = &deadline
+		}
+	}
+
+	return next
+}
+
+// TimeOut clears the deadlines that passed before now and returns a timeout of each of the steps.
+func (v *VipBundle) TimeOut(now time.Time) []InternalVipBundleStepTimedOut {
+	var timeouts []InternalVipBundleStepTimedOut
+	for step, deadline := range v.Deadlines {
+		if deadline.After(now) {
+			continue
+		}
+
+		timeouts = append(timeouts, InternalVipBundleStepTimedOut{
+			Header:      NewMessageHeader(),
+			VipBundleID: v.VipBundleID,
+			Step:        step,
+			Deadline:    deadline,
+		})
+		v.ClearDeadline(step)
+	}
+
+	return timeouts
+}
+
+// StepCompletedAt is nil until the step is completed.
+func (v VipBundle) StepCompletedAt(step VipBundleStep) *time.Time {
+	switch step {
+	case VipBundleStepBookingMade:
+		return v.BookingMadeAt
+	case VipBundleStepTicketsConfirmed:
+		return v.TicketsConfirmedAt
+	case VipBundleStepInboundFlightBooked:
+		return v.InboundFlightBookedAt
+	case VipBundleStepReturnFlightBooked:
+		return v.ReturnFlightBookedAt
+	case VipBundleStepTaxiBooked:
+		return v.TaxiBookedAt
+	case VipBundleStepFinalized:
+		return v.FinalizedAt
+	default:
+		return nil
+	}
+}
+
+// MarkFailed finalizes the bundle after the step failed.
+func (v *VipBundle) MarkFailed(step VipBundleStep, reason string, failedAt time.Time) {
+	v.IsFinalized = true
+	v.Failed = true
+	v.FinalizedAt = &failedAt
+	v.FailedStep = step
+	v.FailureReason = reason
+	v.Deadlines = nil
+}
+
+func (v VipBundle) Status() VipBundleStatus {
+	switch {
+	case !v.IsFinalized:
+		return VipBundleStatusInProgress
+	case v.Failed:
+		return VipBundleStatusFailed
+	default:
+		return VipBundleStatusSucceeded
+	}
+}
+
+type VipBundleStep string
+
+const (
+	VipBundleStepBookingMade         VipBundleStep = "booking_made"
+	VipBundleStepTicketsConfirmed    VipBundleStep = "tickets_confirmed"
+	VipBundleStepInboundFlightBooked VipBundleStep = "inbound_flight_booked"
+	VipBundleStepReturnFlightBooked  VipBundleStep = "return_flight_booked"
+	VipBundleStepTaxiBooked          VipBundleStep = "taxi_booked"
+	VipBundleStepFinalized           VipBundleStep = "finalized"
+)
+
+type VipBundleStatus string
+
+const (
+	VipBundleStatusInProgress VipBundleStatus = "in_progress"
+	VipBundleStatusSucceeded  VipBundleStatus = "succeeded"
+	VipBundleStatusFailed     VipBundleStatus = "failed"
+)
+
+func ParseVipBundleStatus(status string) (VipBundleStatus, error) {
+	switch s := VipBundleStatus(status); s {
+	case VipBundleStatusInProgress, VipBundleStatusSucceeded, VipBundleStatusFailed:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown vip bundle status %q", status)
+	}
+}
+
+type VipBundlesFilter struct {
+	Status        VipBundleStatus
+	CustomerEmail string
+
+	After *VipBundlesCursor
+	Limit int
+}
+
+// VipBundlesCursor points to the last bundle of the page; bundles are ordered by (created_at, vip_bundle_id).
+type VipBundlesCursor struct {
+	CreatedAt   time.Time
+	VipBundleID uuid.UUID
+}
+
+func (c VipBundlesCursor) String() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.VipBundleID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseVipBundlesCursor(cursor string) (VipBundlesCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return VipBundlesCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	createdAtRaw, vipBundleIDRaw, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return VipBundlesCursor{}, fmt.Errorf("invalid cursor format")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtRaw)
+	if err != nil {
+		return VipBundlesCursor{}, fmt.Errorf("invalid cursor time: %w", err)
+	}
+
+	vipBundleID, err := uuid.Parse(vipBundleIDRaw)
+	if err != nil {
+		return VipBundlesCursor{}, fmt.Errorf("invalid cursor vip bundle id: %w", err)
+	}
+
+	return VipBundlesCursor{CreatedAt: createdAt, VipBundleID: vipBundleID}, nil
 }
@@ -0,0 +1,279 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type Call struct {
+	Service    string    `json:"service"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Body       string    `json:"body,omitempty"`
+	StatusCode int       `json:"status_code"`
+	Injected   bool      `json:"injected"`
+	CalledAt   time.Time `json:"called_at"`
+}
+
+type callRecorder struct {
+	lock  sync.Mutex
+	calls []Call
+}
+
+func newCallRecorder() *callRecorder {
+	return &callRecorder{}
+}
+
+func (r *callRecorder) add(call Call) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+func (r *callRecorder) list(service string) []Call {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	calls := make([]Call, 0, len(r.calls))
+	for _, call := range r.calls {
+		if service == "" || call.Service == service {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func (r *callRecorder) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = nil
+}
+
+// Failure makes matching calls fail with StatusCode instead of reaching the API.
+type Failure struct {
+	ID string `json:"id"`
+	// Method matches any method when empty.
+	Method string `json:"method,omitempty"`
+	// Path is a prefix of the request path, for example /transportation-api/flight-tickets.
+	Path string `json:"path"`
+	// BodyContains narrows the failure down, for example to a single flight ID.
+	BodyContains string `json:"body_contains,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	// Skip lets the first matching calls through, so Skip: 2 fails from the third call on.
+	Skip int `json:"skip,omitempty"`
+	// Times is how many calls fail before the failure is removed, 0 fails calls until it's deleted.
+	Times int `json:"times,omitempty"`
+}
+
+func (f Failure) matches(method string, path string, body []byte) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, method) {
+		return false
+	}
+	if !strings.HasPrefix(path, f.Path) {
+		return false
+	}
+
+	return bytes.Contains(body, []byte(f.BodyContains))
+}
+
+type failureRules struct {
+	lock     sync.Mutex
+	failures []Failure
+}
+
+func newFailureRules() *failureRules {
+	return &failureRules{}
+}
+
+func (r *failureRules) add(failure Failure) Failure {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	failure.ID = uuid.NewString()
+	r.failures = append(r.failures, failure)
+
+	return failure
+}
+
+func (r *failureRules) list() []Failure {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return slices.Clone(r.failures)
+}
+
+func (r *failureRules) remove(id string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	i := slices.IndexFunc(r.failures, func(f Failure) bool { return f.ID == id })
+	if i < 0 {
+		return false
+	}
+	r.failures = slices.Delete(r.failures, i, i+1)
+
+	return true
+}
+
+func (r *failureRules) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.failures = nil
+}
+
+// match returns the status code of the first failure matching the call, and counts the call against it.
+func (r *failureRules) match(method string, path string, body []byte) (int, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := range r.failures {
+		failure := &r.failures[i]
+		if !failure.matches(method, path, body) {
+			continue
+		}
+
+		if failure.Skip > 0 {
+			failure.Skip--
+			continue
+		}
+
+		statusCode := failure.StatusCode
+		if failure.Times > 0 {
+			failure.Times--
+			if failure.Times == 0 {
+				r.failures = slices.Delete(r.failures, i, i+1)
+			}
+		}
+
+		return statusCode, true
+	}
+
+	return 0, false
+}
+
+func (s *Server) recordCall(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "could not read request body")
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		err = next(c)
+
+		statusCode := c.Response().Status
+		if err != nil {
+			statusCode = http.StatusInternalServerError
+
+			httpErr := &echo.HTTPError{}
+			if errors.As(err, &httpErr) {
+				statusCode = httpErr.Code
+			}
+		}
+
+		path := c.Request().URL.Path
+		service, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+		s.calls.add(Call{
+			Service:    service,
+			Method:     c.Request().Method,
+			Path:       path,
+			Body:       string(body),
+			StatusCode: statusCode,
+			Injected:   c.Get(injectedFailureKey) != nil,
+			CalledAt:   time.Now(),
+		})
+
+		return err
+	}
+}
+
+const injectedFailureKey = "injected_failure"
+
+func (s *Server) injectFailure(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "could not read request body")
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		statusCode, ok := s.failures.match(c.Request().Method, c.Request().URL.Path, body)
+		if !ok {
+			return next(c)
+		}
+
+		c.Set(injectedFailureKey, true)
+
+		return c.JSON(statusCode, map[string]string{"error": "injected failure"})
+	}
+}
+
+func (s *Server) GetCalls(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.calls.list(c.QueryParam("service")))
+}
+
+func (s *Server) DeleteCalls(c echo.Context) error {
+	s.calls.reset()
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) GetFailures(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.failures.list())
+}
+
+func (s *Server) PostFailure(c echo.Context) error {
+	var failure Failure
+	if err := c.Bind(&failure); err != nil {
+		return err
+	}
+
+	if failure.Path == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "path is required")
+	}
+	if failure.StatusCode < 400 || failure.StatusCode > 599 {
+		return echo.NewHTTPError(http.StatusBadRequest, "status_code must be an error status code")
+	}
+	if failure.Skip < 0 || failure.Times < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "skip and times must not be negative")
+	}
+
+	return c.JSON(http.StatusCreated, s.failures.add(failure))
+}
+
+func (s *Server) DeleteFailures(c echo.Context) error {
+	s.failures.reset()
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) DeleteFailure(c echo.Context) error {
+	if !s.failures.remove(c.Param("id")) {
+		return echo.NewHTTPError(http.StatusNotFound, "failure not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostReset forgets the recorded calls, the injected failures and the state of all APIs.
+func (s *Server) PostReset(c echo.Context) error {
+	s.calls.reset()
+	s.failures.reset()
+	s.state.reset()
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,353 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/dead_nation"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/receipts"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/spreadsheets"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/transportation"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type state struct {
+	lock               sync.Mutex
+	sheets             map[string][][]string
+	receipts           []receipts.Receipt
+	files              map[string]string
+	fileIDs            []string
+	deadNationBookings map[uuid.UUID]dead_nation.PostTicketBookingRequest
+	refunds            []payments.PaymentRefundRequest
+	flightTickets      []transportation.FlightTicket
+	// flightBookings are ticket IDs by idempotency key, so retried bookings return the same tickets
+	flightBookings map[string][]uuid.UUID
+	taxiBookings   []transportation.TaxiBooking
+}
+
+func newState() *state {
+	s := &state{}
+	s.reset()
+
+	return s
+}
+
+func (s *state) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sheets = make(map[string][][]string)
+	s.receipts = nil
+	s.files = make(map[string]string)
+	s.fileIDs = nil
+	s.deadNationBookings = make(map[uuid.UUID]dead_nation.PostTicketBookingRequest)
+	s.refunds = nil
+	s.flightTickets = nil
+	s.flightBookings = make(map[string][]uuid.UUID)
+	s.taxiBookings = nil
+}
+
+func (s *Server) GetSheetRows(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	rows := append([][]string{}, s.state.sheets[c.Param("sheet")]...)
+
+	return c.JSON(http.StatusOK, spreadsheets.SpreadsheetRows{Rows: rows})
+}
+
+func (s *Server) PostSheetRow(c echo.Context) error {
+	var request spreadsheets.PostSheetsSheetRowsJSONBody
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	sheet := c.Param("sheet")
+	s.state.sheets[sheet] = append(s.state.sheets[sheet], request.Columns)
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) GetReceipts(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]receipts.Receipt{}, s.state.receipts...))
+}
+
+func (s *Server) PutReceipt(c echo.Context) error {
+	var request receipts.CreateReceipt
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if request.TicketId == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "ticket_id is required")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	// one receipt is issued per ticket
+	i := slices.IndexFunc(s.state.receipts, func(r receipts.Receipt) bool { return r.TicketId == request.TicketId })
+	if i >= 0 {
+		return c.JSON(http.StatusOK, s.state.receipts[i])
+	}
+
+	receipt := receipts.Receipt{
+		IdempotencyKey: request.IdempotencyKey,
+		IssuedAt:       time.Now().UTC(),
+		Number:         fmt.Sprintf("PR-%06d", len(s.state.receipts)+1),
+		Price:          request.Price,
+		TicketId:       request.TicketId,
+	}
+	s.state.receipts = append(s.state.receipts, receipt)
+
+	return c.JSON(http.StatusCreated, receipt)
+}
+
+func (s *Server) PutVoidReceipt(c echo.Context) error {
+	var request receipts.VoidReceiptRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	i := slices.IndexFunc(s.state.receipts, func(r receipts.Receipt) bool { return r.TicketId == request.TicketId })
+	if i < 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "receipt not found")
+	}
+
+	receipt := &s.state.receipts[i]
+	if receipt.Voided != nil && *receipt.Voided {
+		return c.NoContent(http.StatusOK)
+	}
+
+	voided := true
+	receipt.Voided = &voided
+	receipt.VoidReason = &request.Reason
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func (s *Server) GetFiles(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return c.JSON(
+		http.StatusOK, struct {
+			Files []string `json:"files"`
+		}{
+			Files: append([]string{}, s.state.fileIDs...),
+		},
+	)
+}
+
+func (s *Server) GetFileContent(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	content, ok := s.state.files[c.Param("id")]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "file not found")
+	}
+
+	return c.String(http.StatusOK, content)
+}
+
+func (s *Server) PutFileContent(c echo.Context) error {
+	content, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "could not read file content")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	fileID := c.Param("id")
+	if _, ok := s.state.files[fileID]; ok {
+		return echo.NewHTTPError(http.StatusConflict, "file already exists")
+	}
+
+	s.state.files[fileID] = string(content)
+	s.state.fileIDs = append(s.state.fileIDs, fileID)
+
+	return c.NoContent(http.StatusCreated)
+}
+
+// PostDeadNationBooking confirms all tickets of the booking with the webhook, like Dead Nation does.
+func (s *Server) PostDeadNationBooking(c echo.Context) error {
+	var request dead_nation.PostTicketBookingRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if request.NumberOfTickets < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "number_of_tickets must be greater than 0")
+	}
+
+	s.state.lock.Lock()
+	_, alreadyBooked := s.state.deadNationBookings[request.BookingId]
+	s.state.deadNationBookings[request.BookingId] = request
+	s.state.lock.Unlock()
+
+	if !alreadyBooked {
+		s.webhook.confirmTickets(c.Request().Context(), request)
+	}
+
+	return c.JSON(http.StatusOK, dead_nation.PostTicketBookingResp{BookingId: request.BookingId})
+}
+
+func (s *Server) GetRefunds(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]payments.PaymentRefundRequest{}, s.state.refunds...))
+}
+
+func (s *Server) PutRefund(c echo.Context) error {
+	var request payments.PaymentRefundRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	duplicate := request.DeduplicationId != nil && slices.ContainsFunc(
+		s.state.refunds, func(r payments.PaymentRefundRequest) bool {
+			return r.DeduplicationId != nil && *r.DeduplicationId == *request.DeduplicationId
+		},
+	)
+	if !duplicate {
+		s.state.refunds = append(s.state.refunds, request)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) GetFlightTickets(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]transportation.FlightTicket{}, s.state.flightTickets...))
+}
+
+// PutFlightTickets always has seats left, inject a 409 failure to sell a flight out.
+func (s *Server) PutFlightTickets(c echo.Context) error {
+	var request transportation.BookFlightTicketRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if len(request.PassengerNames) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "passenger_names are required")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	if ticketIDs, ok := s.state.flightBookings[request.IdempotencyKey]; ok && request.IdempotencyKey != "" {
+		return c.JSON(http.StatusCreated, transportation.BookFlightTicketResponse{TicketIds: ticketIDs})
+	}
+
+	ticketIDs := make([]uuid.UUID, 0, len(request.PassengerNames))
+	for _, passenger := range request.PassengerNames {
+		ticket := transportation.FlightTicket{
+			CustomerEmail: request.CustomerEmail,
+			FlightId:      request.FlightId,
+			PassengerName: passenger,
+			ReferenceId:   request.ReferenceId,
+			TicketId:      uuid.New(),
+		}
+		s.state.flightTickets = append(s.state.flightTickets, ticket)
+		ticketIDs = append(ticketIDs, ticket.TicketId)
+	}
+	s.state.flightBookings[request.IdempotencyKey] = ticketIDs
+
+	return c.JSON(http.StatusCreated, transportation.BookFlightTicketResponse{TicketIds: ticketIDs})
+}
+
+func (s *Server) DeleteFlightTicket(c echo.Context) error {
+	ticketID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid ticket id")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	i := slices.IndexFunc(s.state.flightTickets, func(t transportation.FlightTicket) bool { return t.TicketId == ticketID })
+	if i < 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "flight ticket not found")
+	}
+	s.state.flightTickets[i].Cancelled = true
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) GetTaxiBookings(c echo.Context) error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return c.JSON(http.StatusOK, append([]transportation.TaxiBooking{}, s.state.taxiBookings...))
+}
+
+// PutTaxiBooking always finds a taxi, inject a 409 failure to make bookings fail.
+func (s *Server) PutTaxiBooking(c echo.Context) error {
+	var request transportation.TaxiBookingRequest
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+	if request.NumberOfPassengers < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "number_of_passengers must be greater than 0")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	i := slices.IndexFunc(s.state.taxiBookings, func(b transportation.TaxiBooking) bool {
+		return request.IdempotencyKey != "" && b.IdempotencyKey == request.IdempotencyKey
+	})
+	if i >= 0 {
+		return c.JSON(http.StatusCreated, transportation.TaxiBookingResponse{BookingId: s.state.taxiBookings[i].BookingId})
+	}
+
+	booking := transportation.TaxiBooking{
+		BookingId:          uuid.New(),
+		CustomerEmail:      request.CustomerEmail,
+		IdempotencyKey:     request.IdempotencyKey,
+		NumberOfPassengers: request.NumberOfPassengers,
+		PassengerName:      request.PassengerName,
+		ReferenceId:        request.ReferenceId,
+	}
+	s.state.taxiBookings = append(s.state.taxiBookings, booking)
+
+	return c.JSON(http.StatusCreated, transportation.TaxiBookingResponse{BookingId: booking.BookingId})
+}
+
+func (s *Server) DeleteTaxiBooking(c echo.Context) error {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid booking id")
+	}
+
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	i := slices.IndexFunc(s.state.taxiBookings, func(b transportation.TaxiBooking) bool { return b.BookingId == bookingID })
+	if i < 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "taxi booking not found")
+	}
+	s.state.taxiBookings[i].Cancelled = true
+
+	return c.NoContent(http.StatusNoContent)
+}
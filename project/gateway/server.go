@@ -0,0 +1,104 @@
+// Package gateway is a local stand-in for the gateway of the external services,
+// so the service can be developed without network access.
+//
+// It serves the endpoints called by the adapters and keeps their state in memory.
+// Dead Nation bookings are confirmed by calling the /tickets-status webhook of the service back.
+// The admin API under /admin lists the recorded calls and injects failures.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	libHttp "github.com/ThreeDotsLabs/go-event-driven/v2/common/http"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+type Server struct {
+	echo     *echo.Echo
+	calls    *callRecorder
+	failures *failureRules
+	state    *state
+	webhook  *webhook
+}
+
+// NewServer creates the gateway, solutionURL is the base URL of the service receiving webhooks.
+// Webhooks are not sent when it's empty.
+func NewServer(solutionURL string) *Server {
+	s := &Server{
+		echo:     libHttp.NewEcho(),
+		calls:    newCallRecorder(),
+		failures: newFailureRules(),
+		state:    newState(),
+		webhook:  newWebhook(solutionURL),
+	}
+
+	admin := s.echo.Group("/admin")
+	admin.GET("/calls", s.GetCalls)
+	admin.DELETE("/calls", s.DeleteCalls)
+	admin.GET("/failures", s.GetFailures)
+	admin.POST("/failures", s.PostFailure)
+	admin.DELETE("/failures", s.DeleteFailures)
+	admin.DELETE("/failures/:id", s.DeleteFailure)
+	admin.POST("/reset", s.PostReset)
+
+	// the service exports traces through the gateway, there is no Jaeger to forward them to
+	s.echo.POST("/jaeger-api/api/traces", func(c echo.Context) error {
+		return c.NoContent(http.StatusAccepted)
+	})
+
+	api := s.echo.Group("", s.recordCall, s.injectFailure)
+
+	api.GET("/spreadsheets-api/sheets/:sheet/rows", s.GetSheetRows)
+	api.POST("/spreadsheets-api/sheets/:sheet/rows", s.PostSheetRow)
+
+	api.GET("/receipts-api/receipts", s.GetReceipts)
+	api.PUT("/receipts-api/receipts", s.PutReceipt)
+	api.PUT("/receipts-api/void-receipt", s.PutVoidReceipt)
+
+	api.GET("/files-api/files", s.GetFiles)
+	api.GET("/files-api/files/:id/content", s.GetFileContent)
+	api.PUT("/files-api/files/:id/content", s.PutFileContent)
+
+	api.POST("/dead-nation-api/ticket/booking", s.PostDeadNationBooking)
+
+	api.GET("/payments-api/refunds", s.GetRefunds)
+	api.PUT("/payments-api/refunds", s.PutRefund)
+
+	api.GET("/transportation-api/flight-tickets", s.GetFlightTickets)
+	api.PUT("/transportation-api/flight-tickets", s.PutFlightTickets)
+	api.DELETE("/transportation-api/flight-tickets/:id", s.DeleteFlightTicket)
+	api.GET("/transportation-api/taxi-booking", s.GetTaxiBookings)
+	api.PUT("/transportation-api/taxi-booking", s.PutTaxiBooking)
+	api.DELETE("/transportation-api/taxi-booking/:id", s.DeleteTaxiBooking)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.echo.ServeHTTP(w, r)
+}
+
+func (s *Server) Run(ctx context.Context, addr string) error {
+	errGroup, ctx := errgroup.WithContext(ctx)
+	errGroup.Go(
+		func() error {
+			err := s.echo.Start(addr)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+	)
+	errGroup.Go(
+		func() error {
+			<-ctx.Done()
+			return s.echo.Shutdown(context.Background())
+		},
+	)
+
+	return errGroup.Wait()
+}
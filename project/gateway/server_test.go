@@ -0,0 +1,148 @@
+package gateway_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tickets/adapters"
+	"tickets/entities"
+	"tickets/gateway"
+	ticketsHttp "tickets/http"
+)
+
+func TestServer_dead_nation_booking_confirms_tickets(t *testing.T) {
+	webhooks := make(chan ticketsHttp.TicketsStatusRequest, 1)
+	solution := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tickets-status", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("Idempotency-Key"))
+
+		var request ticketsHttp.TicketsStatusRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		webhooks <- request
+	}))
+	defer solution.Close()
+
+	_, apiClients := startGateway(t, solution.URL)
+	booking := entities.DeadNationBooking{
+		BookingID:         uuid.New(),
+		NumberOfTickets:   2,
+		CustomerEmail:     "email@example.com",
+		DeadNationEventID: uuid.New(),
+	}
+
+	err := adapters.NewDeadNationServiceClient(apiClients).CallDeadNation(context.Background(), booking)
+	require.NoError(t, err)
+
+	select {
+	case request := <-webhooks:
+		require.Len(t, request.Tickets, 2)
+		for _, ticket := range request.Tickets {
+			assert.Equal(t, booking.BookingID.String(), ticket.BookingId)
+			assert.Equal(t, "confirmed", ticket.Status)
+			assert.Equal(t, booking.CustomerEmail, ticket.CustomerEmail)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tickets were not confirmed with the webhook")
+	}
+}
+
+func TestServer_injected_failures(t *testing.T) {
+	ctx := context.Background()
+	gatewayURL, apiClients := startGateway(t, "")
+	transportation := adapters.NewTransportationClient(apiClients)
+	spreadsheets := adapters.NewSpreadsheetsAPIClient(apiClients)
+
+	soldOutFlightID := uuid.New()
+	addFailure(t, gatewayURL, gateway.Failure{
+		Method:       http.MethodPut,
+		Path:         "/transportation-api/flight-tickets",
+		BodyContains: soldOutFlightID.String(),
+		StatusCode:   http.StatusConflict,
+	})
+	addFailure(t, gatewayURL, gateway.Failure{
+		Path:       "/spreadsheets-api",
+		StatusCode: http.StatusServiceUnavailable,
+		Skip:       1,
+		Times:      1,
+	})
+
+	_, err := transportation.BookFlight(ctx, entities.BookFlightTicketRequest{
+		FlightID:       soldOutFlightID,
+		PassengerNames: []string{"John Doe"},
+		IdempotencyKey: uuid.NewString(),
+	})
+	assert.ErrorIs(t, err, adapters.ErrNoFlightTicketsAvailable)
+	assert.True(t, entities.IsPermanentError(err))
+
+	booked, err := transportation.BookFlight(ctx, entities.BookFlightTicketRequest{
+		FlightID:       uuid.New(),
+		PassengerNames: []string{"John Doe", "Jane Doe"},
+		IdempotencyKey: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	assert.Len(t, booked.TicketIds, 2)
+
+	assert.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"1"}))
+	err = spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"2"})
+	assert.Error(t, err)
+	assert.False(t, entities.IsPermanentError(err))
+	assert.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"3"}))
+
+	var calls []gateway.Call
+	getJSON(t, gatewayURL+"/admin/calls?service=spreadsheets-api", &calls)
+	require.Len(t, calls, 3)
+	assert.Equal(t, []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK}, []int{
+		calls[0].StatusCode, calls[1].StatusCode, calls[2].StatusCode,
+	})
+	assert.True(t, calls[1].Injected)
+
+	var failures []gateway.Failure
+	getJSON(t, gatewayURL+"/admin/failures", &failures)
+	assert.Len(t, failures, 1, "failures should be removed after failing the given number of times")
+}
+
+func startGateway(t *testing.T, solutionURL string) (string, *clients.Clients) {
+	t.Helper()
+
+	gatewayServer := httptest.NewServer(gateway.NewServer(solutionURL))
+	t.Cleanup(gatewayServer.Close)
+
+	apiClients, err := clients.NewClients(gatewayServer.URL, nil)
+	require.NoError(t, err)
+
+	return gatewayServer.URL, apiClients
+}
+
+func addFailure(t *testing.T, gatewayURL string, failure gateway.Failure) {
+	t.Helper()
+
+	payload, err := json.Marshal(failure)
+	require.NoError(t, err)
+
+	resp, err := http.Post(gatewayURL+"/admin/failures", "application/json", bytes.NewReader(payload))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func getJSON(t *testing.T, url string, response any) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+}
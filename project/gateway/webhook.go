@@ -0,0 +1,116 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients/dead_nation"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
+
+	ticketsEntity "tickets/entities"
+	ticketsHttp "tickets/http"
+)
+
+const (
+	webhookAttempts      = 10
+	webhookRetryInterval = time.Second
+)
+
+// ticketPrice is the price of every ticket confirmed by the webhook, Dead Nation bookings don't carry prices.
+var ticketPrice = ticketsEntity.Money{
+	Amount:   "50.00",
+	Currency: "EUR",
+}
+
+type webhook struct {
+	solutionURL string
+	client      *http.Client
+}
+
+func newWebhook(solutionURL string) *webhook {
+	return &webhook{
+		solutionURL: solutionURL,
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// confirmTickets calls /tickets-status in the background, retrying until the service accepts the tickets.
+func (w *webhook) confirmTickets(ctx context.Context, booking dead_nation.PostTicketBookingRequest) {
+	if w.solutionURL == "" {
+		return
+	}
+
+	request := ticketsHttp.TicketsStatusRequest{}
+	for range booking.NumberOfTickets {
+		request.Tickets = append(request.Tickets, ticketsHttp.TicketStatusRequest{
+			BookingId:     booking.BookingId.String(),
+			TicketID:      uuid.NewString(),
+			Status:        "confirmed",
+			Price:         ticketPrice,
+			CustomerEmail: booking.CustomerAddress,
+		})
+	}
+
+	// the webhook outlives the request of the booking
+	ctx = context.WithoutCancel(ctx)
+	correlationID := log.CorrelationIDFromContext(ctx)
+
+	go func() {
+		logger := log.FromContext(ctx).With("booking_id", booking.BookingId)
+
+		// the same key for all attempts, so the service processes the tickets once
+		idempotencyKey := uuid.NewString()
+
+		var err error
+		for attempt := 1; attempt <= webhookAttempts; attempt++ {
+			err = w.send(request, correlationID, idempotencyKey)
+			if err == nil {
+				logger.Info("Tickets confirmed with webhook")
+				return
+			}
+
+			logger.With("error", err, "attempt", attempt).Warn("Webhook failed, retrying")
+			time.Sleep(webhookRetryInterval)
+		}
+
+		logger.With("error", err).Error("Webhook failed, giving up")
+	}()
+}
+
+func (w *webhook) send(request ticketsHttp.TicketsStatusRequest, correlationID string, idempotencyKey string) error {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	webhookURL, err := url.JoinPath(w.solutionURL, "tickets-status")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Correlation-ID", correlationID)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code for POST %s: %d", webhookURL, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -17,6 +17,11 @@ type Handler struct {
 	bookingRepository BookingRepository
 	opsReadModel      OpsBookingReadModel
 	vipBundleRepo     VipBundleRepository
+	poisonQueue       PoisonQueue
+	rebuilder         ProjectionRebuilder
+	opsBookingUpdates OpsBookingUpdates
+	outboxMonitor     OutboxMonitor
+	circuitBreakers   []CircuitBreaker
 }
 
 type TicketsRepository interface {
@@ -25,21 +30,44 @@ type TicketsRepository interface {
 type ShowsRepository interface {
 	AddShow(ctx context.Context, show ticketsEntity.Show) error
 	ShowByID(ctx context.Context, showID string) (ticketsEntity.Show, error)
+	ShowAvailabilityByID(ctx context.Context, showID string) (ticketsEntity.ShowAvailability, error)
+	AllShows(ctx context.Context, filter ticketsEntity.ShowsFilter) ([]ticketsEntity.ShowAvailability, error)
 }
 
 type BookingRepository interface {
 	AddBooking(ctx context.Context, booking ticketsEntity.Booking) error
+	CancelBooking(ctx context.Context, bookingID string) error
 }
 
 type OpsBookingReadModel interface {
-	AllBookingsByDate(date string) ([]ticketsEntity.OpsBooking, error)
+	Bookings(
+		ctx context.Context,
+		filter ticketsEntity.OpsBookingsFilter,
+	) ([]ticketsEntity.OpsBooking, *ticketsEntity.OpsBookingsCursor, error)
 	ReservationReadModel(ctx context.Context, bookingID string) (ticketsEntity.OpsBooking, error)
 }
 
+type OpsBookingUpdates interface {
+	Subscribe(ctx context.Context) <-chan uuid.UUID
+}
+
+type OutboxMonitor interface {
+	Stats(ctx context.Context) (ticketsEntity.OutboxStats, error)
+}
+
+type CircuitBreaker interface {
+	Name() string
+	State() string
+}
+
 type VipBundleRepository interface {
 	Add(ctx context.Context, vipBundle ticketsEntity.VipBundle) error
 	Get(ctx context.Context, vipBundleID ticketsEntity.VipBundleID) (ticketsEntity.VipBundle, error)
 	GetByBookingID(ctx context.Context, bookingID uuid.UUID) (ticketsEntity.VipBundle, error)
+	VipBundles(
+		ctx context.Context,
+		filter ticketsEntity.VipBundlesFilter,
+	) ([]ticketsEntity.VipBundle, *ticketsEntity.VipBundlesCursor, error)
 
 	UpdateByID(
 		ctx context.Context,
@@ -54,6 +82,20 @@ type VipBundleRepository interface {
 	) (ticketsEntity.VipBundle, error)
 }
 
+type PoisonQueue interface {
+	List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error)
+	Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error)
+	Requeue(ctx context.Context, messageID string) error
+	Remove(ctx context.Context, messageID string) error
+}
+
+type ProjectionRebuilder interface {
+	Start(ctx context.Context) (ticketsEntity.ProjectionRebuild, error)
+	Resume(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error)
+	Get(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error)
+	List(ctx context.Context) ([]ticketsEntity.ProjectionRebuild, error)
+}
+
 type dbExecutor interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
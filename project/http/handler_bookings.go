@@ -7,6 +7,7 @@ import (
 	ticketsEntity "tickets/entities"
 
 	"github.com/ThreeDotsLabs/watermill"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -37,10 +38,28 @@ func (h Handler) CreateBooking(c echo.Context) error {
 	}
 	err = h.bookingRepository.AddBooking(c.Request().Context(), booking)
 	if err != nil {
-		if errors.As(err, &ticketsDB.ErrNoPlacesLeft) {
+		if errors.Is(err, ticketsDB.ErrNoPlacesLeft) {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusCreated, BookingResponse{BookingID: booking.BookingID})
 }
+
+func (h Handler) DeleteBooking(c echo.Context) error {
+	bookingID := c.Param("id")
+	if _, err := uuid.Parse(bookingID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "booking id must be a valid UUID")
+	}
+
+	err := h.bookingRepository.CancelBooking(c.Request().Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, ticketsDB.ErrBookingNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// tickets are refunded asynchronously
+	return c.JSON(http.StatusAccepted, BookingResponse{BookingID: bookingID})
+}
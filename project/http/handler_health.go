@@ -9,7 +9,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-func health(c echo.Context) error {
+type HealthResponse struct {
+	Status string `json:"status"`
+	// CircuitBreakers are states of circuit breakers by the name of the external service
+	CircuitBreakers map[string]string `json:"circuit_breakers"`
+}
+
+// Health reports the service as healthy even when circuit breakers are open,
+// restarting the service won't fix external services.
+func (h Handler) Health(c echo.Context) error {
 	go func() {
 		for {
 			veryImportantCounter.Inc()
@@ -17,7 +25,15 @@ func health(c echo.Context) error {
 		}
 	}()
 
-	return c.String(http.StatusOK, "ok")
+	circuitBreakers := make(map[string]string, len(h.circuitBreakers))
+	for _, breaker := range h.circuitBreakers {
+		circuitBreakers[breaker.Name()] = breaker.State()
+	}
+
+	return c.JSON(http.StatusOK, HealthResponse{
+		Status:          "ok",
+		CircuitBreakers: circuitBreakers,
+	})
 }
 
 var (
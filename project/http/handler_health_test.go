@@ -0,0 +1,44 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsHttp "tickets/http"
+)
+
+func TestHealth_circuit_breakers(t *testing.T) {
+	e := newRouter(routerDependencies{
+		circuitBreakers: []ticketsHttp.CircuitBreaker{
+			circuitBreakerStub{name: "payments", state: "open"},
+			circuitBreakerStub{name: "receipts", state: "closed"},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code, "open circuit breakers shouldn't make the service unhealthy")
+
+	var response ticketsHttp.HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, map[string]string{"payments": "open", "receipts": "closed"}, response.CircuitBreakers)
+}
+
+type circuitBreakerStub struct {
+	name  string
+	state string
+}
+
+func (c circuitBreakerStub) Name() string {
+	return c.name
+}
+
+func (c circuitBreakerStub) State() string {
+	return c.state
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// keeps proxies from closing idle connections
+const sseHeartbeatInterval = 15 * time.Second
+
+// GetOpsBookingStream streams the booking whenever its read model is updated.
+// The current state is sent first, if the booking is already in the read model.
+func (h Handler) GetOpsBookingStream(c echo.Context) error {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid booking id")
+	}
+
+	ctx := c.Request().Context()
+	updates := h.opsBookingUpdates.Subscribe(ctx)
+
+	startSSE(c)
+
+	if err := h.sendOpsBooking(ctx, c, bookingID); err != nil {
+		return err
+	}
+
+	return streamSSE(ctx, c, updates, func(updatedID uuid.UUID) error {
+		if updatedID != bookingID {
+			return nil
+		}
+		return h.sendOpsBooking(ctx, c, updatedID)
+	})
+}
+
+// GetOpsBookingsStream streams all updated bookings.
+func (h Handler) GetOpsBookingsStream(c echo.Context) error {
+	ctx := c.Request().Context()
+	updates := h.opsBookingUpdates.Subscribe(ctx)
+
+	startSSE(c)
+
+	return streamSSE(ctx, c, updates, func(updatedID uuid.UUID) error {
+		return h.sendOpsBooking(ctx, c, updatedID)
+	})
+}
+
+func (h Handler) sendOpsBooking(ctx context.Context, c echo.Context, bookingID uuid.UUID) error {
+	booking, err := h.opsReadModel.ReservationReadModel(ctx, bookingID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		// headers are already sent, so the error can't be returned to the client
+		log.FromContext(ctx).With("error", err, "booking_id", bookingID).Error("Could not get ops booking")
+		return nil
+	}
+
+	data, err := json.Marshal(booking)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Response(), "event: booking\nid: %s\ndata: %s\n\n", bookingID, data)
+	if err != nil {
+		return err
+	}
+	c.Response().Flush()
+
+	return nil
+}
+
+func startSSE(c echo.Context) {
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Flush()
+}
+
+func streamSSE(
+	ctx context.Context,
+	c echo.Context,
+	updates <-chan uuid.UUID,
+	onUpdate func(bookingID uuid.UUID) error,
+) error {
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case bookingID, ok := <-updates:
+			if !ok {
+				// the service is stopping
+				return nil
+			}
+			if err := onUpdate(bookingID); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Response(), ": heartbeat\n\n"); err != nil {
+				return err
+			}
+			c.Response().Flush()
+		}
+	}
+}
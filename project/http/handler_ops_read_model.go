@@ -3,18 +3,90 @@ package http
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	ticketsEntity "tickets/entities"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/lib/pq"
 )
 
-func (h Handler) GetAllBookingByDate(c echo.Context) error {
-	receiptIssueDate := c.QueryParam("receipt_issue_date")
-	allBooking, err := h.opsReadModel.AllBookingsByDate(receiptIssueDate)
+const (
+	defaultOpsBookingsLimit = 100
+	maxOpsBookingsLimit     = 1000
+)
+
+// GetOpsBookings lists bookings page by page; the cursor of the next page is returned in the X-Next-Cursor header.
+func (h Handler) GetOpsBookings(c echo.Context) error {
+	filter := ticketsEntity.OpsBookingsFilter{
+		ShowID:           c.QueryParam("show_id"),
+		CustomerEmail:    c.QueryParam("customer_email"),
+		ReceiptNumber:    c.QueryParam("receipt_number"),
+		ReceiptIssueDate: c.QueryParam("receipt_issue_date"),
+		Limit:            defaultOpsBookingsLimit,
+	}
+
+	var err error
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxOpsBookingsLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 1000")
+		}
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		after, err := ticketsEntity.ParseOpsBookingsCursor(cursor)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		filter.After = &after
+	}
+	if filter.ShowID != "" {
+		if _, err := uuid.Parse(filter.ShowID); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "show_id must be a valid UUID")
+		}
+	}
+	if filter.ReceiptIssueDate != "" {
+		if _, err := time.Parse(time.DateOnly, filter.ReceiptIssueDate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "receipt_issue_date must be a date (YYYY-MM-DD)")
+		}
+	}
+	if filter.BookedFrom, err = parseTimeParam(c, "booked_from"); err != nil {
+		return err
+	}
+	if filter.BookedTo, err = parseEndTimeParam(c, "booked_to"); err != nil {
+		return err
+	}
+	if filter.Refunded, err = parseBoolParam(c, "refunded"); err != nil {
+		return err
+	}
+	if filter.Printed, err = parseBoolParam(c, "printed"); err != nil {
+		return err
+	}
+
+	bookings, next, err := h.opsReadModel.Bookings(c.Request().Context(), filter)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
-	return c.JSON(http.StatusOK, allBooking)
+	if next != nil {
+		c.Response().Header().Set("X-Next-Cursor", next.String())
+	}
+
+	return c.JSON(http.StatusOK, bookings)
+}
+
+func parseBoolParam(c echo.Context, name string) (*bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be true or false")
+	}
+
+	return &parsed, nil
 }
 
 func (h Handler) GetBookingByID(c echo.Context) error {
@@ -0,0 +1,40 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsMemory "tickets/db/memory"
+	ticketsEntity "tickets/entities"
+)
+
+func TestGetOpsBookings_booked_on_day(t *testing.T) {
+	readModel := ticketsMemory.NewOpsBookingReadModel(ticketsMemory.NewDB(), nil)
+
+	header := ticketsEntity.NewMessageHeader()
+	header.PublishedAt = time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	bookingID := uuid.NewString()
+	require.NoError(t, readModel.OnBookingMade(context.Background(), &ticketsEntity.BookingMade_v1{
+		Header:    header,
+		BookingID: bookingID,
+	}))
+
+	e := newRouter(routerDependencies{opsReadModel: readModel})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ops/bookings?booked_from=2024-05-01&booked_to=2024-05-01", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var bookings []ticketsEntity.OpsBooking
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &bookings))
+	require.Len(t, bookings, 1)
+	assert.Equal(t, bookingID, bookings[0].BookingID.String())
+}
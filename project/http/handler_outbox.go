@@ -0,0 +1,16 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h Handler) GetOutboxStats(c echo.Context) error {
+	stats, err := h.outboxMonitor.Stats(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
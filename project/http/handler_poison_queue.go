@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	ticketsMessage "tickets/message"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h Handler) GetPoisonedMessages(c echo.Context) error {
+	messages, err := h.poisonQueue.List(c.Request().Context())
+	if err != nil {
+		return poisonQueueError(err)
+	}
+
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h Handler) GetPoisonedMessage(c echo.Context) error {
+	msg, err := h.poisonQueue.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return poisonQueueError(err)
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+func (h Handler) PostPoisonedMessageRequeue(c echo.Context) error {
+	err := h.poisonQueue.Requeue(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return poisonQueueError(err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h Handler) DeletePoisonedMessage(c echo.Context) error {
+	err := h.poisonQueue.Remove(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return poisonQueueError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func poisonQueueError(err error) error {
+	if errors.Is(err, ticketsMessage.ErrPoisonedMessageNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, ticketsMessage.ErrPoisonQueueNotSupported) {
+		return echo.NewHTTPError(http.StatusNotImplemented, err.Error())
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
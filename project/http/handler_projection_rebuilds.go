@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	ticketsDB "tickets/db"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func (h Handler) PostOpsBookingsRebuild(c echo.Context) error {
+	rebuild, err := h.rebuilder.Start(c.Request().Context())
+	if err != nil {
+		return projectionRebuildError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, rebuild)
+}
+
+func (h Handler) GetOpsBookingsRebuilds(c echo.Context) error {
+	rebuilds, err := h.rebuilder.List(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, rebuilds)
+}
+
+func (h Handler) GetOpsBookingsRebuild(c echo.Context) error {
+	rebuildID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid rebuild id")
+	}
+
+	rebuild, err := h.rebuilder.Get(c.Request().Context(), rebuildID)
+	if err != nil {
+		return projectionRebuildError(err)
+	}
+
+	return c.JSON(http.StatusOK, rebuild)
+}
+
+func (h Handler) PostOpsBookingsRebuildResume(c echo.Context) error {
+	rebuildID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid rebuild id")
+	}
+
+	rebuild, err := h.rebuilder.Resume(c.Request().Context(), rebuildID)
+	if err != nil {
+		return projectionRebuildError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, rebuild)
+}
+
+func projectionRebuildError(err error) error {
+	switch {
+	case errors.Is(err, ticketsDB.ErrRebuildAlreadyRunning):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, ticketsDB.ErrRebuildNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,12 +1,15 @@
 package http
 
 import (
-	"fmt"
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 	ticketsEntity "tickets/entities"
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -33,9 +36,110 @@ func (h Handler) CreateShow(c echo.Context) error {
 		Venue:           request.Venue,
 	}
 	if err := h.showRepository.AddShow(c.Request().Context(), show); err != nil {
-		return fmt.Errorf(err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	data := map[string]string{"show_id": show.ShowID}
 
 	return c.JSON(http.StatusCreated, data)
 }
+
+const (
+	defaultShowsLimit = 20
+	maxShowsLimit     = 100
+)
+
+type ShowsResponse struct {
+	Shows  []ticketsEntity.ShowAvailability `json:"shows"`
+	Limit  int                              `json:"limit"`
+	Offset int                              `json:"offset"`
+}
+
+func (h Handler) GetShows(c echo.Context) error {
+	filter := ticketsEntity.ShowsFilter{
+		Venue:  c.QueryParam("venue"),
+		Limit:  defaultShowsLimit,
+		Offset: 0,
+	}
+
+	var err error
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxShowsLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 100")
+		}
+	}
+	if offset := c.QueryParam("offset"); offset != "" {
+		filter.Offset, err = strconv.Atoi(offset)
+		if err != nil || filter.Offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "offset must be a non-negative number")
+		}
+	}
+	if filter.From, err = parseTimeParam(c, "from"); err != nil {
+		return err
+	}
+	if filter.To, err = parseEndTimeParam(c, "to"); err != nil {
+		return err
+	}
+
+	shows, err := h.showRepository.AllShows(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(
+		http.StatusOK, ShowsResponse{
+			Shows:  shows,
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+	)
+}
+
+func (h Handler) GetShow(c echo.Context) error {
+	showID := c.Param("id")
+	if _, err := uuid.Parse(showID); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "show id must be a valid UUID")
+	}
+
+	show, err := h.showRepository.ShowAvailabilityByID(c.Request().Context(), showID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "show not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, show)
+}
+
+// parseTimeParam accepts both RFC 3339 timestamps and plain dates (2006-01-02).
+func parseTimeParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be a RFC 3339 timestamp or a date (YYYY-MM-DD)")
+}
+
+// parseEndTimeParam parses the exclusive end of a range, a plain date includes the whole day.
+func parseEndTimeParam(c echo.Context, name string) (*time.Time, error) {
+	end, err := parseTimeParam(c, name)
+	if err != nil || end == nil {
+		return end, err
+	}
+
+	if _, err := time.Parse(time.DateOnly, c.QueryParam(name)); err == nil {
+		nextDay := end.AddDate(0, 0, 1)
+		return &nextDay, nil
+	}
+
+	return end, nil
+}
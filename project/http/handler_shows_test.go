@@ -0,0 +1,100 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsMemory "tickets/db/memory"
+	ticketsEntity "tickets/entities"
+	ticketsHttp "tickets/http"
+)
+
+func TestGetShow(t *testing.T) {
+	shows := ticketsMemory.NewShowsRepository(ticketsMemory.NewDB())
+	show := ticketsEntity.Show{ShowID: uuid.NewString(), NumberOfTickets: 3, Title: "The Show"}
+	require.NoError(t, shows.AddShow(context.Background(), show))
+
+	e := newRouter(routerDependencies{shows: shows})
+
+	get := func(showID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/shows/"+showID, nil))
+		return rec
+	}
+
+	found := get(show.ShowID)
+	require.Equal(t, http.StatusOK, found.Code)
+
+	var availability ticketsEntity.ShowAvailability
+	require.NoError(t, json.Unmarshal(found.Body.Bytes(), &availability))
+	assert.Equal(t, show.ShowID, availability.ShowID)
+	assert.Equal(t, 3, availability.RemainingSeats)
+
+	assert.Equal(t, http.StatusNotFound, get(uuid.NewString()).Code)
+	assert.Equal(t, http.StatusBadRequest, get("not-a-uuid").Code)
+}
+
+func TestGetShows_date_range(t *testing.T) {
+	ctx := context.Background()
+	shows := ticketsMemory.NewShowsRepository(ticketsMemory.NewDB())
+
+	may1Evening := ticketsEntity.Show{
+		ShowID:    uuid.NewString(),
+		StartTime: time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC),
+	}
+	may2Midnight := ticketsEntity.Show{
+		ShowID:    uuid.NewString(),
+		StartTime: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, shows.AddShow(ctx, may1Evening))
+	require.NoError(t, shows.AddShow(ctx, may2Midnight))
+
+	e := newRouter(routerDependencies{shows: shows})
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "same day",
+			query:    "from=2024-05-01&to=2024-05-01",
+			expected: []string{may1Evening.ShowID},
+		},
+		{
+			name:     "last day included",
+			query:    "from=2024-05-01&to=2024-05-02",
+			expected: []string{may1Evening.ShowID, may2Midnight.ShowID},
+		},
+		{
+			name:     "timestamp end excluded",
+			query:    "from=2024-05-01&to=2024-05-02T00:00:00Z",
+			expected: []string{may1Evening.ShowID},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/shows?"+tc.query, nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response ticketsHttp.ShowsResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+			var showIDs []string
+			for _, show := range response.Shows {
+				showIDs = append(showIDs, show.ShowID)
+			}
+			assert.Equal(t, tc.expected, showIDs)
+		})
+	}
+}
@@ -34,7 +34,7 @@ func (h Handler) PostTicketsStatus(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	idempotencyKey := c.Request().Header.Get("Idempotency-Key")
+	idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
 	if idempotencyKey == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key is required")
 	}
@@ -1,7 +1,11 @@
 package http
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -36,6 +40,7 @@ func (h Handler) PostVipBundle(c echo.Context) error {
 
 	vb := ticketsEntity.VipBundle{
 		VipBundleID:     ticketsEntity.VipBundleID{UUID: uuid.New()},
+		CreatedAt:       time.Now().UTC(),
 		BookingID:       uuid.New(),
 		CustomerEmail:   request.CustomerEmail,
 		NumberOfTickets: request.NumberOfTickets,
@@ -58,3 +63,157 @@ func (h Handler) PostVipBundle(c echo.Context) error {
 		},
 	)
 }
+
+const (
+	defaultVipBundlesLimit = 100
+	maxVipBundlesLimit     = 1000
+)
+
+const (
+	vipBundleStepCompleted = "completed"
+	vipBundleStepFailed    = "failed"
+	vipBundleStepPending   = "pending"
+	vipBundleStepSkipped   = "skipped"
+)
+
+type vipBundleStepResponse struct {
+	Step          ticketsEntity.VipBundleStep `json:"step"`
+	Status        string                      `json:"status"`
+	At            *time.Time                  `json:"at,omitempty"`
+	FailureReason string                      `json:"failure_reason,omitempty"`
+}
+
+type vipBundleDetailsResponse struct {
+	VipBundleID ticketsEntity.VipBundleID     `json:"vip_bundle_id"`
+	BookingID   uuid.UUID                     `json:"booking_id"`
+	Status      ticketsEntity.VipBundleStatus `json:"status"`
+
+	CustomerEmail   string    `json:"customer_email"`
+	ShowID          uuid.UUID `json:"show_id"`
+	NumberOfTickets int       `json:"number_of_tickets"`
+	Passengers      []string  `json:"passengers"`
+	InboundFlightID uuid.UUID `json:"inbound_flight_id"`
+	ReturnFlightID  uuid.UUID `json:"return_flight_id"`
+
+	TicketIDs              []uuid.UUID `json:"ticket_ids"`
+	InboundFlightTicketIDs []uuid.UUID `json:"inbound_flight_ticket_ids"`
+	ReturnFlightTicketIDs  []uuid.UUID `json:"return_flight_ticket_ids"`
+	TaxiBookingID          *uuid.UUID  `json:"taxi_booking_id"`
+
+	CreatedAt     time.Time  `json:"created_at"`
+	FinalizedAt   *time.Time `json:"finalized_at"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+
+	Steps []vipBundleStepResponse `json:"steps"`
+}
+
+func newVipBundleDetailsResponse(vb ticketsEntity.VipBundle) vipBundleDetailsResponse {
+	return vipBundleDetailsResponse{
+		VipBundleID:            vb.VipBundleID,
+		BookingID:              vb.BookingID,
+		Status:                 vb.Status(),
+		CustomerEmail:          vb.CustomerEmail,
+		ShowID:                 vb.ShowID,
+		NumberOfTickets:        vb.NumberOfTickets,
+		Passengers:             vb.Passengers,
+		InboundFlightID:        vb.InboundFlightID,
+		ReturnFlightID:         vb.ReturnFlightID,
+		TicketIDs:              vb.TicketIDs,
+		InboundFlightTicketIDs: vb.InboundFlightTicketsIDs,
+		ReturnFlightTicketIDs:  vb.ReturnFlightTicketsIDs,
+		TaxiBookingID:          vb.TaxiBookingID,
+		CreatedAt:              vb.CreatedAt,
+		FinalizedAt:            vb.FinalizedAt,
+		FailureReason:          vb.FailureReason,
+		Steps: []vipBundleStepResponse{
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepBookingMade),
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepTicketsConfirmed),
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepInboundFlightBooked),
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepReturnFlightBooked),
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepTaxiBooked),
+			newVipBundleStepResponse(vb, ticketsEntity.VipBundleStepFinalized),
+		},
+	}
+}
+
+func newVipBundleStepResponse(vb ticketsEntity.VipBundle, step ticketsEntity.VipBundleStep) vipBundleStepResponse {
+	completedAt := vb.StepCompletedAt(step)
+
+	switch {
+	case vb.FailedStep == step:
+		return vipBundleStepResponse{
+			Step:          step,
+			Status:        vipBundleStepFailed,
+			At:            vb.FinalizedAt,
+			FailureReason: vb.FailureReason,
+		}
+	case completedAt != nil:
+		return vipBundleStepResponse{Step: step, Status: vipBundleStepCompleted, At: completedAt}
+	case vb.IsFinalized:
+		// the bundle failed before getting to this step
+		return vipBundleStepResponse{Step: step, Status: vipBundleStepSkipped}
+	default:
+		return vipBundleStepResponse{Step: step, Status: vipBundleStepPending}
+	}
+}
+
+func (h Handler) GetVipBundle(c echo.Context) error {
+	vipBundleID, err := ticketsEntity.ParseBundleID(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "vip bundle id must be a valid UUID")
+	}
+
+	vb, err := h.vipBundleRepo.Get(c.Request().Context(), vipBundleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "vip bundle not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, newVipBundleDetailsResponse(vb))
+}
+
+// GetVipBundles lists bundles page by page; the cursor of the next page is returned in the X-Next-Cursor header.
+func (h Handler) GetVipBundles(c echo.Context) error {
+	filter := ticketsEntity.VipBundlesFilter{
+		CustomerEmail: c.QueryParam("customer_email"),
+		Limit:         defaultVipBundlesLimit,
+	}
+
+	var err error
+	if status := c.QueryParam("status"); status != "" {
+		filter.Status, err = ticketsEntity.ParseVipBundleStatus(status)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "status must be in_progress, succeeded or failed")
+		}
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxVipBundlesLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 1000")
+		}
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		after, err := ticketsEntity.ParseVipBundlesCursor(cursor)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		filter.After = &after
+	}
+
+	vipBundles, next, err := h.vipBundleRepo.VipBundles(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if next != nil {
+		c.Response().Header().Set("X-Next-Cursor", next.String())
+	}
+
+	response := make([]vipBundleDetailsResponse, 0, len(vipBundles))
+	for _, vb := range vipBundles {
+		response = append(response, newVipBundleDetailsResponse(vb))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	ticketsEntity "tickets/entities"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/labstack/echo/v4"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type IdempotencyKeyRepository interface {
+	Reserve(
+		ctx context.Context,
+		idempotencyKey string,
+		requestFingerprint string,
+	) (ticketsEntity.IdempotentRequest, bool, error)
+	SaveResponse(ctx context.Context, idempotencyKey string, status int, contentType string, body []byte) error
+	Release(ctx context.Context, idempotencyKey string) error
+}
+
+// IdempotencyMiddleware stores the response of the first request with the given Idempotency-Key
+// and replays it for all retries of that request.
+// Requests without the header are passed through.
+func IdempotencyMiddleware(repo IdempotencyKeyRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			idempotencyKey := c.Request().Header.Get(idempotencyKeyHeader)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "could not read request body")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := requestFingerprint(c.Request(), body)
+
+			existing, reserved, err := repo.Reserve(ctx, idempotencyKey, fingerprint)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			if !reserved {
+				if existing.RequestFingerprint != fingerprint {
+					return echo.NewHTTPError(
+						http.StatusUnprocessableEntity,
+						"Idempotency-Key was already used with a different request",
+					)
+				}
+				if existing.ResponseStatus == nil {
+					return echo.NewHTTPError(
+						http.StatusConflict,
+						"request with this Idempotency-Key is still being processed",
+					)
+				}
+
+				c.Response().Header().Set("Idempotent-Replayed", "true")
+				if existing.ResponseContentType != "" {
+					return c.Blob(*existing.ResponseStatus, existing.ResponseContentType, existing.ResponseBody)
+				}
+				return c.NoContent(*existing.ResponseStatus)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			err = next(c)
+
+			status := c.Response().Status
+			if err != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+				// the request can be safely retried with the same key
+				if releaseErr := repo.Release(ctx, idempotencyKey); releaseErr != nil {
+					log.FromContext(ctx).With("error", releaseErr).Error("Failed to release idempotency key")
+				}
+				return err
+			}
+
+			saveErr := repo.SaveResponse(
+				ctx,
+				idempotencyKey,
+				status,
+				c.Response().Header().Get(echo.HeaderContentType),
+				recorder.body.Bytes(),
+			)
+			if saveErr != nil {
+				// the response was already sent, the key will be taken over after the lock expires
+				log.FromContext(ctx).With("error", saveErr).Error("Failed to save idempotent response")
+			}
+
+			return nil
+		}
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte(r.URL.Path))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
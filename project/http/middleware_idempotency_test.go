@@ -0,0 +1,109 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ticketsEntity "tickets/entities"
+	ticketsHttp "tickets/http"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	repo := &idempotencyKeyRepositoryMock{requests: map[string]ticketsEntity.IdempotentRequest{}}
+
+	calls := 0
+	e := echo.New()
+	e.POST(
+		"/book-tickets",
+		func(c echo.Context) error {
+			calls++
+			return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+		},
+		ticketsHttp.IdempotencyMiddleware(repo),
+	)
+
+	send := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/book-tickets", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"number_of_tickets": 1}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	retry := send("key-1", `{"number_of_tickets": 1}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls, "handler should be called only once")
+
+	reused := send("key-1", `{"number_of_tickets": 2}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+
+	other := send("key-2", `{"number_of_tickets": 2}`)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Equal(t, 2, calls)
+}
+
+type idempotencyKeyRepositoryMock struct {
+	lock     sync.Mutex
+	requests map[string]ticketsEntity.IdempotentRequest
+}
+
+func (r *idempotencyKeyRepositoryMock) Reserve(
+	ctx context.Context,
+	idempotencyKey string,
+	requestFingerprint string,
+) (ticketsEntity.IdempotentRequest, bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if existing, ok := r.requests[idempotencyKey]; ok {
+		return existing, false, nil
+	}
+
+	r.requests[idempotencyKey] = ticketsEntity.IdempotentRequest{
+		IdempotencyKey:     idempotencyKey,
+		RequestFingerprint: requestFingerprint,
+	}
+
+	return ticketsEntity.IdempotentRequest{}, true, nil
+}
+
+func (r *idempotencyKeyRepositoryMock) SaveResponse(
+	ctx context.Context,
+	idempotencyKey string,
+	status int,
+	contentType string,
+	body []byte,
+) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	request := r.requests[idempotencyKey]
+	request.ResponseStatus = &status
+	request.ResponseContentType = contentType
+	request.ResponseBody = body
+	r.requests[idempotencyKey] = request
+
+	return nil
+}
+
+func (r *idempotencyKeyRepositoryMock) Release(ctx context.Context, idempotencyKey string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.requests, idempotencyKey)
+
+	return nil
+}
@@ -16,6 +16,12 @@ func NewHttpRouter(
 	bookingRepo BookingRepository,
 	opsReadModel OpsBookingReadModel,
 	vipBundleRepo VipBundleRepository,
+	poisonQueue PoisonQueue,
+	idempotencyKeyRepo IdempotencyKeyRepository,
+	rebuilder ProjectionRebuilder,
+	opsBookingUpdates OpsBookingUpdates,
+	outboxMonitor OutboxMonitor,
+	circuitBreakers []CircuitBreaker,
 ) *echo.Echo {
 	e := libHttp.NewEcho()
 
@@ -27,22 +33,55 @@ func NewHttpRouter(
 		bookingRepository: bookingRepo,
 		opsReadModel:      opsReadModel,
 		vipBundleRepo:     vipBundleRepo,
+		poisonQueue:       poisonQueue,
+		rebuilder:         rebuilder,
+		opsBookingUpdates: opsBookingUpdates,
+		outboxMonitor:     outboxMonitor,
+		circuitBreakers:   circuitBreakers,
 	}
 
-	e.GET("/health", health)
-	e.POST("/tickets-status", handler.PostTicketsStatus)
+	idempotency := IdempotencyMiddleware(idempotencyKeyRepo)
+
+	e.GET("/health", handler.Health)
+	e.POST("/tickets-status", handler.PostTicketsStatus, idempotency)
 	e.GET("/tickets", handler.GetAllTickets)
 
 	e.POST("/shows", handler.CreateShow)
+	e.GET("/shows", handler.GetShows)
+	e.GET("/shows/:id", handler.GetShow)
 
-	e.POST("/book-tickets", handler.CreateBooking)
+	e.POST("/book-tickets", handler.CreateBooking, idempotency)
+	e.DELETE("/bookings/:id", handler.DeleteBooking)
 
 	e.PUT("ticket-refund/:ticket_id", handler.PutTicketRefund)
-	e.GET("/ops/bookings", handler.GetAllBookingByDate)
+	e.GET("/ops/bookings", handler.GetOpsBookings)
 	e.GET("/ops/bookings/:id", handler.GetBookingByID)
+	e.GET("/ops/bookings/stream", handler.GetOpsBookingsStream)
+	e.GET("/ops/bookings/:id/stream", handler.GetOpsBookingStream)
+
+	// poison queue
+	e.GET("/ops/poison-queue", handler.GetPoisonedMessages)
+	e.GET("/ops/poison-queue/:id", handler.GetPoisonedMessage)
+	e.POST("/ops/poison-queue/:id/requeue", handler.PostPoisonedMessageRequeue)
+	e.DELETE("/ops/poison-queue/:id", handler.DeletePoisonedMessage)
+
+	// the outbox and read model rebuilds are not available when the service runs in memory
+	if outboxMonitor != nil {
+		e.GET("/ops/outbox", handler.GetOutboxStats)
+	}
+
+	// read model rebuilds
+	if rebuilder != nil {
+		e.POST("/admin/projections/ops-bookings/rebuilds", handler.PostOpsBookingsRebuild)
+		e.GET("/admin/projections/ops-bookings/rebuilds", handler.GetOpsBookingsRebuilds)
+		e.GET("/admin/projections/ops-bookings/rebuilds/:id", handler.GetOpsBookingsRebuild)
+		e.POST("/admin/projections/ops-bookings/rebuilds/:id/resume", handler.PostOpsBookingsRebuildResume)
+	}
 
 	// vip bundle
-	e.POST("/book-vip-bundle", handler.PostVipBundle)
+	e.POST("/book-vip-bundle", handler.PostVipBundle, idempotency)
+	e.GET("/vip-bundles", handler.GetVipBundles)
+	e.GET("/vip-bundles/:id", handler.GetVipBundle)
 
 	// for metrics
 	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
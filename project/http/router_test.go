@@ -0,0 +1,32 @@
+package http_test
+
+import (
+	"github.com/labstack/echo/v4"
+
+	ticketsHttp "tickets/http"
+)
+
+// routerDependencies are the dependencies used by the tested handlers, the others are nil.
+type routerDependencies struct {
+	shows           ticketsHttp.ShowsRepository
+	opsReadModel    ticketsHttp.OpsBookingReadModel
+	circuitBreakers []ticketsHttp.CircuitBreaker
+}
+
+func newRouter(deps routerDependencies) *echo.Echo {
+	return ticketsHttp.NewHttpRouter(
+		nil,
+		nil,
+		nil,
+		deps.shows,
+		nil,
+		deps.opsReadModel,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		deps.circuitBreakers,
+	)
+}
@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	ticketsAdapter "tickets/adapters"
-	ticketsMessage "tickets/message"
+	"tickets/config"
+	dataLakeExport "tickets/data_lake_export"
+	ticketsDB "tickets/db"
+	ticketsHttp "tickets/http"
+	"tickets/message/broker"
 	ticketsService "tickets/service"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/clients"
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill"
 	"github.com/jmoiron/sqlx"
 	"github.com/uptrace/opentelemetry-go-extra/otelsql"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -22,25 +29,62 @@ import (
 func main() {
 	log.Init(slog.LevelInfo)
 
-	traceDB, err := otelsql.Open(
-		"postgres", os.Getenv("POSTGRES_URL"),
-		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
-		otelsql.WithDBName("db"),
-	)
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err == nil {
+		if len(args) > 0 {
+			// commands only use the database
+			err = cfg.ValidateDatabase()
+		} else {
+			err = cfg.Validate()
+		}
+	}
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
 	}
 
-	db := sqlx.NewDb(traceDB, "postgres")
-	if err != nil {
-		panic(err)
+	// commands always use the database
+	var db *sqlx.DB
+	if !cfg.InMemory || len(args) > 0 {
+		traceDB, err := otelsql.Open(
+			"postgres", cfg.PostgresURL,
+			otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+			otelsql.WithDBName("db"),
+		)
+		if err != nil {
+			panic(err)
+		}
+
+		db = sqlx.NewDb(traceDB, "postgres")
+		defer func() {
+			err := db.Close()
+			if err != nil {
+				panic(err)
+			}
+		}()
 	}
-	defer func() {
-		err := db.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if len(args) > 0 {
+		var err error
+		switch args[0] {
+		case dataLakeExport.CommandName:
+			err = dataLakeExport.RunCommand(ctx, ticketsDB.NewEventsRepository(db), args[1:])
+		case migrateCommandName:
+			err = runMigrateCommand(ctx, db, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", args[0])
+		}
 		if err != nil {
 			panic(err)
 		}
-	}()
+		return
+	}
 
 	traceHttpClients := &http.Client{
 		Transport: otelhttp.NewTransport(
@@ -53,7 +97,7 @@ func main() {
 		)}
 
 	apiClients, err := clients.NewClientsWithHttpClient(
-		os.Getenv("GATEWAY_ADDR"),
+		cfg.GatewayAddr,
 		func(ctx context.Context, req *http.Request) error {
 			req.Header.Set("Correlation-ID", log.CorrelationIDFromContext(ctx))
 			return nil
@@ -64,29 +108,99 @@ func main() {
 		panic(err)
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer cancel()
+	spreadsheetsBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.SpreadsheetsService, cfg.CircuitBreakers[ticketsAdapter.SpreadsheetsService])
+	receiptsBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.ReceiptsService, cfg.CircuitBreakers[ticketsAdapter.ReceiptsService])
+	filesBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.FilesService, cfg.CircuitBreakers[ticketsAdapter.FilesService])
+	deadNationBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.DeadNationService, cfg.CircuitBreakers[ticketsAdapter.DeadNationService])
+	paymentsBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.PaymentsService, cfg.CircuitBreakers[ticketsAdapter.PaymentsService])
+	transportationBreaker := ticketsAdapter.NewCircuitBreaker(ticketsAdapter.TransportationService, cfg.CircuitBreakers[ticketsAdapter.TransportationService])
+
+	// rate limiters wrap circuit breakers, so waiting for the limiter is never counted as a failure of the service
+	spreadsheetsAPI := ticketsAdapter.NewSpreadsheetsAPIWithRateLimiter(
+		ticketsAdapter.NewSpreadsheetsAPIWithCircuitBreaker(
+			ticketsAdapter.NewSpreadsheetsAPIClient(apiClients),
+			spreadsheetsBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.SpreadsheetsService, cfg.RateLimits[ticketsAdapter.SpreadsheetsService]),
+	)
+	receiptsService := ticketsAdapter.NewReceiptsServiceWithRateLimiter(
+		ticketsAdapter.NewReceiptsServiceWithCircuitBreaker(
+			ticketsAdapter.NewReceiptsServiceClient(apiClients),
+			receiptsBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.ReceiptsService, cfg.RateLimits[ticketsAdapter.ReceiptsService]),
+	)
+	fileService := ticketsAdapter.NewFileServiceWithRateLimiter(
+		ticketsAdapter.NewFileServiceWithCircuitBreaker(
+			ticketsAdapter.NewFileServiceClient(apiClients),
+			filesBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.FilesService, cfg.RateLimits[ticketsAdapter.FilesService]),
+	)
+	deadNationService := ticketsAdapter.NewDeadNationServiceWithRateLimiter(
+		ticketsAdapter.NewDeadNationServiceWithCircuitBreaker(
+			ticketsAdapter.NewDeadNationServiceClient(apiClients),
+			deadNationBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.DeadNationService, cfg.RateLimits[ticketsAdapter.DeadNationService]),
+	)
+	paymentsService := ticketsAdapter.NewPaymentsServiceWithRateLimiter(
+		ticketsAdapter.NewPaymentsServiceWithCircuitBreaker(
+			ticketsAdapter.NewPaymentsServiceClient(apiClients),
+			paymentsBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.PaymentsService, cfg.RateLimits[ticketsAdapter.PaymentsService]),
+	)
+	bookFligtService := ticketsAdapter.NewTransportationWithRateLimiter(
+		ticketsAdapter.NewTransportationWithCircuitBreaker(
+			ticketsAdapter.NewTransportationClient(apiClients),
+			transportationBreaker,
+		),
+		ticketsAdapter.NewRateLimiter(ticketsAdapter.TransportationService, cfg.RateLimits[ticketsAdapter.TransportationService]),
+	)
+
+	circuitBreakers := []ticketsHttp.CircuitBreaker{
+		spreadsheetsBreaker,
+		receiptsBreaker,
+		filesBreaker,
+		deadNationBreaker,
+		paymentsBreaker,
+		transportationBreaker,
+	}
+
+	var service ticketsService.Service
+	if cfg.InMemory {
+		service = ticketsService.NewInMemory(
+			cfg,
+			spreadsheetsAPI,
+			receiptsService,
+			fileService,
+			paymentsService,
+			deadNationService,
+			bookFligtService,
+			circuitBreakers,
+		)
+	} else {
+		messageBroker, err := broker.New(cfg.Broker, db, watermill.NewSlogLogger(log.FromContext(ctx)))
+		if err != nil {
+			panic(err)
+		}
 
-	spreadsheetsAPI := ticketsAdapter.NewSpreadsheetsAPIClient(apiClients)
-	receiptsService := ticketsAdapter.NewReceiptsServiceClient(apiClients)
-	fileService := ticketsAdapter.NewFileServiceClient(apiClients)
-	deadNationService := ticketsAdapter.NewDeadNationServiceClient(apiClients)
-	paymentsService := ticketsAdapter.NewPaymentsServiceClient(apiClients)
-	bookFligtService := ticketsAdapter.NewTransportationClient(apiClients)
-
-	rdb := ticketsMessage.NewRedisClient(os.Getenv("REDIS_ADDR"))
-
-	err = ticketsService.New(
-		db,
-		spreadsheetsAPI,
-		receiptsService,
-		fileService,
-		paymentsService,
-		deadNationService,
-		bookFligtService,
-		rdb,
-	).Run(ctx)
+		service = ticketsService.New(
+			cfg,
+			db,
+			spreadsheetsAPI,
+			receiptsService,
+			fileService,
+			paymentsService,
+			deadNationService,
+			bookFligtService,
+			messageBroker,
+			circuitBreakers,
+		)
+	}
 
+	err = service.Run(ctx)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,63 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	ticketsEntity "tickets/entities"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	KindRedis = "redis"
+	KindKafka = "kafka"
+	KindSQL   = "sql"
+)
+
+var Kinds = []string{KindRedis, KindKafka, KindSQL}
+
+type Config struct {
+	// Kind is one of Kinds
+	Kind         string   `yaml:"kind"`
+	RedisAddr    string   `yaml:"redis_addr"`
+	KafkaBrokers []string `yaml:"kafka_brokers"`
+}
+
+// Broker is the message broker used for events and commands.
+type Broker interface {
+	Publisher() message.Publisher
+
+	// Subscriber returns a subscriber of the consumer group, each message is handled by one subscriber of the group.
+	Subscriber(consumerGroup string) (message.Subscriber, error)
+
+	// FanOutSubscriber returns a subscriber receiving all messages, so each instance of the service receives them.
+	FanOutSubscriber() (message.Subscriber, error)
+
+	// ConsumerGroup returns the consumer group of the handler in a form accepted by the broker.
+	ConsumerGroup(prefix string, handlerName string) string
+
+	PoisonQueue() PoisonQueue
+}
+
+type PoisonQueue interface {
+	List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error)
+	Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error)
+	Requeue(ctx context.Context, messageID string) error
+	Remove(ctx context.Context, messageID string) error
+}
+
+// New creates the broker of the configured kind. The database is used only by the SQL broker.
+func New(config Config, db *sqlx.DB, logger watermill.LoggerAdapter) (Broker, error) {
+	switch config.Kind {
+	case KindRedis:
+		return NewRedis(config.RedisAddr, logger), nil
+	case KindKafka:
+		return NewKafka(config.KafkaBrokers, logger)
+	case KindSQL:
+		return NewSQL(db, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", config.Kind)
+	}
+}
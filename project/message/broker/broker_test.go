@@ -0,0 +1,220 @@
+package broker_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	ticketsMessage "tickets/message"
+	"tickets/message/broker"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerGroup(t *testing.T) {
+	assert.Equal(
+		t,
+		"svc-tickets.events.vip_bundle_process_manager.OnBookingMade",
+		broker.Redis{}.ConsumerGroup("svc-tickets.events.", "vip_bundle_process_manager.OnBookingMade"),
+	)
+	assert.Equal(
+		t,
+		"svc-tickets.commands.Book_Flight",
+		broker.Kafka{}.ConsumerGroup("svc-tickets.commands.", "Book Flight"),
+	)
+}
+
+func TestNew_unknown_kind(t *testing.T) {
+	_, err := broker.New(broker.Config{Kind: "nats"}, nil, watermill.NopLogger{})
+	assert.ErrorContains(t, err, `unknown broker "nats"`)
+}
+
+func TestNew_kafka_without_brokers(t *testing.T) {
+	_, err := broker.New(broker.Config{Kind: broker.KindKafka}, nil, watermill.NopLogger{})
+	assert.ErrorContains(t, err, "missing Kafka brokers")
+}
+
+func TestSQL_Subscriber(t *testing.T) {
+	sqlBroker := newSQLBroker(t)
+	topic := "broker_test_" + watermill.NewShortUUID()
+
+	first := subscribe(t, sqlBroker, "group_a", topic)
+	second := subscribe(t, sqlBroker, "group_b", topic)
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	require.NoError(t, sqlBroker.Publisher().Publish(topic, msg))
+
+	assert.Equal(t, msg.UUID, receive(t, first).UUID, "each consumer group should receive the message")
+	assert.Equal(t, msg.UUID, receive(t, second).UUID, "each consumer group should receive the message")
+}
+
+func TestSQL_FanOutSubscriber(t *testing.T) {
+	ctx := context.Background()
+	sqlBroker := newSQLBroker(t)
+	topic := "broker_test_" + watermill.NewShortUUID()
+
+	published := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	require.NoError(t, sqlBroker.Publisher().Publish(topic, published))
+
+	subscriber, err := sqlBroker.FanOutSubscriber()
+	require.NoError(t, err)
+	messages, err := subscriber.Subscribe(ctx, topic)
+	require.NoError(t, err)
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	require.NoError(t, sqlBroker.Publisher().Publish(topic, msg))
+
+	assert.Equal(t, msg.UUID, receive(t, messages).UUID, "messages published before subscribing should be skipped")
+
+	db := getDb(t)
+	countOffsets := func() int {
+		var count int
+		require.NoError(t, db.Get(&count, `SELECT count(*) FROM "watermill_offsets_`+topic+`"`))
+		return count
+	}
+	require.Equal(t, 1, countOffsets())
+
+	require.NoError(t, subscriber.Close())
+	assert.Equal(t, 0, countOffsets(), "offsets of the closed fan-out subscriber should be removed")
+}
+
+func TestSQL_FanOutSubscriber_closed_when_ops_booking_updates_stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sqlBroker := newSQLBroker(t)
+	db := getDb(t)
+
+	existingGroups := fanOutGroups(t, db)
+
+	subscriber, err := sqlBroker.FanOutSubscriber()
+	require.NoError(t, err)
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- ticketsMessage.NewOpsBookingUpdates(subscriber).Run(ctx)
+	}()
+
+	var group string
+	require.Eventually(t, func() bool {
+		for g := range fanOutGroups(t, db) {
+			if !existingGroups[g] {
+				group = g
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 100*time.Millisecond, "offsets of the fan-out subscriber should be created")
+
+	cancel()
+	require.NoError(t, <-stopped)
+
+	assert.NotContains(t, fanOutGroups(t, db), group, "offsets should be removed when ops booking updates stop")
+}
+
+func TestKafka_Subscriber(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("KAFKA_BROKERS is not set")
+	}
+
+	kafkaBroker, err := broker.New(
+		broker.Config{Kind: broker.KindKafka, KafkaBrokers: strings.Split(brokers, ",")},
+		nil,
+		watermill.NopLogger{},
+	)
+	require.NoError(t, err)
+
+	topic := "broker_test_" + watermill.NewShortUUID()
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	// published before the group is created, it's received as the group starts from the oldest message
+	require.NoError(t, kafkaBroker.Publisher().Publish(topic, msg))
+
+	messages := subscribe(t, kafkaBroker, kafkaBroker.ConsumerGroup("broker_test.", "handler"), topic)
+
+	assert.Equal(t, msg.UUID, receive(t, messages).UUID)
+}
+
+func newSQLBroker(t *testing.T) broker.Broker {
+	t.Helper()
+
+	sqlBroker, err := broker.New(broker.Config{Kind: broker.KindSQL}, getDb(t), watermill.NopLogger{})
+	require.NoError(t, err)
+
+	return sqlBroker
+}
+
+func getDb(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	postgresURL := os.Getenv("POSTGRES_URL")
+	if postgresURL == "" {
+		t.Skip("POSTGRES_URL is not set")
+	}
+
+	db, err := sqlx.Open("postgres", postgresURL)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func subscribe(t *testing.T, b broker.Broker, consumerGroup string, topic string) <-chan *message.Message {
+	t.Helper()
+
+	subscriber, err := b.Subscriber(consumerGroup)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = subscriber.Close()
+	})
+
+	messages, err := subscriber.Subscribe(context.Background(), topic)
+	require.NoError(t, err)
+
+	return messages
+}
+
+// fanOutGroups returns consumer groups of fan-out subscribers of internal events,
+// the offsets tables names are truncated, so all of them are checked.
+func fanOutGroups(t *testing.T, db *sqlx.DB) map[string]bool {
+	t.Helper()
+
+	var tables []string
+	require.NoError(t, db.Select(
+		&tables,
+		`SELECT quote_ident(tablename) FROM pg_tables WHERE tablename LIKE 'watermill\_offsets\_internal-events.%'`,
+	))
+
+	groups := map[string]bool{}
+	for _, table := range tables {
+		var tableGroups []string
+		require.NoError(t, db.Select(
+			&tableGroups,
+			`SELECT consumer_group FROM `+table+` WHERE consumer_group LIKE 'fan\_out\_%'`,
+		))
+		for _, group := range tableGroups {
+			groups[group] = true
+		}
+	}
+
+	return groups
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		return msg
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "message not received")
+		return nil
+	}
+}
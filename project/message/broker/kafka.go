@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"fmt"
+	"regexp"
+	ticketsMessage "tickets/message"
+	ticketsOutbox "tickets/message/outbox"
+
+	"github.com/IBM/sarama"
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-kafka/v3/pkg/kafka"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// group ids are used in ACLs and metrics, so they are kept to the characters allowed in topic names
+var kafkaInvalidGroupCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+type Kafka struct {
+	brokers   []string
+	publisher message.Publisher
+	logger    watermill.LoggerAdapter
+}
+
+func NewKafka(brokers []string, logger watermill.LoggerAdapter) (Kafka, error) {
+	if len(brokers) == 0 {
+		return Kafka{}, fmt.Errorf("missing Kafka brokers")
+	}
+
+	var publisher message.Publisher
+	publisher, err := kafka.NewPublisher(
+		kafka.PublisherConfig{
+			Brokers:   brokers,
+			Marshaler: kafka.DefaultMarshaler{},
+		}, logger,
+	)
+	if err != nil {
+		return Kafka{}, fmt.Errorf("could not create Kafka publisher: %w", err)
+	}
+	publisher = log.CorrelationPublisherDecorator{Publisher: publisher}
+	publisher = ticketsOutbox.TracePublisherDecorator{Publisher: publisher}
+
+	return Kafka{
+		brokers:   brokers,
+		publisher: publisher,
+		logger:    logger,
+	}, nil
+}
+
+func (k Kafka) Publisher() message.Publisher {
+	return k.publisher
+}
+
+func (k Kafka) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	saramaConfig := kafka.DefaultSaramaSubscriberConfig()
+	// new consumer groups handle messages published before the handler was added, like Redis consumer groups
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	return kafka.NewSubscriber(
+		kafka.SubscriberConfig{
+			Brokers:               k.brokers,
+			Unmarshaler:           kafka.DefaultMarshaler{},
+			OverwriteSaramaConfig: saramaConfig,
+			ConsumerGroup:         consumerGroup,
+		}, k.logger,
+	)
+}
+
+// FanOutSubscriber consumes all partitions without a consumer group, starting from the newest messages.
+func (k Kafka) FanOutSubscriber() (message.Subscriber, error) {
+	saramaConfig := kafka.DefaultSaramaSubscriberConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	return kafka.NewSubscriber(
+		kafka.SubscriberConfig{
+			Brokers:               k.brokers,
+			Unmarshaler:           kafka.DefaultMarshaler{},
+			OverwriteSaramaConfig: saramaConfig,
+		}, k.logger,
+	)
+}
+
+func (k Kafka) ConsumerGroup(prefix string, handlerName string) string {
+	return kafkaInvalidGroupCharacters.ReplaceAllString(prefix+handlerName, "_")
+}
+
+// PoisonQueue can't list or remove messages, Kafka topics are append-only.
+// Poisoned messages are still published to the poison queue topic.
+func (k Kafka) PoisonQueue() PoisonQueue {
+	return ticketsMessage.UnsupportedPoisonQueue{}
+}
@@ -0,0 +1,72 @@
+package broker
+
+import (
+	ticketsMessage "tickets/message"
+	ticketsOutbox "tickets/message/outbox"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+)
+
+// GoChannel keeps messages in memory, it's used to run the whole service in a single process.
+// Each subscriber receives all messages of the topic, which works like consumer groups as long as
+// there is one instance of the service. Messages published to topics without subscribers are dropped.
+type GoChannel struct {
+	pubSub      *gochannel.GoChannel
+	publisher   message.Publisher
+	poisonQueue *ticketsMessage.MemoryPoisonQueue
+}
+
+func NewGoChannel(logger watermill.LoggerAdapter) GoChannel {
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, logger)
+
+	var publisher message.Publisher = pubSub
+	publisher = log.CorrelationPublisherDecorator{Publisher: publisher}
+	publisher = ticketsOutbox.TracePublisherDecorator{Publisher: publisher}
+
+	// subscribed right away, so no poisoned message is dropped
+	poisonQueue, err := ticketsMessage.NewMemoryPoisonQueue(pubSub, publisher)
+	if err != nil {
+		panic(err)
+	}
+
+	return GoChannel{
+		pubSub:      pubSub,
+		publisher:   publisher,
+		poisonQueue: poisonQueue,
+	}
+}
+
+func (g GoChannel) Publisher() message.Publisher {
+	return g.publisher
+}
+
+// Subscriber ignores the consumer group, every subscriber receives all messages of the topic.
+// Subscribers sharing a consumer group would each handle the same message, but each handler of the service
+// has its own group, so messages are handled once per group, the same way as with the other brokers.
+func (g GoChannel) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return g.pubSub, nil
+}
+
+func (g GoChannel) FanOutSubscriber() (message.Subscriber, error) {
+	return sharedSubscriber{g.pubSub}, nil
+}
+
+func (g GoChannel) ConsumerGroup(prefix string, handlerName string) string {
+	return prefix + handlerName
+}
+
+func (g GoChannel) PoisonQueue() PoisonQueue {
+	return g.poisonQueue
+}
+
+// sharedSubscriber can be closed by its user without closing the GoChannel, which is shared with the publisher.
+type sharedSubscriber struct {
+	message.Subscriber
+}
+
+func (s sharedSubscriber) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package broker
+
+import (
+	ticketsMessage "tickets/message"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	rdb       redis.UniversalClient
+	publisher message.Publisher
+	logger    watermill.LoggerAdapter
+}
+
+func NewRedis(addr string, logger watermill.LoggerAdapter) Redis {
+	return NewRedisWithClient(ticketsMessage.NewRedisClient(addr), logger)
+}
+
+func NewRedisWithClient(rdb redis.UniversalClient, logger watermill.LoggerAdapter) Redis {
+	if rdb == nil {
+		panic("missing rdb")
+	}
+
+	return Redis{
+		rdb:       rdb,
+		publisher: ticketsMessage.NewRedisPublisher(rdb, logger),
+		logger:    logger,
+	}
+}
+
+func (r Redis) Publisher() message.Publisher {
+	return r.publisher
+}
+
+func (r Redis) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return redisstream.NewSubscriber(
+		redisstream.SubscriberConfig{
+			Client:        r.rdb,
+			ConsumerGroup: consumerGroup,
+		}, r.logger,
+	)
+}
+
+// FanOutSubscriber doesn't use a consumer group, so each subscriber receives all messages.
+func (r Redis) FanOutSubscriber() (message.Subscriber, error) {
+	return redisstream.NewSubscriber(
+		redisstream.SubscriberConfig{
+			Client: r.rdb,
+		}, r.logger,
+	)
+}
+
+func (r Redis) ConsumerGroup(prefix string, handlerName string) string {
+	return prefix + handlerName
+}
+
+func (r Redis) PoisonQueue() PoisonQueue {
+	return ticketsMessage.NewPoisonQueue(r.rdb, r.publisher)
+}
@@ -0,0 +1,197 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	ticketsMessage "tickets/message"
+	ticketsOutbox "tickets/message/outbox"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v3/pkg/sql"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+)
+
+// Postgres truncates longer identifiers, so topics with a common prefix could end up in the same table
+const postgresMaxIdentifierLength = 63
+
+var (
+	sqlSchema = watermillSQL.DefaultPostgreSQLSchema{
+		GenerateMessagesTableName: func(topic string) string {
+			return sqlTableName("watermill_", topic)
+		},
+	}
+	sqlOffsetsAdapter = watermillSQL.DefaultPostgreSQLOffsetsAdapter{
+		GenerateMessagesOffsetsTableName: func(topic string) string {
+			return sqlTableName("watermill_offsets_", topic)
+		},
+	}
+)
+
+// SQL uses Postgres tables as topics, so small deployments don't need another broker.
+type SQL struct {
+	db        *sqlx.DB
+	publisher message.Publisher
+	logger    watermill.LoggerAdapter
+}
+
+func NewSQL(db *sqlx.DB, logger watermill.LoggerAdapter) SQL {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	var publisher message.Publisher
+	publisher, err := watermillSQL.NewPublisher(
+		db,
+		watermillSQL.PublisherConfig{
+			SchemaAdapter:        sqlSchema,
+			AutoInitializeSchema: true,
+		}, logger,
+	)
+	if err != nil {
+		panic(err)
+	}
+	publisher = log.CorrelationPublisherDecorator{Publisher: publisher}
+	publisher = ticketsOutbox.TracePublisherDecorator{Publisher: publisher}
+
+	return SQL{
+		db:        db,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+func (s SQL) Publisher() message.Publisher {
+	return s.publisher
+}
+
+func (s SQL) Subscriber(consumerGroup string) (message.Subscriber, error) {
+	return s.newSubscriber(consumerGroup, sqlOffsetsAdapter)
+}
+
+// FanOutSubscriber uses a consumer group unique to this instance of the service.
+// The group starts from the latest message in the table, like the fan-out subscribers of other brokers,
+// and its offsets are removed when the subscriber is closed.
+func (s SQL) FanOutSubscriber() (message.Subscriber, error) {
+	consumerGroup := "fan_out_" + watermill.NewShortUUID()
+
+	subscriber, err := s.newSubscriber(consumerGroup, sqlFanOutOffsetsAdapter{sqlOffsetsAdapter})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlFanOutSubscriber{
+		Subscriber:    subscriber,
+		db:            s.db,
+		consumerGroup: consumerGroup,
+	}, nil
+}
+
+func (s SQL) newSubscriber(
+	consumerGroup string,
+	offsetsAdapter watermillSQL.OffsetsAdapter,
+) (*watermillSQL.Subscriber, error) {
+	return watermillSQL.NewSubscriber(
+		s.db,
+		watermillSQL.SubscriberConfig{
+			ConsumerGroup:    consumerGroup,
+			InitializeSchema: true,
+			SchemaAdapter:    sqlSchema,
+			OffsetsAdapter:   offsetsAdapter,
+		}, s.logger,
+	)
+}
+
+func (s SQL) ConsumerGroup(prefix string, handlerName string) string {
+	return prefix + handlerName
+}
+
+func (s SQL) PoisonQueue() PoisonQueue {
+	return ticketsMessage.NewSQLPoisonQueue(s.db, s.publisher, sqlSchema.MessagesTable(ticketsMessage.PoisonQueueTopic))
+}
+
+// sqlFanOutOffsetsAdapter starts new consumer groups after the latest message visible to the subscribers.
+type sqlFanOutOffsetsAdapter struct {
+	watermillSQL.DefaultPostgreSQLOffsetsAdapter
+}
+
+func (a sqlFanOutOffsetsAdapter) BeforeSubscribingQueries(topic string, consumerGroup string) []watermillSQL.Query {
+	return []watermillSQL.Query{
+		{
+			Query: `
+				INSERT INTO ` + a.MessagesOffsetsTable(topic) + ` (consumer_group, offset_acked, last_processed_transaction_id)
+				SELECT $1, COALESCE(latest."offset", 0), COALESCE(latest.transaction_id, '0')
+				FROM (VALUES (1)) AS start
+				LEFT JOIN LATERAL (
+					-- messages of transactions still in progress are received, the same way subscribers select them
+					SELECT "offset", transaction_id FROM ` + sqlSchema.MessagesTable(topic) + `
+					WHERE transaction_id < pg_snapshot_xmin(pg_current_snapshot())
+					ORDER BY transaction_id DESC, "offset" DESC
+					LIMIT 1
+				) AS latest ON true
+				ON CONFLICT DO NOTHING`,
+			Args: []any{consumerGroup},
+		},
+	}
+}
+
+// sqlFanOutSubscriber removes offsets of its consumer group when closed, the group is not used again.
+type sqlFanOutSubscriber struct {
+	*watermillSQL.Subscriber
+
+	db            *sqlx.DB
+	consumerGroup string
+
+	lock   sync.Mutex
+	topics []string
+}
+
+func (s *sqlFanOutSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	messages, err := s.Subscriber.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	s.topics = append(s.topics, topic)
+	s.lock.Unlock()
+
+	return messages, nil
+}
+
+func (s *sqlFanOutSubscriber) Close() error {
+	err := s.Subscriber.Close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, topic := range s.topics {
+		_, deleteErr := s.db.Exec(
+			`DELETE FROM `+sqlOffsetsAdapter.MessagesOffsetsTable(topic)+` WHERE consumer_group = $1`,
+			s.consumerGroup,
+		)
+		if deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("could not remove offsets of %s: %w", s.consumerGroup, deleteErr))
+		}
+	}
+	s.topics = nil
+
+	return err
+}
+
+func sqlTableName(prefix string, topic string) string {
+	name := prefix + topic
+	// quotes are not counted in the identifier length
+	if len(name) > postgresMaxIdentifierLength {
+		hash := sha1.Sum([]byte(topic))
+		suffix := "_" + hex.EncodeToString(hash[:])[:8]
+		name = name[:postgresMaxIdentifierLength-len(suffix)] + suffix
+	}
+
+	return `"` + name + `"`
+}
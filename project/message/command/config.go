@@ -1,11 +1,11 @@
 package command
 
 import (
+	ticketsEntity "tickets/entities"
+
 	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/redis/go-redis/v9"
 )
 
 var (
@@ -14,21 +14,41 @@ var (
 	}
 )
 
+// Subscribers creates subscribers of the message broker.
+type Subscribers interface {
+	Subscriber(consumerGroup string) (message.Subscriber, error)
+	ConsumerGroup(prefix string, handlerName string) string
+}
+
 func NewCommandProcessorConfig(
-	rdb redis.UniversalClient,
+	subscribers Subscribers,
 	logger watermill.LoggerAdapter,
+	consumerGroupPrefix string,
 ) *cqrs.CommandProcessorConfig {
+	if subscribers == nil {
+		panic("missing subscribers")
+	}
+	if consumerGroupPrefix == "" {
+		panic("missing consumerGroupPrefix")
+	}
+
 	return &cqrs.CommandProcessorConfig{
 		GenerateSubscribeTopic: func(params cqrs.CommandProcessorGenerateSubscribeTopicParams) (string, error) {
 			return "commands." + params.CommandName, nil
 		},
-		Marshaler: jsonMarshaler,
+		Marshaler: permanentUnmarshalErrorsMarshaler{jsonMarshaler},
 		Logger:    logger,
 		SubscriberConstructor: func(params cqrs.CommandProcessorSubscriberConstructorParams) (message.Subscriber, error) {
-			return redisstream.NewSubscriber(redisstream.SubscriberConfig{
-				Client:        rdb,
-				ConsumerGroup: "svc-tickets.commands." + params.HandlerName,
-			}, logger)
+			return subscribers.Subscriber(subscribers.ConsumerGroup(consumerGroupPrefix, params.HandlerName))
 		},
 	}
 }
+
+// permanentUnmarshalErrorsMarshaler marks unmarshal errors as permanent, malformed commands won't unmarshal on retry either.
+type permanentUnmarshalErrorsMarshaler struct {
+	cqrs.CommandEventMarshaler
+}
+
+func (m permanentUnmarshalErrorsMarshaler) Unmarshal(msg *message.Message, v any) error {
+	return ticketsEntity.NewPermanentError(m.CommandEventMarshaler.Unmarshal(msg, v))
+}
@@ -2,9 +2,7 @@ package command
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	ticketsDB "tickets/db"
 	ticketsEntity "tickets/entities"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
@@ -25,22 +23,24 @@ func (h Handler) BookFlight(
 	}
 
 	bookFlightResponse, err := h.bookFlightService.BookFlight(ctx, bookFlightRequest)
-	if err != nil {
-		if errors.As(err, &ticketsDB.ErrNoPlacesLeft) {
-			errPub := h.eventBus.Publish(
-				ctx, ticketsEntity.FlightBookingFailed_v1{
-					Header:        ticketsEntity.NewMessageHeader(),
-					FlightID:      command.FlightID,
-					ReferenceID:   command.ReferenceID,
-					FailureReason: "Out of flight tickets",
-				},
-			)
-			if errPub != nil {
-				logger.Warn("Failed to publish booking event")
-				return errPub
-			}
-			return err
+	if ticketsEntity.IsPermanentError(err) {
+		// the flight can't be booked, transient errors are retried instead
+		errPub := h.eventBus.Publish(
+			ctx, ticketsEntity.FlightBookingFailed_v1{
+				Header:        ticketsEntity.NewMessageHeader(),
+				FlightID:      command.FlightID,
+				ReferenceID:   command.ReferenceID,
+				FailureReason: err.Error(),
+			},
+		)
+		if errPub != nil {
+			logger.Warn("Failed to publish booking event")
+			return errPub
 		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to book flight: %w", err)
 	}
 	err = h.eventBus.Publish(
 		ctx, ticketsEntity.FlightBooked_v1{
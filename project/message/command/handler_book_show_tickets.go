@@ -24,19 +24,19 @@ func (h Handler) BookShowTickets(
 
 	err := h.bookingRepository.AddBooking(ctx, booking)
 	if err != nil {
-		if errors.As(err, &ticketsDB.ErrNoPlacesLeft) {
+		if errors.Is(err, ticketsDB.ErrNoPlacesLeft) {
 			errPub := h.eventBus.Publish(
 				ctx, ticketsEntity.BookingFailed_v1{
 					Header:        ticketsEntity.NewMessageHeader(),
 					BookingID:     command.BookingID,
-					FailureReason: "Out of tickets",
+					FailureReason: err.Error(),
 				},
 			)
 			if errPub != nil {
 				logger.Warn("Failed to publish booking event")
 				return errPub
 			}
-			return err
+			return nil
 		}
 		if errors.Is(err, ticketsDB.ErrBookingAlreadyExists) {
 			// now AddBooking is called via Pub/Sub, we are taking into account at-least-once delivery
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"fmt"
 	ticketsEntity "tickets/entities"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
@@ -24,19 +25,23 @@ func (h Handler) BookTaxi(
 		},
 	)
 
-	if err != nil {
+	if ticketsEntity.IsPermanentError(err) {
+		// the taxi can't be booked, transient errors are retried instead
 		errPub := h.eventBus.Publish(
 			ctx, ticketsEntity.TaxiBookingFailed_v1{
 				Header:        ticketsEntity.NewMessageHeader(),
 				ReferenceID:   command.ReferenceID,
-				FailureReason: "Out of tickets",
+				FailureReason: err.Error(),
 			},
 		)
 		if errPub != nil {
 			logger.Warn("Failed to publish taxi booking failed event")
 			return errPub
 		}
-		return err
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to book taxi: %w", err)
 	}
 
 	err = h.eventBus.Publish(
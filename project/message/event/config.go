@@ -3,12 +3,11 @@ package event
 import (
 	"fmt"
 	"tickets/entities"
+	"tickets/upcasting"
 
 	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/redis/go-redis/v9"
 )
 
 var (
@@ -17,10 +16,28 @@ var (
 	}
 )
 
+// Subscribers creates subscribers of the message broker.
+type Subscribers interface {
+	Subscriber(consumerGroup string) (message.Subscriber, error)
+	ConsumerGroup(prefix string, handlerName string) string
+}
+
 func NewEventProcessorConfig(
-	rdb redis.UniversalClient,
+	subscribers Subscribers,
 	logger watermill.LoggerAdapter,
+	upcasters *upcasting.Registry,
+	consumerGroupPrefix string,
 ) *cqrs.EventProcessorConfig {
+	if subscribers == nil {
+		panic("missing subscribers")
+	}
+	if upcasters == nil {
+		panic("missing upcasters")
+	}
+	if consumerGroupPrefix == "" {
+		panic("missing consumerGroupPrefix")
+	}
+
 	return &cqrs.EventProcessorConfig{
 		GenerateSubscribeTopic: func(params cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
 			handlerEvent := params.EventHandler.NewEvent()
@@ -38,15 +55,13 @@ func NewEventProcessorConfig(
 
 			return prefix + params.EventName, nil
 		},
-		Marshaler: jsonMarshaler,
-		Logger:    logger,
+		Marshaler: upcastingMarshaler{
+			CommandEventMarshaler: jsonMarshaler,
+			registry:              upcasters,
+		},
+		Logger: logger,
 		SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
-			return redisstream.NewSubscriber(
-				redisstream.SubscriberConfig{
-					Client:        rdb,
-					ConsumerGroup: "svc-tickets.events." + params.HandlerName,
-				}, logger,
-			)
+			return subscribers.Subscriber(subscribers.ConsumerGroup(consumerGroupPrefix, params.HandlerName))
 		},
 	}
 }
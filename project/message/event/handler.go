@@ -3,6 +3,7 @@ package event
 import (
 	"context"
 	ticketsEntity "tickets/entities"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 )
@@ -29,6 +30,11 @@ type DeadNationService interface {
 type TicketsRepository interface {
 	Add(ctx context.Context, ticket ticketsEntity.Ticket) error
 	Remove(ctx context.Context, ticket ticketsEntity.Ticket) error
+	FindByBookingID(ctx context.Context, bookingID string) ([]ticketsEntity.Ticket, error)
+}
+
+type BookingsRepository interface {
+	BookingCanceledAt(ctx context.Context, bookingID string) (*time.Time, error)
 }
 
 type ShowsRepository interface {
@@ -40,15 +46,21 @@ type EventsRepository interface {
 	SaveEvents(ctx context.Context, event ticketsEntity.ExternalEvent, eventName string, payload []byte) error
 }
 
+type CommandBus interface {
+	Send(ctx context.Context, command any) error
+}
+
 type Handler struct {
 	spreadsheetsAPI   SpreadsheetsAPI
 	receiptsService   ReceiptsService
 	fileService       FilesService
 	deadNationService DeadNationService
 	ticketRepository  TicketsRepository
+	bookingRepository BookingsRepository
 	showRepository    ShowsRepository
 	eventRepository   EventsRepository
 	eventBus          *cqrs.EventBus
+	commandBus        CommandBus
 }
 
 func NewEventHandler(
@@ -57,9 +69,11 @@ func NewEventHandler(
 	fileService FilesService,
 	deadNationService DeadNationService,
 	ticketRepository TicketsRepository,
+	bookingRepository BookingsRepository,
 	showRepository ShowsRepository,
 	eventRepository EventsRepository,
 	eventBus *cqrs.EventBus,
+	commandBus CommandBus,
 ) *Handler {
 	if spreadsheetsAPI == nil {
 		panic("missing spreadsheetsAPI")
@@ -76,6 +90,9 @@ func NewEventHandler(
 	if ticketRepository == nil {
 		panic("missing ticketRepository")
 	}
+	if bookingRepository == nil {
+		panic("missing bookingRepository")
+	}
 	if showRepository == nil {
 		panic("missing showRepository")
 	}
@@ -85,14 +102,19 @@ func NewEventHandler(
 	if eventBus == nil {
 		panic("missing eventBus")
 	}
+	if commandBus == nil {
+		panic("missing commandBus")
+	}
 	return &Handler{
 		spreadsheetsAPI:   spreadsheetsAPI,
 		receiptsService:   receiptsService,
 		fileService:       fileService,
 		deadNationService: deadNationService,
 		ticketRepository:  ticketRepository,
+		bookingRepository: bookingRepository,
 		showRepository:    showRepository,
 		eventRepository:   eventRepository,
 		eventBus:          eventBus,
+		commandBus:        commandBus,
 	}
 }
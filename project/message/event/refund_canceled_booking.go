@@ -0,0 +1,41 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	ticketsEntity "tickets/entities"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+)
+
+func (h Handler) RefundCanceledBooking(
+	ctx context.Context,
+	event *ticketsEntity.BookingCanceled_v1,
+) error {
+	logger := log.FromContext(ctx)
+	logger.With("booking_id", event.BookingID).Info("Refunding tickets of canceled booking")
+
+	tickets, err := h.ticketRepository.FindByBookingID(ctx, event.BookingID)
+	if err != nil {
+		return err
+	}
+
+	for _, ticket := range tickets {
+		if err := h.refundTicketOfCanceledBooking(ctx, event.BookingID, ticket.TicketID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refundTicketOfCanceledBooking is called both when the booking is canceled and when a ticket is confirmed
+// after the cancellation, the ticket is refunded once even if both find it.
+func (h Handler) refundTicketOfCanceledBooking(ctx context.Context, bookingID string, ticketID string) error {
+	err := h.commandBus.Send(ctx, ticketsEntity.NewCanceledBookingRefund(bookingID, ticketID))
+	if err != nil {
+		return fmt.Errorf("failed to send RefundTicket for ticket %s: %w", ticketID, err)
+	}
+
+	return nil
+}
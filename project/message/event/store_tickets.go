@@ -5,6 +5,7 @@ import (
 	ticketsEntity "tickets/entities"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
 )
 
 func (h Handler) StoreTickets(
@@ -16,7 +17,8 @@ func (h Handler) StoreTickets(
 	err := h.ticketRepository.Add(
 		ctx,
 		ticketsEntity.Ticket{
-			TicketID: event.TicketID,
+			TicketID:  event.TicketID,
+			BookingID: event.BookingID,
 			Price: ticketsEntity.Money{
 				Amount:   event.Price.Amount,
 				Currency: event.Price.Currency,
@@ -24,8 +26,26 @@ func (h Handler) StoreTickets(
 			CustomerEmail: event.CustomerEmail,
 		},
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Dead Nation may confirm tickets after the booking was canceled. The ticket is stored before the check,
+	// so it's refunded either here or by RefundCanceledBooking, whichever runs later.
+	if _, err := uuid.Parse(event.BookingID); err != nil {
+		return nil
+	}
+	canceledAt, err := h.bookingRepository.BookingCanceledAt(ctx, event.BookingID)
+	if err != nil {
+		return err
+	}
+	if canceledAt == nil {
+		return nil
+	}
+
+	logger.With("booking_id", event.BookingID).Info("Refunding ticket confirmed after the booking was canceled")
+
+	return h.refundTicketOfCanceledBooking(ctx, event.BookingID, event.TicketID)
 }
 
 func (h Handler) RemoveCanceledTicket(
@@ -0,0 +1,51 @@
+package event
+
+import (
+	"fmt"
+	"tickets/entities"
+	"tickets/upcasting"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// upcastingMarshaler upcasts old versions of events before they are unmarshaled,
+// so event handlers only receive the latest version of the event.
+type upcastingMarshaler struct {
+	cqrs.CommandEventMarshaler
+	registry *upcasting.Registry
+}
+
+func (m upcastingMarshaler) Unmarshal(msg *message.Message, v any) error {
+	eventName := m.CommandEventMarshaler.NameFromMessage(msg)
+
+	// malformed events won't unmarshal on retry either
+	latestName, payload, err := m.registry.Upcast(eventName, msg.Payload)
+	if err != nil {
+		return entities.NewPermanentError(err)
+	}
+	if latestName == eventName {
+		return entities.NewPermanentError(m.CommandEventMarshaler.Unmarshal(msg, v))
+	}
+
+	upcasted := msg.Copy()
+	upcasted.Payload = payload
+	upcasted.Metadata.Set("name", latestName)
+
+	if err := m.CommandEventMarshaler.Unmarshal(upcasted, v); err != nil {
+		return entities.NewPermanentError(
+			fmt.Errorf("could not unmarshal %s upcasted from %s: %w", latestName, eventName, err),
+		)
+	}
+
+	return nil
+}
+
+func (m upcastingMarshaler) NameFromMessage(msg *message.Message) string {
+	return m.registry.LatestName(m.CommandEventMarshaler.NameFromMessage(msg))
+}
+
+// OriginalNameFromMessage returns the event name the message was published with, before upcasting.
+func OriginalNameFromMessage(msg *message.Message) string {
+	return jsonMarshaler.NameFromMessage(msg)
+}
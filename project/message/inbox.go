@@ -0,0 +1,94 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	ticketsDB "tickets/db"
+	ticketsEntity "tickets/entities"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+type Inbox interface {
+	IsProcessed(ctx context.Context, handlerName string, messageID string) (bool, error)
+	MarkProcessed(ctx context.Context, handlerName string, messageID string) error
+	Unmark(ctx context.Context, handlerName string, messageID string) error
+}
+
+// InboxMiddleware skips messages already processed by the handler, deduplicating on MessageHeader.ID.
+//
+// If the handler writes to the database, the message is recorded as processed in the handler's first transaction.
+// All writes of handlers to Postgres are done in transactions, so storing tickets, events, bookings,
+// the ops read model and VIP bundles is atomic with the record. Handlers that don't write to the database
+// (like the ones calling external APIs) have the message recorded after they succeed.
+//
+// When the handler fails after its first transaction was committed (e.g. publishing an event
+// or canceling a booking of a VIP bundle), the record is removed, so the message is processed again
+// when redelivered. What was committed before is done again, so it must be idempotent.
+// Messages without a header (like forwarded outbox envelopes) are not deduplicated.
+func InboxMiddleware(inbox Inbox) message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			messageID := messageHeaderID(msg)
+			if messageID == "" {
+				return h(msg)
+			}
+
+			ctx := msg.Context()
+			handlerName := message.HandlerNameFromCtx(ctx)
+			logger := log.FromContext(ctx).With("handler", handlerName, "message_header_id", messageID)
+
+			processed, err := inbox.IsProcessed(ctx, handlerName, messageID)
+			if err != nil {
+				return nil, err
+			}
+			if processed {
+				logger.Info("Skipping already processed message")
+				return nil, nil
+			}
+
+			inboxMsg := &ticketsDB.InboxMessage{
+				HandlerName: handlerName,
+				MessageID:   messageID,
+			}
+			msg.SetContext(ticketsDB.ContextWithInboxMessage(ctx, inboxMsg))
+
+			msgs, err := h(msg)
+			if errors.Is(err, ticketsDB.ErrMessageAlreadyProcessed) {
+				// processed concurrently, e.g. by another instance after a redelivery
+				logger.Info("Skipping already processed message")
+				return nil, nil
+			}
+			if err != nil {
+				if inboxMsg.Recorded() {
+					if unmarkErr := inbox.Unmark(context.WithoutCancel(ctx), handlerName, messageID); unmarkErr != nil {
+						return nil, errors.Join(err, unmarkErr)
+					}
+				}
+				return nil, err
+			}
+
+			if !inboxMsg.Recorded() {
+				if err := inbox.MarkProcessed(ctx, handlerName, messageID); err != nil {
+					return nil, fmt.Errorf("could not record processed message: %w", err)
+				}
+			}
+
+			return msgs, nil
+		}
+	}
+}
+
+func messageHeaderID(msg *message.Message) string {
+	var payload struct {
+		Header *ticketsEntity.MessageHeader `json:"header"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.Header == nil {
+		return ""
+	}
+
+	return payload.Header.ID
+}
@@ -0,0 +1,90 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	ticketsDB "tickets/db"
+	ticketsMessage "tickets/message"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInboxMiddleware(t *testing.T) {
+	inbox := newInboxStub()
+	calls := 0
+	handlerErr := error(nil)
+
+	handler := ticketsMessage.InboxMiddleware(inbox)(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, handlerErr
+	})
+
+	newMsg := func() *message.Message {
+		return message.NewMessage("watermill-uuid", []byte(`{"header":{"id":"header-id"}}`))
+	}
+
+	handlerErr = errors.New("spreadsheet unavailable")
+	_, err := handler(newMsg())
+	require.Error(t, err)
+	assert.Empty(t, inbox.processed, "failed handling must not be recorded")
+
+	handlerErr = nil
+	_, err = handler(newMsg())
+	require.NoError(t, err)
+	_, err = handler(newMsg())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, calls, "redelivered message should be skipped")
+	// handler name is set by the router, it's empty when the middleware is called directly
+	assert.Contains(t, inbox.processed, "/header-id")
+
+	handlerErr = fmt.Errorf("could not store ticket: %w", ticketsDB.ErrMessageAlreadyProcessed)
+	inbox.processed = map[string]struct{}{}
+	_, err = handler(newMsg())
+	assert.NoError(t, err, "message processed concurrently should be acked")
+}
+
+func TestInboxMiddleware_message_without_header(t *testing.T) {
+	inbox := newInboxStub()
+	calls := 0
+
+	handler := ticketsMessage.InboxMiddleware(inbox)(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, nil
+	})
+
+	for range 2 {
+		_, err := handler(message.NewMessage("watermill-uuid", []byte(`{"destination_topic":"events"}`)))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, inbox.processed)
+}
+
+type inboxStub struct {
+	processed map[string]struct{}
+}
+
+func newInboxStub() *inboxStub {
+	return &inboxStub{processed: map[string]struct{}{}}
+}
+
+func (i *inboxStub) IsProcessed(_ context.Context, handlerName string, messageID string) (bool, error) {
+	_, ok := i.processed[handlerName+"/"+messageID]
+	return ok, nil
+}
+
+func (i *inboxStub) MarkProcessed(_ context.Context, handlerName string, messageID string) error {
+	i.processed[handlerName+"/"+messageID] = struct{}{}
+	return nil
+}
+
+func (i *inboxStub) Unmark(_ context.Context, handlerName string, messageID string) error {
+	delete(i.processed, handlerName+"/"+messageID)
+	return nil
+}
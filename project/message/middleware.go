@@ -3,12 +3,13 @@ package message
 import (
 	"fmt"
 	"log/slog"
+	"runtime/debug"
+	ticketsEntity "tickets/entities"
 	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
-	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
 	"github.com/lithammer/shortuuid/v3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -38,6 +39,24 @@ var (
 		[]string{"topic", "handler"},
 	)
 
+	messagesProcessingErrorsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "messages",
+			Name:      "processing_errors_total",
+			Help:      "The total number of failed message handling attempts, by error class",
+		},
+		[]string{"topic", "handler", "class"},
+	)
+
+	messagesPanicsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "messages",
+			Name:      "handler_panics_total",
+			Help:      "The total number of panics recovered in message handlers",
+		},
+		[]string{"topic", "handler"},
+	)
+
 	messagesProcessingTotalCounter = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "messages",
@@ -58,6 +77,11 @@ func MetricsMiddleware() func(h message.HandlerFunc) message.HandlerFunc {
 			messagesProcessingTotalCounter.With(prometheus.Labels{"topic": topic, "handler": handler}).Inc()
 			if err != nil {
 				messagesProcessingFailedCounter.With(prometheus.Labels{"topic": topic, "handler": handler}).Inc()
+				messagesProcessingErrorsCounter.With(prometheus.Labels{
+					"topic":   topic,
+					"handler": handler,
+					"class":   errorClass(err),
+				}).Inc()
 			}
 			return msgs, err
 		}
@@ -73,7 +97,7 @@ func LoggingMiddleware() func(h message.HandlerFunc) message.HandlerFunc {
 				"metadata", msg.Metadata,
 				"handler", message.HandlerNameFromCtx(msg.Context()),
 			)
-			logger.Info("Handling a message, message_id=", msg.UUID)
+			logger.With("message_id", msg.UUID).Info("Handling a message")
 
 			msgs, err := next(msg)
 			if err != nil {
@@ -137,22 +161,62 @@ func DistributedTracingMiddleware() func(h message.HandlerFunc) message.HandlerF
 	}
 }
 
-func RetryMiddleware(watermillLogger watermill.LoggerAdapter) func(h message.HandlerFunc) message.HandlerFunc {
-	retry := middleware.Retry{
-		MaxRetries:      10,
-		InitialInterval: time.Millisecond * 100,
-		MaxInterval:     time.Second,
-		Multiplier:      2,
-		Logger:          watermillLogger,
-	}
+// RecovererMiddleware turns panics in handlers into permanent errors,
+// handling the same message again would panic again.
+func RecovererMiddleware() func(h message.HandlerFunc) message.HandlerFunc {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) (msgs []*message.Message, err error) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				topic := message.SubscribeTopicFromCtx(msg.Context())
+				handler := message.HandlerNameFromCtx(msg.Context())
+				stack := string(debug.Stack())
+
+				messagesPanicsCounter.With(prometheus.Labels{"topic": topic, "handler": handler}).Inc()
+
+				log.FromContext(msg.Context()).With(
+					"panic", recovered,
+					"stacktrace", stack,
+					"message_id", msg.UUID,
+					"handler", handler,
+				).Error("Panic while handling a message")
 
-	return retry.Middleware
+				span := trace.SpanFromContext(msg.Context())
+				span.AddEvent("panic", trace.WithAttributes(
+					attribute.String("panic", fmt.Sprint(recovered)),
+					attribute.String("stacktrace", stack),
+				))
+
+				msgs = nil
+				err = ticketsEntity.NewPermanentError(fmt.Errorf("panic in handler %s: %v", handler, recovered))
+			}()
+
+			return h(msg)
+		}
+	}
 }
 
-func AddMiddleWare(router *message.Router, watermillLogger watermill.LoggerAdapter) {
+func AddMiddleWare(
+	router *message.Router,
+	publisher message.Publisher,
+	inbox Inbox,
+	retryPolicies RetryPolicies,
+	watermillLogger watermill.LoggerAdapter,
+) {
 	router.AddMiddleware(CorrelationIdMiddleware())
 	router.AddMiddleware(LoggingMiddleware())
-	router.AddMiddleware(RetryMiddleware(watermillLogger))
+	router.AddMiddleware(RequeuedMessagesMiddleware())
+	// poison queue has to wrap the retry middleware, so only messages that exhausted all retries are moved there
+	router.AddMiddleware(PoisonQueueMiddleware(publisher))
+	router.AddMiddleware(RetryMiddleware(retryPolicies, watermillLogger))
 	router.AddMiddleware(MetricsMiddleware())
 	router.AddMiddleware(DistributedTracingMiddleware())
+	// inside the retry middleware, so each retry checks if the message was processed in the meantime
+	router.AddMiddleware(InboxMiddleware(inbox))
+	// innermost, so the middlewares above handle panics like any other error
+	router.AddMiddleware(RecovererMiddleware())
 }
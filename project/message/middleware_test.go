@@ -0,0 +1,28 @@
+package message_test
+
+import (
+	"testing"
+	ticketsEntity "tickets/entities"
+	ticketsMessage "tickets/message"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecovererMiddleware(t *testing.T) {
+	handler := ticketsMessage.RecovererMiddleware()(func(msg *message.Message) ([]*message.Message, error) {
+		ticketsMessage.MustParseBundleID("not-an-uuid")
+		return nil, nil
+	})
+
+	var err error
+	require.NotPanics(t, func() {
+		_, err = handler(message.NewMessage(watermill.NewUUID(), nil))
+	})
+
+	require.Error(t, err)
+	assert.True(t, ticketsEntity.IsPermanentError(err), "panicking message should not be retried")
+	assert.Contains(t, err.Error(), "failed to parse VipBundleID")
+}
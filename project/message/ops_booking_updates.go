@@ -0,0 +1,123 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	ticketsEntity "tickets/entities"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/google/uuid"
+)
+
+// slow listeners miss updates instead of blocking the others
+const opsBookingUpdatesBufferSize = 64
+
+var opsReadModelUpdatedTopic = "internal-events.svc-tickets." + cqrs.StructName(&ticketsEntity.InternalOpsReadModelUpdated{})
+
+// OpsBookingUpdates broadcasts IDs of updated ops bookings to listeners of this instance (e.g. SSE connections).
+type OpsBookingUpdates struct {
+	subscriber message.Subscriber
+
+	lock      sync.Mutex
+	listeners map[chan uuid.UUID]struct{}
+	closed    bool
+}
+
+// NewOpsBookingUpdates expects a fan-out subscriber,
+// so each instance of the service receives all updates.
+func NewOpsBookingUpdates(subscriber message.Subscriber) *OpsBookingUpdates {
+	if subscriber == nil {
+		panic("missing subscriber")
+	}
+
+	return &OpsBookingUpdates{
+		subscriber: subscriber,
+		listeners:  map[chan uuid.UUID]struct{}{},
+	}
+}
+
+// Run closes the subscriber when it returns, so the broker can clean up what it keeps for this instance.
+func (u *OpsBookingUpdates) Run(ctx context.Context) (err error) {
+	defer u.close()
+	defer func() {
+		if closeErr := u.subscriber.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("could not close subscriber: %w", closeErr))
+		}
+	}()
+
+	messages, err := u.subscriber.Subscribe(ctx, opsReadModelUpdatedTopic)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to %s: %w", opsReadModelUpdatedTopic, err)
+	}
+
+	for msg := range messages {
+		var event ticketsEntity.InternalOpsReadModelUpdated
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			log.FromContext(ctx).With("error", err, "message_uuid", msg.UUID).Error("Invalid ops read model update")
+			msg.Ack()
+			continue
+		}
+
+		u.broadcast(event.BookingID)
+		msg.Ack()
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel of updated booking IDs.
+// The channel is closed when ctx is done or when the service is stopping.
+func (u *OpsBookingUpdates) Subscribe(ctx context.Context) <-chan uuid.UUID {
+	listener := make(chan uuid.UUID, opsBookingUpdatesBufferSize)
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.closed {
+		close(listener)
+		return listener
+	}
+	u.listeners[listener] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+
+		u.lock.Lock()
+		defer u.lock.Unlock()
+
+		if _, ok := u.listeners[listener]; ok {
+			delete(u.listeners, listener)
+			close(listener)
+		}
+	}()
+
+	return listener
+}
+
+func (u *OpsBookingUpdates) broadcast(bookingID uuid.UUID) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	for listener := range u.listeners {
+		select {
+		case listener <- bookingID:
+		default:
+		}
+	}
+}
+
+func (u *OpsBookingUpdates) close() {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.closed = true
+	for listener := range u.listeners {
+		delete(u.listeners, listener)
+		close(listener)
+	}
+}
@@ -0,0 +1,112 @@
+package message_test
+
+import (
+	"context"
+	"testing"
+	ticketsMemory "tickets/db/memory"
+	ticketsEntity "tickets/entities"
+	ticketsMessage "tickets/message"
+	ticketsEvent "tickets/message/event"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpsBookingUpdates_ticket_updates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// persistent, so updates published before Run subscribes are not lost
+	pubSub := gochannel.NewGoChannel(gochannel.Config{Persistent: true}, watermill.NopLogger{})
+	t.Cleanup(func() {
+		_ = pubSub.Close()
+	})
+
+	readModel := ticketsMemory.NewOpsBookingReadModel(
+		ticketsMemory.NewDB(),
+		ticketsEvent.NewEventBus(pubSub, watermill.NopLogger{}),
+	)
+
+	updates := ticketsMessage.NewOpsBookingUpdates(pubSub)
+	listener := updates.Subscribe(ctx)
+	go func() {
+		_ = updates.Run(ctx)
+	}()
+
+	bookingID := uuid.New()
+	ticketID := uuid.NewString()
+
+	require.NoError(t, readModel.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: bookingID.String(),
+	}))
+	requireUpdate(t, listener, bookingID)
+
+	require.NoError(t, readModel.OnTicketBookingConfirmed(ctx, &ticketsEntity.TicketBookingConfirmed_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: bookingID.String(),
+		TicketID:  ticketID,
+	}))
+	requireUpdate(t, listener, bookingID)
+
+	require.NoError(t, readModel.OnTicketPrinted(ctx, &ticketsEntity.TicketPrinted_v1{
+		Header:   ticketsEntity.NewMessageHeader(),
+		TicketID: ticketID,
+		FileName: ticketID + "-ticket.html",
+	}))
+	requireUpdate(t, listener, bookingID)
+
+	require.NoError(t, readModel.OnTicketRefunded(ctx, &ticketsEntity.TicketRefunded_v1{
+		Header:   ticketsEntity.NewMessageHeader(),
+		TicketID: ticketID,
+	}))
+	requireUpdate(t, listener, bookingID)
+}
+
+func TestOpsBookingUpdates_closes_subscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	subscriber := &closeSpySubscriber{Subscriber: pubSub}
+
+	updates := ticketsMessage.NewOpsBookingUpdates(subscriber)
+	listener := updates.Subscribe(context.Background())
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- updates.Run(ctx)
+	}()
+
+	cancel()
+	require.NoError(t, <-stopped)
+	assert.True(t, subscriber.closed, "fan-out subscriber should be closed when updates stop")
+
+	_, ok := <-listener
+	assert.False(t, ok, "listeners should be closed when updates stop")
+}
+
+type closeSpySubscriber struct {
+	message.Subscriber
+	closed bool
+}
+
+func (s *closeSpySubscriber) Close() error {
+	s.closed = true
+	return s.Subscriber.Close()
+}
+
+func requireUpdate(t *testing.T, listener <-chan uuid.UUID, bookingID uuid.UUID) {
+	t.Helper()
+
+	select {
+	case updated := <-listener:
+		require.Equal(t, bookingID, updated)
+	case <-time.After(time.Second):
+		t.Fatal("booking update not received")
+	}
+}
@@ -29,7 +29,10 @@ func AddForwarderHandler(
 							"metadata", msg.Metadata,
 						).Info("Forwarding message")
 
-						return h(msg)
+						msgs, err := h(msg)
+						recordForwardResult(err)
+
+						return msgs, err
 					}
 				},
 			},
@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v3/pkg/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	DefaultRetention = 7 * 24 * time.Hour
+
+	janitorInterval   = 10 * time.Minute
+	janitorBatchSize  = 500
+	janitorBatchPause = 100 * time.Millisecond
+)
+
+var (
+	janitorDeletedRowsCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "outbox",
+			Name:      "janitor_deleted_rows_total",
+			Help:      "The total number of forwarded messages deleted from the outbox",
+		},
+	)
+	janitorFailuresCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "outbox",
+			Name:      "janitor_failures_total",
+			Help:      "The total number of failed outbox cleanups",
+		},
+	)
+)
+
+// Janitor deletes messages that were already forwarded and are older than the retention.
+type Janitor struct {
+	db        *sqlx.DB
+	retention time.Duration
+}
+
+func NewJanitor(db *sqlx.DB, retention time.Duration) Janitor {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return Janitor{db: db, retention: retention}
+}
+
+// Run cleans up the outbox periodically until ctx is done. Zero retention disables the cleanup.
+func (j Janitor) Run(ctx context.Context) error {
+	if j.retention <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := j.Cleanup(ctx)
+		if err != nil && ctx.Err() == nil {
+			janitorFailuresCounter.Inc()
+			log.FromContext(ctx).With("error", err).Error("Could not clean up outbox")
+		} else if deleted > 0 {
+			log.FromContext(ctx).With("deleted", deleted).Info("Outbox cleaned up")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup deletes old forwarded messages in batches, so the outbox table is never locked for long.
+func (j Janitor) Cleanup(ctx context.Context) (int64, error) {
+	var total int64
+
+	for {
+		deleted, err := j.deleteBatch(ctx)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < janitorBatchSize {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(janitorBatchPause):
+		}
+	}
+}
+
+func (j Janitor) deleteBatch(ctx context.Context) (int64, error) {
+	messagesTable := watermillSQL.DefaultPostgreSQLSchema{}.MessagesTable(outboxTopic)
+
+	// only messages at or before the offset acked by the forwarder (subscribing without a consumer group) are deleted
+	res, err := j.db.ExecContext(ctx, fmt.Sprintf(`
+		WITH last_processed AS (
+			SELECT offset_acked, last_processed_transaction_id
+			FROM %s
+			WHERE consumer_group = ''
+		)
+		DELETE FROM %s
+		WHERE (transaction_id, "offset") IN (
+			SELECT m.transaction_id, m."offset"
+			FROM %s m, last_processed lp
+			WHERE
+				m.created_at < CURRENT_TIMESTAMP::timestamp - make_interval(secs => $1)
+				AND (
+					m.transaction_id < lp.last_processed_transaction_id
+					OR (m.transaction_id = lp.last_processed_transaction_id AND m."offset" <= lp.offset_acked)
+				)
+			LIMIT $2
+		)
+	`,
+		watermillSQL.DefaultPostgreSQLOffsetsAdapter{}.MessagesOffsetsTable(outboxTopic),
+		messagesTable,
+		messagesTable,
+	), j.retention.Seconds(), janitorBatchSize)
+
+	var postgresError *pq.Error
+	if errors.As(err, &postgresError) && postgresError.Code.Name() == "undefined_table" {
+		// the forwarder didn't initialize the outbox yet
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not delete forwarded messages: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	janitorDeletedRowsCounter.Add(float64(deleted))
+
+	return deleted, nil
+}
@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	watermillSQL "github.com/ThreeDotsLabs/watermill-sql/v3/pkg/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const monitorInterval = 15 * time.Second
+
+var (
+	unforwardedMessagesGauge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "outbox",
+			Name:      "unforwarded_messages",
+			Help:      "The number of messages in the outbox not forwarded yet",
+		},
+	)
+	oldestUnforwardedMessageAgeGauge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "outbox",
+			Name:      "oldest_unforwarded_message_age_seconds",
+			Help:      "The age of the oldest message in the outbox not forwarded yet",
+		},
+	)
+	forwardFailuresCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "outbox",
+			Name:      "forward_failures_total",
+			Help:      "The total number of failed attempts to forward a message from the outbox",
+		},
+	)
+	lastForwardTimestampGauge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "outbox",
+			Name:      "last_forward_timestamp_seconds",
+			Help:      "The time of the last message forwarded from the outbox",
+		},
+	)
+
+	forwardFailures atomic.Uint64
+	lastForwardedAt atomic.Pointer[time.Time]
+)
+
+// Monitor reports how far behind the outbox forwarder is.
+type Monitor struct {
+	db *sqlx.DB
+}
+
+func NewMonitor(db *sqlx.DB) Monitor {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return Monitor{db: db}
+}
+
+// Run refreshes the outbox gauges until ctx is done.
+func (m Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Stats(ctx); err != nil && ctx.Err() == nil {
+			log.FromContext(ctx).With("error", err).Error("Could not get outbox stats")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m Monitor) Stats(ctx context.Context) (ticketsEntity.OutboxStats, error) {
+	var row struct {
+		Count     int64      `db:"count"`
+		OldestAt  *time.Time `db:"oldest_at"`
+		OldestAge *float64   `db:"oldest_age"`
+	}
+
+	// the forwarder subscribes without a consumer group
+	err := m.db.GetContext(ctx, &row, fmt.Sprintf(`
+		WITH last_processed AS (
+			SELECT offset_acked, last_processed_transaction_id
+			FROM %s
+			WHERE consumer_group = ''
+		)
+		SELECT
+			count(*) AS count,
+			min(created_at) AS oldest_at,
+			EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP::timestamp - min(created_at)))::float8 AS oldest_age
+		FROM %s
+		WHERE
+			NOT EXISTS (SELECT 1 FROM last_processed)
+			OR transaction_id > (SELECT last_processed_transaction_id FROM last_processed)
+			OR (
+				transaction_id = (SELECT last_processed_transaction_id FROM last_processed)
+				AND "offset" > (SELECT offset_acked FROM last_processed)
+			)
+	`,
+		watermillSQL.DefaultPostgreSQLOffsetsAdapter{}.MessagesOffsetsTable(outboxTopic),
+		watermillSQL.DefaultPostgreSQLSchema{}.MessagesTable(outboxTopic),
+	))
+
+	var postgresError *pq.Error
+	if errors.As(err, &postgresError) && postgresError.Code.Name() == "undefined_table" {
+		// the forwarder didn't initialize the outbox yet
+		err = nil
+	}
+	if err != nil {
+		return ticketsEntity.OutboxStats{}, fmt.Errorf("could not query outbox backlog: %w", err)
+	}
+
+	stats := ticketsEntity.OutboxStats{
+		UnforwardedMessages: row.Count,
+		OldestUnforwardedAt: row.OldestAt,
+		ForwardFailures:     forwardFailures.Load(),
+		LastForwardedAt:     lastForwardedAt.Load(),
+	}
+	if row.OldestAge != nil {
+		stats.OldestUnforwardedAgeSeconds = *row.OldestAge
+	}
+
+	unforwardedMessagesGauge.Set(float64(stats.UnforwardedMessages))
+	oldestUnforwardedMessageAgeGauge.Set(stats.OldestUnforwardedAgeSeconds)
+
+	return stats, nil
+}
+
+func recordForwardResult(err error) {
+	if err != nil {
+		forwardFailures.Add(1)
+		forwardFailuresCounter.Inc()
+		return
+	}
+
+	now := time.Now().UTC()
+	lastForwardedAt.Store(&now)
+	lastForwardTimestampGauge.Set(float64(now.Unix()))
+}
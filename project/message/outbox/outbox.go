@@ -1,3 +1,6 @@
 package outbox
 
 const outboxTopic = "events_to_forward"
+
+// ForwarderHandlerName is the name watermill's forwarder gives to its handler.
+const ForwarderHandlerName = "events_forwarder"
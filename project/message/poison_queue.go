@@ -0,0 +1,249 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	ticketsEntity "tickets/entities"
+	ticketsOutbox "tickets/message/outbox"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/redis/go-redis/v9"
+)
+
+// PoisonQueueTopic is the Redis stream where messages that exhausted all retries are moved.
+const PoisonQueueTopic = "poison_queue"
+
+const correlationIDMetadataKey = "correlation_id"
+
+// requeuedForHandlerMetadataKey is the name of the handler that poisoned a requeued message.
+const requeuedForHandlerMetadataKey = "requeued_for_handler"
+
+var (
+	ErrPoisonedMessageNotFound = errors.New("poisoned message not found")
+	ErrPoisonQueueNotSupported = errors.New("poison queue management is not supported by the message broker")
+)
+
+func PoisonQueueMiddleware(publisher message.Publisher) message.HandlerMiddleware {
+	// messages waiting for a circuit breaker are not broken, they are nacked and handled again later
+	pq, err := middleware.PoisonQueueWithFilter(publisher, PoisonQueueTopic, func(err error) bool {
+		return !errors.Is(err, ticketsEntity.ErrCircuitOpen)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		withPoisonQueue := pq(h)
+
+		return func(msg *message.Message) ([]*message.Message, error) {
+			// forwarded messages are still in the outbox, they are forwarded again instead
+			if message.HandlerNameFromCtx(msg.Context()) == ticketsOutbox.ForwarderHandlerName {
+				return h(msg)
+			}
+
+			return withPoisonQueue(msg)
+		}
+	}
+}
+
+// RequeuedMessagesMiddleware acks requeued messages in handlers other than the one that poisoned them.
+// Messages are requeued to the topic shared by all handlers, and the other handlers already handled them.
+func RequeuedMessagesMiddleware() message.HandlerMiddleware {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			requeuedFor := msg.Metadata.Get(requeuedForHandlerMetadataKey)
+			if requeuedFor == "" {
+				return h(msg)
+			}
+			if requeuedFor != message.HandlerNameFromCtx(msg.Context()) {
+				return nil, nil
+			}
+
+			// so it's not passed on when the handler publishes the message further, like the events splitter does
+			delete(msg.Metadata, requeuedForHandlerMetadataKey)
+
+			return h(msg)
+		}
+	}
+}
+
+// PoisonQueue manages the poison queue stored in a Redis stream.
+type PoisonQueue struct {
+	rdb         redis.UniversalClient
+	publisher   message.Publisher
+	unmarshaler redisstream.DefaultMarshallerUnmarshaller
+}
+
+func NewPoisonQueue(rdb redis.UniversalClient, publisher message.Publisher) *PoisonQueue {
+	if rdb == nil {
+		panic("missing rdb")
+	}
+	if publisher == nil {
+		panic("missing publisher")
+	}
+
+	return &PoisonQueue{
+		rdb:       rdb,
+		publisher: publisher,
+	}
+}
+
+func (p PoisonQueue) List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error) {
+	entries, err := p.rdb.XRange(ctx, PoisonQueueTopic, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("could not read poison queue: %w", err)
+	}
+
+	result := make([]ticketsEntity.PoisonedMessage, 0, len(entries))
+	for _, entry := range entries {
+		msg, err := p.unmarshaler.Unmarshal(entry.Values)
+		if err != nil {
+			return nil, fmt.Errorf("could not unmarshal poisoned message %s: %w", entry.ID, err)
+		}
+
+		result = append(result, newPoisonedMessage(msg, streamEntryTime(entry.ID)))
+	}
+
+	return result, nil
+}
+
+func (p PoisonQueue) Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error) {
+	entryID, msg, err := p.find(ctx, messageID)
+	if err != nil {
+		return ticketsEntity.PoisonedMessage{}, err
+	}
+
+	return newPoisonedMessage(msg, streamEntryTime(entryID)), nil
+}
+
+// Requeue publishes the message back to the topic it was consumed from and removes it from the poison queue.
+func (p PoisonQueue) Requeue(ctx context.Context, messageID string) error {
+	entryID, msg, err := p.find(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	if err := requeuePoisonedMessage(ctx, p.publisher, msg); err != nil {
+		return err
+	}
+
+	return p.remove(ctx, entryID)
+}
+
+func (p PoisonQueue) Remove(ctx context.Context, messageID string) error {
+	entryID, _, err := p.find(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	return p.remove(ctx, entryID)
+}
+
+func (p PoisonQueue) remove(ctx context.Context, entryID string) error {
+	if err := p.rdb.XDel(ctx, PoisonQueueTopic, entryID).Err(); err != nil {
+		return fmt.Errorf("could not remove poison queue entry %s: %w", entryID, err)
+	}
+
+	return nil
+}
+
+func (p PoisonQueue) find(ctx context.Context, messageID string) (string, *message.Message, error) {
+	entries, err := p.rdb.XRange(ctx, PoisonQueueTopic, "-", "+").Result()
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read poison queue: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Values[redisstream.UUIDHeaderKey] != messageID {
+			continue
+		}
+
+		msg, err := p.unmarshaler.Unmarshal(entry.Values)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not unmarshal poisoned message %s: %w", entry.ID, err)
+		}
+
+		return entry.ID, msg, nil
+	}
+
+	return "", nil, ErrPoisonedMessageNotFound
+}
+
+// requeuePoisonedMessage publishes the message back to the topic it was consumed from,
+// to be handled only by the handler that poisoned it.
+func requeuePoisonedMessage(ctx context.Context, publisher message.Publisher, msg *message.Message) error {
+	topic := msg.Metadata.Get(middleware.PoisonedTopicKey)
+	if topic == "" {
+		return fmt.Errorf("poisoned message %s has no original topic", msg.UUID)
+	}
+
+	requeued := message.NewMessage(msg.UUID, msg.Payload)
+	for key, value := range msg.Metadata {
+		switch key {
+		case middleware.ReasonForPoisonedKey,
+			middleware.PoisonedTopicKey,
+			middleware.PoisonedHandlerKey,
+			middleware.PoisonedSubscriberKey:
+			continue
+		}
+		requeued.Metadata.Set(key, value)
+	}
+	requeued.Metadata.Set(requeuedForHandlerMetadataKey, msg.Metadata.Get(middleware.PoisonedHandlerKey))
+	requeued.SetContext(ctx)
+
+	if err := publisher.Publish(topic, requeued); err != nil {
+		return fmt.Errorf("could not requeue message %s to %s: %w", msg.UUID, topic, err)
+	}
+
+	return nil
+}
+
+func newPoisonedMessage(msg *message.Message, poisonedAt time.Time) ticketsEntity.PoisonedMessage {
+	return ticketsEntity.PoisonedMessage{
+		MessageID:     msg.UUID,
+		Topic:         msg.Metadata.Get(middleware.PoisonedTopicKey),
+		Handler:       msg.Metadata.Get(middleware.PoisonedHandlerKey),
+		Subscriber:    msg.Metadata.Get(middleware.PoisonedSubscriberKey),
+		Reason:        msg.Metadata.Get(middleware.ReasonForPoisonedKey),
+		CorrelationID: msg.Metadata.Get(correlationIDMetadataKey),
+		PoisonedAt:    poisonedAt,
+		Payload:       string(msg.Payload),
+		Metadata:      msg.Metadata,
+	}
+}
+
+// streamEntryTime extracts the time from the Redis stream entry ID, which is in the "<milliseconds>-<sequence>" format.
+func streamEntryTime(entryID string) time.Time {
+	millis, _, _ := strings.Cut(entryID, "-")
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(ms).UTC()
+}
+
+// UnsupportedPoisonQueue is used with brokers that can't list or remove messages.
+type UnsupportedPoisonQueue struct{}
+
+func (UnsupportedPoisonQueue) List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error) {
+	return nil, ErrPoisonQueueNotSupported
+}
+
+func (UnsupportedPoisonQueue) Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error) {
+	return ticketsEntity.PoisonedMessage{}, ErrPoisonQueueNotSupported
+}
+
+func (UnsupportedPoisonQueue) Requeue(ctx context.Context, messageID string) error {
+	return ErrPoisonQueueNotSupported
+}
+
+func (UnsupportedPoisonQueue) Remove(ctx context.Context, messageID string) error {
+	return ErrPoisonQueueNotSupported
+}
@@ -0,0 +1,117 @@
+package message
+
+import (
+	"context"
+	"slices"
+	"sync"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// MemoryPoisonQueue keeps poisoned messages in memory, for brokers that don't store messages.
+type MemoryPoisonQueue struct {
+	publisher message.Publisher
+
+	lock     sync.Mutex
+	messages []memoryPoisonedMessage
+}
+
+type memoryPoisonedMessage struct {
+	msg        *message.Message
+	poisonedAt time.Time
+}
+
+// NewMemoryPoisonQueue subscribes to PoisonQueueTopic until the subscriber is closed.
+func NewMemoryPoisonQueue(subscriber message.Subscriber, publisher message.Publisher) (*MemoryPoisonQueue, error) {
+	if subscriber == nil {
+		panic("missing subscriber")
+	}
+	if publisher == nil {
+		panic("missing publisher")
+	}
+
+	messages, err := subscriber.Subscribe(context.Background(), PoisonQueueTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &MemoryPoisonQueue{publisher: publisher}
+
+	go func() {
+		for msg := range messages {
+			p.lock.Lock()
+			p.messages = append(p.messages, memoryPoisonedMessage{msg: msg, poisonedAt: time.Now().UTC()})
+			p.lock.Unlock()
+
+			msg.Ack()
+		}
+	}()
+
+	return p, nil
+}
+
+func (p *MemoryPoisonQueue) List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	result := make([]ticketsEntity.PoisonedMessage, 0, len(p.messages))
+	for _, poisoned := range p.messages {
+		result = append(result, newPoisonedMessage(poisoned.msg, poisoned.poisonedAt))
+	}
+
+	return result, nil
+}
+
+func (p *MemoryPoisonQueue) Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := p.find(messageID)
+	if i < 0 {
+		return ticketsEntity.PoisonedMessage{}, ErrPoisonedMessageNotFound
+	}
+
+	return newPoisonedMessage(p.messages[i].msg, p.messages[i].poisonedAt), nil
+}
+
+// Requeue publishes the message back to the topic it was consumed from and removes it from the poison queue.
+func (p *MemoryPoisonQueue) Requeue(ctx context.Context, messageID string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := p.find(messageID)
+	if i < 0 {
+		return ErrPoisonedMessageNotFound
+	}
+
+	if err := requeuePoisonedMessage(ctx, p.publisher, p.messages[i].msg); err != nil {
+		return err
+	}
+
+	p.messages = slices.Delete(p.messages, i, i+1)
+
+	return nil
+}
+
+func (p *MemoryPoisonQueue) Remove(ctx context.Context, messageID string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := p.find(messageID)
+	if i < 0 {
+		return ErrPoisonedMessageNotFound
+	}
+
+	p.messages = slices.Delete(p.messages, i, i+1)
+
+	return nil
+}
+
+// find must be called with the lock held.
+func (p *MemoryPoisonQueue) find(messageID string) int {
+	return slices.IndexFunc(p.messages, func(poisoned memoryPoisonedMessage) bool {
+		return poisoned.msg.UUID == messageID
+	})
+}
@@ -0,0 +1,155 @@
+package message
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SQLPoisonQueue manages the poison queue stored in a watermill-sql messages table.
+type SQLPoisonQueue struct {
+	db        *sqlx.DB
+	publisher message.Publisher
+	table     string
+}
+
+type sqlPoisonedMessage struct {
+	Offset    int64     `db:"offset"`
+	UUID      string    `db:"uuid"`
+	CreatedAt time.Time `db:"created_at"`
+	Payload   []byte    `db:"payload"`
+	Metadata  []byte    `db:"metadata"`
+}
+
+// NewSQLPoisonQueue manages messages in the table of PoisonQueueTopic, table is its quoted name.
+func NewSQLPoisonQueue(db *sqlx.DB, publisher message.Publisher, table string) *SQLPoisonQueue {
+	if db == nil {
+		panic("db is nil")
+	}
+	if publisher == nil {
+		panic("missing publisher")
+	}
+
+	return &SQLPoisonQueue{
+		db:        db,
+		publisher: publisher,
+		table:     table,
+	}
+}
+
+func (p SQLPoisonQueue) List(ctx context.Context) ([]ticketsEntity.PoisonedMessage, error) {
+	var rows []sqlPoisonedMessage
+	err := p.db.SelectContext(ctx, &rows, fmt.Sprintf(`
+		SELECT "offset", uuid, created_at, payload, metadata
+		FROM %s
+		ORDER BY transaction_id, "offset"
+	`, p.table))
+	if isUndefinedTable(err) {
+		// nothing was poisoned yet
+		return []ticketsEntity.PoisonedMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read poison queue: %w", err)
+	}
+
+	result := make([]ticketsEntity.PoisonedMessage, 0, len(rows))
+	for _, row := range rows {
+		msg, err := row.message()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, newPoisonedMessage(msg, row.CreatedAt.UTC()))
+	}
+
+	return result, nil
+}
+
+func (p SQLPoisonQueue) Get(ctx context.Context, messageID string) (ticketsEntity.PoisonedMessage, error) {
+	row, msg, err := p.find(ctx, messageID)
+	if err != nil {
+		return ticketsEntity.PoisonedMessage{}, err
+	}
+
+	return newPoisonedMessage(msg, row.CreatedAt.UTC()), nil
+}
+
+// Requeue publishes the message back to the topic it was consumed from and removes it from the poison queue.
+func (p SQLPoisonQueue) Requeue(ctx context.Context, messageID string) error {
+	row, msg, err := p.find(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	if err := requeuePoisonedMessage(ctx, p.publisher, msg); err != nil {
+		return err
+	}
+
+	return p.remove(ctx, row.Offset)
+}
+
+func (p SQLPoisonQueue) Remove(ctx context.Context, messageID string) error {
+	row, _, err := p.find(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	return p.remove(ctx, row.Offset)
+}
+
+func (p SQLPoisonQueue) remove(ctx context.Context, offset int64) error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE "offset" = $1`, p.table), offset)
+	if err != nil {
+		return fmt.Errorf("could not remove poison queue entry %d: %w", offset, err)
+	}
+
+	return nil
+}
+
+func (p SQLPoisonQueue) find(ctx context.Context, messageID string) (sqlPoisonedMessage, *message.Message, error) {
+	var row sqlPoisonedMessage
+	err := p.db.GetContext(ctx, &row, fmt.Sprintf(`
+		SELECT "offset", uuid, created_at, payload, metadata
+		FROM %s
+		WHERE uuid = $1
+		ORDER BY transaction_id, "offset"
+		LIMIT 1
+	`, p.table), messageID)
+	if errors.Is(err, sql.ErrNoRows) || isUndefinedTable(err) {
+		return sqlPoisonedMessage{}, nil, ErrPoisonedMessageNotFound
+	}
+	if err != nil {
+		return sqlPoisonedMessage{}, nil, fmt.Errorf("could not read poison queue: %w", err)
+	}
+
+	msg, err := row.message()
+	if err != nil {
+		return sqlPoisonedMessage{}, nil, err
+	}
+
+	return row, msg, nil
+}
+
+func (r sqlPoisonedMessage) message() (*message.Message, error) {
+	msg := message.NewMessage(r.UUID, r.Payload)
+	if len(r.Metadata) > 0 {
+		if err := json.Unmarshal(r.Metadata, &msg.Metadata); err != nil {
+			return nil, fmt.Errorf("could not unmarshal metadata of poisoned message %s: %w", r.UUID, err)
+		}
+	}
+
+	return msg, nil
+}
+
+func isUndefinedTable(err error) bool {
+	var postgresError *pq.Error
+	return errors.As(err, &postgresError) && postgresError.Code.Name() == "undefined_table"
+}
@@ -0,0 +1,172 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	ticketsEntity "tickets/entities"
+	ticketsMessage "tickets/message"
+	ticketsOutbox "tickets/message/outbox"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const poisonQueueTestTopic = "poison_queue_test"
+
+func TestPoisonQueue_requeue(t *testing.T) {
+	ctx := context.Background()
+	pubSub, poisonQueue := newPoisonQueueTest(t)
+
+	failing := &handlerStub{failures: 1}
+	other := &handlerStub{}
+	runRouter(t, pubSub, map[string]*handlerStub{
+		"failing": failing,
+		"other":   other,
+	})
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	msg.Metadata.Set("correlation_id", "test-correlation-id")
+	require.NoError(t, pubSub.Publish(poisonQueueTestTopic, msg))
+
+	var poisoned []ticketsEntity.PoisonedMessage
+	require.Eventually(t, func() bool {
+		poisoned = listPoisoned(t, poisonQueue)
+		return len(poisoned) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, msg.UUID, poisoned[0].MessageID)
+	assert.Equal(t, poisonQueueTestTopic, poisoned[0].Topic)
+	assert.Equal(t, "failing", poisoned[0].Handler)
+	assert.Equal(t, "test-correlation-id", poisoned[0].CorrelationID)
+	assert.Contains(t, poisoned[0].Reason, "handler failed")
+	assert.Equal(t, 1, other.handled())
+
+	got, err := poisonQueue.Get(ctx, msg.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, msg.UUID, got.MessageID)
+
+	require.NoError(t, poisonQueue.Requeue(ctx, msg.UUID))
+
+	require.Eventually(t, func() bool {
+		return failing.handled() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		return other.handled() != 1
+	}, 100*time.Millisecond, 10*time.Millisecond, "requeued message should be handled only by the handler that poisoned it")
+
+	assert.Empty(t, listPoisoned(t, poisonQueue))
+	assert.ErrorIs(t, poisonQueue.Requeue(ctx, msg.UUID), ticketsMessage.ErrPoisonedMessageNotFound)
+}
+
+func TestPoisonQueue_remove(t *testing.T) {
+	ctx := context.Background()
+	pubSub, poisonQueue := newPoisonQueueTest(t)
+
+	failing := &handlerStub{failures: 1}
+	runRouter(t, pubSub, map[string]*handlerStub{"failing": failing})
+
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{}`))
+	require.NoError(t, pubSub.Publish(poisonQueueTestTopic, msg))
+
+	require.Eventually(t, func() bool {
+		return len(listPoisoned(t, poisonQueue)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, poisonQueue.Remove(ctx, msg.UUID))
+
+	assert.Empty(t, listPoisoned(t, poisonQueue))
+	_, err := poisonQueue.Get(ctx, msg.UUID)
+	assert.ErrorIs(t, err, ticketsMessage.ErrPoisonedMessageNotFound)
+	assert.Equal(t, 0, failing.handled())
+}
+
+func TestPoisonQueue_outbox_forwarder_is_not_poisoned(t *testing.T) {
+	pubSub, poisonQueue := newPoisonQueueTest(t)
+
+	forwarder := &handlerStub{failures: 1}
+	runRouter(t, pubSub, map[string]*handlerStub{ticketsOutbox.ForwarderHandlerName: forwarder})
+
+	require.NoError(t, pubSub.Publish(poisonQueueTestTopic, message.NewMessage(watermill.NewUUID(), []byte(`{}`))))
+
+	require.Eventually(t, func() bool {
+		return forwarder.handled() == 1
+	}, time.Second, 10*time.Millisecond, "message should be redelivered to the forwarder")
+	assert.Empty(t, listPoisoned(t, poisonQueue))
+}
+
+func newPoisonQueueTest(t *testing.T) (*gochannel.GoChannel, *ticketsMessage.MemoryPoisonQueue) {
+	t.Helper()
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	t.Cleanup(func() {
+		_ = pubSub.Close()
+	})
+
+	poisonQueue, err := ticketsMessage.NewMemoryPoisonQueue(pubSub, pubSub)
+	require.NoError(t, err)
+
+	return pubSub, poisonQueue
+}
+
+func runRouter(t *testing.T, pubSub *gochannel.GoChannel, handlers map[string]*handlerStub) {
+	t.Helper()
+
+	router := message.NewDefaultRouter(watermill.NopLogger{})
+	router.AddMiddleware(
+		ticketsMessage.RequeuedMessagesMiddleware(),
+		ticketsMessage.PoisonQueueMiddleware(pubSub),
+	)
+	for name, handler := range handlers {
+		router.AddConsumerHandler(name, poisonQueueTestTopic, pubSub, handler.handle)
+	}
+
+	go func() {
+		_ = router.Run(context.Background())
+	}()
+	t.Cleanup(func() {
+		_ = router.Close()
+	})
+	<-router.Running()
+}
+
+func listPoisoned(t *testing.T, poisonQueue *ticketsMessage.MemoryPoisonQueue) []ticketsEntity.PoisonedMessage {
+	t.Helper()
+
+	messages, err := poisonQueue.List(context.Background())
+	require.NoError(t, err)
+
+	return messages
+}
+
+// handlerStub fails the first failures calls and counts the successful ones.
+type handlerStub struct {
+	lock      sync.Mutex
+	failures  int
+	successes int
+}
+
+func (h *handlerStub) handle(msg *message.Message) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.failures > 0 {
+		h.failures--
+		return errors.New("handler failed")
+	}
+
+	h.successes++
+	return nil
+}
+
+func (h *handlerStub) handled() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.successes
+}
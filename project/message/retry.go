@@ -0,0 +1,181 @@
+package message
+
+import (
+	"context"
+	"errors"
+	ticketsEntity "tickets/entities"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	errorClassPermanent   = "permanent"
+	errorClassCircuitOpen = "circuit_open"
+	errorClassTimeout     = "timeout"
+	errorClassTransient   = "transient"
+)
+
+// maxCircuitOpenWait is how long a message waits for circuit breakers of external services to close.
+// After that the message is nacked and redelivered, so it's not held longer than Redis waits before redelivering
+// it to another consumer.
+const maxCircuitOpenWait = 45 * time.Second
+
+var (
+	messagesCircuitOpenWaitsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "messages",
+			Name:      "circuit_open_waits_total",
+			Help:      "The total number of times handling waited for the circuit breaker of an external service",
+		},
+		[]string{"topic", "handler"},
+	)
+
+	messagesRetriesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "messages",
+			Name:      "retries_total",
+			Help:      "The total number of retried message handling attempts",
+		},
+		[]string{"topic", "handler"},
+	)
+
+	messagesGivenUpCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "messages",
+			Name:      "given_up_total",
+			Help:      "The total number of messages that failed without further retries, by the class of the last error",
+		},
+		[]string{"topic", "handler", "class"},
+	)
+)
+
+type RetryPolicy struct {
+	MaxRetries      int           `yaml:"max_retries"`
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	MaxInterval     time.Duration `yaml:"max_interval"`
+	Multiplier      float64       `yaml:"multiplier"`
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:      10,
+	InitialInterval: time.Millisecond * 100,
+	MaxInterval:     time.Second,
+	Multiplier:      2,
+}
+
+// externalAPIRetryPolicy gives external services more time to recover.
+// All retries should take less than a minute, after that Redis redelivers the message to another consumer.
+var externalAPIRetryPolicy = RetryPolicy{
+	MaxRetries:      6,
+	InitialInterval: time.Millisecond * 500,
+	MaxInterval:     time.Second * 10,
+	Multiplier:      2,
+}
+
+// RetryPolicies are retry policies by handler name.
+// Handlers without a policy use DefaultRetryPolicy.
+type RetryPolicies map[string]RetryPolicy
+
+// DefaultRetryPolicies are the policies used unless the config overrides them.
+func DefaultRetryPolicies() RetryPolicies {
+	return RetryPolicies{
+		"AppendToTracker":       externalAPIRetryPolicy,
+		"TicketRefundToSheet":   externalAPIRetryPolicy,
+		"IssueReceipt":          externalAPIRetryPolicy,
+		"PrintConfirmedTicket":  externalAPIRetryPolicy,
+		"CallDeadNation":        externalAPIRetryPolicy,
+		"RefundCanceledBooking": externalAPIRetryPolicy,
+		"RefundReceipt":         externalAPIRetryPolicy,
+		"BookFlight":            externalAPIRetryPolicy,
+		"BookTaxi":              externalAPIRetryPolicy,
+		"CancelFlight":          externalAPIRetryPolicy,
+	}
+}
+
+func (p RetryPolicies) ForHandler(handlerName string) RetryPolicy {
+	if policy, ok := p[handlerName]; ok {
+		return policy
+	}
+
+	return DefaultRetryPolicy
+}
+
+// RetryMiddleware retries failed messages with the policy of the handler.
+// Permanent errors are not retried, so the message goes straight to the poison queue.
+// Calls rejected by an open circuit breaker don't use up the retries, handling waits until the breaker lets
+// requests through and the message is nacked if it doesn't happen in maxCircuitOpenWait.
+func RetryMiddleware(
+	policies RetryPolicies,
+	watermillLogger watermill.LoggerAdapter,
+) func(h message.HandlerFunc) message.HandlerFunc {
+	return func(h message.HandlerFunc) message.HandlerFunc {
+		return func(msg *message.Message) ([]*message.Message, error) {
+			topic := message.SubscribeTopicFromCtx(msg.Context())
+			handler := message.HandlerNameFromCtx(msg.Context())
+			policy := policies.ForHandler(handler)
+
+			retry := middleware.Retry{
+				MaxRetries:      policy.MaxRetries,
+				InitialInterval: policy.InitialInterval,
+				MaxInterval:     policy.MaxInterval,
+				Multiplier:      policy.Multiplier,
+				ShouldRetry: func(params middleware.RetryParams) bool {
+					return !ticketsEntity.IsPermanentError(params.Err) && !errors.Is(params.Err, ticketsEntity.ErrCircuitOpen)
+				},
+				Logger: watermillLogger,
+			}
+
+			attempts := 0
+			circuitOpenWaitUntil := time.Now().Add(maxCircuitOpenWait)
+			msgs, err := retry.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+				attempts++
+				for {
+					msgs, err := h(msg)
+
+					var circuitOpen ticketsEntity.CircuitOpenError
+					if !errors.As(err, &circuitOpen) || time.Now().Add(circuitOpen.RetryAfter).After(circuitOpenWaitUntil) {
+						return msgs, err
+					}
+
+					messagesCircuitOpenWaitsCounter.With(prometheus.Labels{"topic": topic, "handler": handler}).Inc()
+					select {
+					case <-msg.Context().Done():
+						return msgs, err
+					case <-time.After(circuitOpen.RetryAfter):
+					}
+				}
+			})(msg)
+
+			if attempts > 1 {
+				messagesRetriesCounter.With(prometheus.Labels{"topic": topic, "handler": handler}).Add(float64(attempts - 1))
+			}
+			if err != nil && !errors.Is(err, ticketsEntity.ErrCircuitOpen) {
+				messagesGivenUpCounter.With(prometheus.Labels{
+					"topic":   topic,
+					"handler": handler,
+					"class":   errorClass(err),
+				}).Inc()
+			}
+
+			return msgs, err
+		}
+	}
+}
+
+func errorClass(err error) string {
+	switch {
+	case ticketsEntity.IsPermanentError(err):
+		return errorClassPermanent
+	case errors.Is(err, ticketsEntity.ErrCircuitOpen):
+		return errorClassCircuitOpen
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorClassTimeout
+	default:
+		return errorClassTransient
+	}
+}
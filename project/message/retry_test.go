@@ -0,0 +1,97 @@
+package message_test
+
+import (
+	"errors"
+	"testing"
+	ticketsEntity "tickets/entities"
+	ticketsMessage "tickets/message"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	// handler name is set by the router, it's empty when the middleware is called directly
+	policies := ticketsMessage.RetryPolicies{
+		"": {
+			MaxRetries:      3,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			Multiplier:      1,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		err           error
+		expectedCalls int
+	}{
+		{
+			name:          "transient",
+			err:           errors.New("receipts service unavailable"),
+			expectedCalls: 4,
+		},
+		{
+			name:          "permanent",
+			err:           ticketsEntity.NewPermanentError(errors.New("invalid booking id")),
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			handler := ticketsMessage.RetryMiddleware(policies, watermill.NopLogger{})(
+				func(msg *message.Message) ([]*message.Message, error) {
+					calls++
+					return nil, tc.err
+				},
+			)
+
+			_, err := handler(message.NewMessage(watermill.NewUUID(), nil))
+			require.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestRetryMiddleware_circuit_open(t *testing.T) {
+	policies := ticketsMessage.RetryPolicies{
+		"": {
+			MaxRetries:      1,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond,
+			Multiplier:      1,
+		},
+	}
+
+	calls := 0
+	handler := ticketsMessage.RetryMiddleware(policies, watermill.NopLogger{})(
+		func(msg *message.Message) ([]*message.Message, error) {
+			calls++
+			if calls <= 3 {
+				return nil, ticketsEntity.CircuitOpenError{Service: "receipts", RetryAfter: time.Millisecond}
+			}
+			if calls == 4 {
+				return nil, errors.New("receipts service unavailable")
+			}
+			return nil, nil
+		},
+	)
+
+	_, err := handler(message.NewMessage(watermill.NewUUID(), nil))
+	require.NoError(t, err, "waiting for the circuit breaker should not use up the retries")
+	assert.Equal(t, 5, calls)
+
+	handler = ticketsMessage.RetryMiddleware(policies, watermill.NopLogger{})(
+		func(msg *message.Message) ([]*message.Message, error) {
+			return nil, ticketsEntity.CircuitOpenError{Service: "receipts", RetryAfter: time.Hour}
+		},
+	)
+
+	_, err = handler(message.NewMessage(watermill.NewUUID(), nil))
+	assert.ErrorIs(t, err, ticketsEntity.ErrCircuitOpen, "message should be nacked instead of waiting too long")
+}
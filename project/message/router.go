@@ -1,8 +1,9 @@
 package message
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	ticketsDB "tickets/db"
 	ticketsEntity "tickets/entities"
 	ticketsCommand "tickets/message/command"
 	ticketsEvent "tickets/message/event"
@@ -13,22 +14,36 @@ import (
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
+type OpsBookingReadModel interface {
+	OnBookingMade(ctx context.Context, event *ticketsEntity.BookingMade_v1) error
+	OnTicketBookingConfirmed(ctx context.Context, event *ticketsEntity.TicketBookingConfirmed_v1) error
+	OnTicketRefunded(ctx context.Context, event *ticketsEntity.TicketRefunded_v1) error
+	OnTicketPrinted(ctx context.Context, event *ticketsEntity.TicketPrinted_v1) error
+	OnTicketReceiptIssued(ctx context.Context, event *ticketsEntity.TicketReceiptIssued_v1) error
+	OnBookingCanceled(ctx context.Context, event *ticketsEntity.BookingCanceled_v1) error
+}
+
+// NewRouter creates the router of all handlers. postgresSubscriber is nil when the outbox is not used.
 func NewRouter(
-	redisSubscriberStore message.Subscriber,
-	redisSubscriber message.Subscriber,
+	eventsStoreSubscriber message.Subscriber,
+	eventsSplitterSubscriber message.Subscriber,
 	postgresSubscriber message.Subscriber,
-	redisPublisher message.Publisher,
+	publisher message.Publisher,
 	eventProcessorConfig cqrs.EventProcessorConfig,
 	commandProcessorConfig cqrs.CommandProcessorConfig,
 	commandHandler ticketsCommand.Handler,
-	opsReadModel ticketsDB.OpsBookingReadModel,
+	opsReadModel OpsBookingReadModel,
 	eventHandler *ticketsEvent.Handler,
 	watermillLogger watermill.LoggerAdapter,
 	vipBundleProcessManager *VipBundleProcessManager,
+	inbox Inbox,
+	retryPolicies RetryPolicies,
 ) *message.Router {
 	router := message.NewDefaultRouter(watermillLogger)
-	AddMiddleWare(router, watermillLogger)
-	ticketsOutbox.AddForwarderHandler(postgresSubscriber, redisPublisher, router, watermillLogger)
+	AddMiddleWare(router, publisher, inbox, retryPolicies, watermillLogger)
+	if postgresSubscriber != nil {
+		ticketsOutbox.AddForwarderHandler(postgresSubscriber, publisher, router, watermillLogger)
+	}
 	eventProcessor, err := cqrs.NewEventProcessorWithConfig(
 		router,
 		eventProcessorConfig,
@@ -44,29 +59,32 @@ func NewRouter(
 	router.AddConsumerHandler(
 		"events_splitter",
 		"events",
-		redisSubscriber,
+		eventsSplitterSubscriber,
 		func(msg *message.Message) error {
+			// old versions of events are forwarded to the topic of the latest version,
+			// they are upcasted when handled by the event processor
 			eventName := eventProcessorConfig.Marshaler.NameFromMessage(msg)
 			if eventName == "" {
-				return fmt.Errorf("cannot get event name from message")
+				return ticketsEntity.NewPermanentError(fmt.Errorf("cannot get event name from message"))
 			}
-			return redisPublisher.Publish("events."+eventName, msg)
+			return publisher.Publish("events."+eventName, msg)
 		},
 	)
 
 	router.AddConsumerHandler(
 		"events_store",
 		"events",
-		redisSubscriberStore,
+		eventsStoreSubscriber,
 		func(msg *message.Message) error {
+			// the data lake keeps events as they were published, without upcasting
 			var event ticketsEntity.ExternalEvent
-			if err := eventProcessorConfig.Marshaler.Unmarshal(msg, &event); err != nil {
-				return fmt.Errorf("cannot unmarshal event: %w", err)
+			if err := json.Unmarshal(msg.Payload, &event); err != nil {
+				return ticketsEntity.NewPermanentError(fmt.Errorf("cannot unmarshal event: %w", err))
 			}
 
-			eventName := eventProcessorConfig.Marshaler.NameFromMessage(msg)
+			eventName := ticketsEvent.OriginalNameFromMessage(msg)
 			if eventName == "" {
-				return fmt.Errorf("cannot get event name from message")
+				return ticketsEntity.NewPermanentError(fmt.Errorf("cannot get event name from message"))
 			}
 
 			return eventHandler.StoreEvent(msg.Context(), event, eventName, msg.Payload)
@@ -102,6 +120,10 @@ func NewRouter(
 			"CallDeadNation",
 			eventHandler.CallDeadNation,
 		),
+		cqrs.NewEventHandler(
+			"RefundCanceledBooking",
+			eventHandler.RefundCanceledBooking,
+		),
 		cqrs.NewEventHandler(
 			"ops_read_model.OnBookingMade",
 			opsReadModel.OnBookingMade,
@@ -122,6 +144,10 @@ func NewRouter(
 			"ops_read_model.OnTicketReceiptIssued",
 			opsReadModel.OnTicketReceiptIssued,
 		),
+		cqrs.NewEventHandler(
+			"ops_read_model.OnBookingCanceled",
+			opsReadModel.OnBookingCanceled,
+		),
 		cqrs.NewEventHandler(
 			"vip_bundle_process_manager.OnVipBundleInitialized",
 			vipBundleProcessManager.OnVipBundleInitialized,
@@ -154,6 +180,10 @@ func NewRouter(
 			"vip_bundle_process_manager.OnTaxiBookingFailed",
 			vipBundleProcessManager.OnTaxiBookingFailed,
 		),
+		cqrs.NewEventHandler(
+			"vip_bundle_process_manager.OnStepTimedOut",
+			vipBundleProcessManager.OnStepTimedOut,
+		),
 	)
 	if err != nil {
 		panic(err)
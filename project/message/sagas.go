@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	ticketsDB "tickets/db"
 	ticketsEntity "tickets/entities"
+	"time"
 
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/google/uuid"
 )
 
@@ -36,6 +39,10 @@ type VipBundleRepository interface {
 	) (ticketsEntity.VipBundle, error)
 }
 
+type BookingCanceler interface {
+	CancelBooking(ctx context.Context, bookingID string) error
+}
+
 type CommandBus interface {
 	Send(ctx context.Context, command any) error
 }
@@ -48,17 +55,41 @@ type VipBundleProcessManager struct {
 	commandBus          CommandBus
 	eventBus            EventBus
 	vipBundleRepository VipBundleRepository
+	bookingCanceler     BookingCanceler
+	stepTimeout         time.Duration
 }
 
+// NewVipBundleProcessManager creates the process manager, stepTimeout is how long it waits for each step
+// before the bundle is compensated.
 func NewVipBundleProcessManager(
 	commandBus CommandBus,
 	eventBus EventBus,
 	vipBundleRepository VipBundleRepository,
+	bookingCanceler BookingCanceler,
+	stepTimeout time.Duration,
 ) *VipBundleProcessManager {
+	if commandBus == nil {
+		panic("commandBus is nil")
+	}
+	if eventBus == nil {
+		panic("eventBus is nil")
+	}
+	if vipBundleRepository == nil {
+		panic("vipBundleRepository is nil")
+	}
+	if bookingCanceler == nil {
+		panic("bookingCanceler is nil")
+	}
+	if stepTimeout <= 0 {
+		panic("stepTimeout must be positive")
+	}
+
 	return &VipBundleProcessManager{
 		commandBus:          commandBus,
 		eventBus:            eventBus,
 		vipBundleRepository: vipBundleRepository,
+		bookingCanceler:     bookingCanceler,
+		stepTimeout:         stepTimeout,
 	}
 }
 
@@ -66,11 +97,23 @@ func (v VipBundleProcessManager) OnVipBundleInitialized(
 	ctx context.Context,
 	event *ticketsEntity.VipBundleInitialized_v1,
 ) error {
-	// Get information about VIP bundle
-	vipBundle, err := v.vipBundleRepository.Get(ctx, event.VipBundleID)
+	vipBundle, err := v.vipBundleRepository.UpdateByID(
+		ctx,
+		event.VipBundleID,
+		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if !vipBundle.IsFinalized && vipBundle.BookingMadeAt == nil {
+				vipBundle.SetDeadline(ticketsEntity.VipBundleStepBookingMade, time.Now().Add(v.stepTimeout))
+			}
+			return vipBundle, nil
+		},
+	)
 	if err != nil {
 		return err
 	}
+	if vipBundle.IsFinalized {
+		return nil
+	}
+
 	bookingShowTickets := ticketsEntity.BookShowTickets{
 		BookingID:       vipBundle.BookingID,
 		CustomerEmail:   vipBundle.CustomerEmail,
@@ -84,13 +127,21 @@ func (v VipBundleProcessManager) OnVipBundleInitialized(
 func (v VipBundleProcessManager) OnBookingMade(ctx context.Context, event *ticketsEntity.BookingMade_v1) error {
 	bookingID, err := uuid.Parse(event.BookingID)
 	if err != nil {
-		return err
+		return ticketsEntity.NewPermanentError(err)
 	}
 	vipBundle, err := v.vipBundleRepository.UpdateByBookingID(
 		ctx,
 		bookingID,
 		func(b ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if b.IsFinalized {
+				return b, nil
+			}
 			b.BookingMadeAt = &event.Header.PublishedAt
+			b.ClearDeadline(ticketsEntity.VipBundleStepBookingMade)
+			if b.TicketsConfirmedAt == nil {
+				b.SetDeadline(ticketsEntity.VipBundleStepTicketsConfirmed, time.Now().Add(v.stepTimeout))
+			}
+			b.SetDeadline(ticketsEntity.VipBundleStepInboundFlightBooked, time.Now().Add(v.stepTimeout))
 			return b, nil
 		},
 	)
@@ -101,6 +152,14 @@ func (v VipBundleProcessManager) OnBookingMade(ctx context.Context, event *ticke
 		}
 		return err
 	}
+	if vipBundle.Failed {
+		// The booking may be made after the bundle timed out, its seats are not needed anymore.
+		// Canceling a canceled booking is a no-op, so it's fine when the compensation canceled it already.
+		return v.bookingCanceler.CancelBooking(ctx, event.BookingID)
+	}
+	if vipBundle.IsFinalized {
+		return nil
+	}
 
 	return v.commandBus.Send(
 		ctx, ticketsEntity.BookFlight{
@@ -118,14 +177,22 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *tick
 		ctx,
 		MustParseBundleID(event.ReferenceID),
 		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if vipBundle.IsFinalized {
+				return vipBundle, nil
+			}
 			// Check if this is inbound or return flight
 			if event.FlightID == vipBundle.InboundFlightID {
-				// Store inbound flight ticket IDs
+				// Store inbound flight ticket IDs and timestamp
 				vipBundle.InboundFlightTicketsIDs = event.TicketIDs
+				vipBundle.InboundFlightBookedAt = &event.Header.PublishedAt
+				vipBundle.ClearDeadline(ticketsEntity.VipBundleStepInboundFlightBooked)
+				vipBundle.SetDeadline(ticketsEntity.VipBundleStepReturnFlightBooked, time.Now().Add(v.stepTimeout))
 			} else if event.FlightID == vipBundle.ReturnFlightID {
 				// Store return flight ticket IDs and timestamp
 				vipBundle.ReturnFlightTicketsIDs = event.TicketIDs
 				vipBundle.ReturnFlightBookedAt = &event.Header.PublishedAt
+				vipBundle.ClearDeadline(ticketsEntity.VipBundleStepReturnFlightBooked)
+				vipBundle.SetDeadline(ticketsEntity.VipBundleStepTaxiBooked, time.Now().Add(v.stepTimeout))
 			}
 			return vipBundle, nil
 		},
@@ -133,6 +200,18 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *tick
 	if err != nil {
 		return err
 	}
+	if vb.IsFinalized {
+		if !vb.Failed || flightTicketsStored(vb, event.FlightID) {
+			// Redelivered event, the tickets are used or canceled by the compensation
+			return nil
+		}
+		// The flight was booked after the bundle timed out, nobody is going to fly with these tickets
+		return v.commandBus.Send(
+			ctx, ticketsEntity.CancelFlightTickets{
+				FlightTicketIDs: event.TicketIDs,
+			},
+		)
+	}
 	// Determine next action based on which flight was booked
 	if event.FlightID == vb.InboundFlightID {
 		// Inbound flight booked - book return flight
@@ -161,13 +240,22 @@ func (v VipBundleProcessManager) OnFlightBooked(ctx context.Context, event *tick
 	return nil
 }
 
+func flightTicketsStored(vb ticketsEntity.VipBundle, flightID uuid.UUID) bool {
+	if flightID == vb.InboundFlightID {
+		return len(vb.InboundFlightTicketsIDs) > 0
+	}
+
+	return flightID == vb.ReturnFlightID && len(vb.ReturnFlightTicketsIDs) > 0
+}
+
 func (v VipBundleProcessManager) OnBookingFailed(ctx context.Context, event *ticketsEntity.BookingFailed_v1) error {
 	vb, err := v.vipBundleRepository.UpdateByBookingID(
 		ctx,
 		event.BookingID,
 		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
-			vipBundle.IsFinalized = true
-			vipBundle.Failed = true
+			if !vipBundle.IsFinalized {
+				vipBundle.MarkFailed(ticketsEntity.VipBundleStepBookingMade, event.FailureReason, event.Header.PublishedAt)
+			}
 			return vipBundle, nil
 		},
 	)
@@ -178,11 +266,17 @@ func (v VipBundleProcessManager) OnBookingFailed(ctx context.Context, event *tic
 		}
 		return err
 	}
+	if vb.FailedStep != ticketsEntity.VipBundleStepBookingMade {
+		// The bundle was finalized before, by a timeout for example
+		return nil
+	}
+
 	return v.eventBus.Publish(
 		ctx, ticketsEntity.VipBundleFinalized_v1{
-			Header:      ticketsEntity.NewMessageHeader(),
-			VipBundleID: vb.VipBundleID,
-			Success:     false,
+			Header:        ticketsEntity.NewMessageHeader(),
+			VipBundleID:   vb.VipBundleID,
+			Success:       false,
+			FailureReason: vb.FailureReason,
 		},
 	)
 }
@@ -193,7 +287,7 @@ func (v VipBundleProcessManager) OnTicketBookingConfirmed(
 ) error {
 	bookingID, err := uuid.Parse(event.BookingID)
 	if err != nil {
-		return err
+		return ticketsEntity.NewPermanentError(err)
 	}
 	_, err = v.vipBundleRepository.UpdateByBookingID(
 		ctx,
@@ -201,9 +295,13 @@ func (v VipBundleProcessManager) OnTicketBookingConfirmed(
 		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
 			ticketID, err := uuid.Parse(event.TicketID)
 			if err != nil {
-				return ticketsEntity.VipBundle{}, err
+				return ticketsEntity.VipBundle{}, ticketsEntity.NewPermanentError(err)
 			}
 			vipBundle.TicketIDs = append(vipBundle.TicketIDs, ticketID)
+			if len(vipBundle.TicketIDs) >= vipBundle.NumberOfTickets && vipBundle.TicketsConfirmedAt == nil {
+				vipBundle.TicketsConfirmedAt = &event.Header.PublishedAt
+				vipBundle.ClearDeadline(ticketsEntity.VipBundleStepTicketsConfirmed)
+			}
 			return vipBundle, nil
 		},
 	)
@@ -220,61 +318,19 @@ func (v VipBundleProcessManager) OnFlightBookingFailed(
 ) error {
 	vipBundleID, err := uuid.Parse(event.ReferenceID)
 	if err != nil {
-		return err
+		return ticketsEntity.NewPermanentError(err)
 	}
 	vb, err := v.vipBundleRepository.Get(ctx, ticketsEntity.VipBundleID{UUID: vipBundleID})
 	if err != nil {
 		return err
 	}
-	ticketIDs := vb.TicketIDs
-	if len(ticketIDs) < vb.NumberOfTickets {
-		return errors.New("confirmed tickets haven't processed yet")
-	}
-
-	// refund shows
-	for i := range ticketIDs {
-		err := v.commandBus.Send(
-			ctx, ticketsEntity.RefundTicket{
-				Header:   ticketsEntity.NewMessageHeader(),
-				TicketID: ticketIDs[i].String(),
-			},
-		)
-		if err != nil {
-			return err
-		}
-	}
 
-	// Cancel inbound flight tickets if they exist
-	if len(vb.InboundFlightTicketsIDs) > 0 {
-		err := v.commandBus.Send(
-			ctx, ticketsEntity.CancelFlightTickets{
-				FlightTicketIDs: vb.InboundFlightTicketsIDs,
-			},
-		)
-		if err != nil {
-			return err
-		}
-	}
-	vb, err = v.vipBundleRepository.UpdateByBookingID(
-		ctx,
-		vb.BookingID,
-		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
-			vipBundle.IsFinalized = true
-			vipBundle.Failed = true
-			return vipBundle, nil
-		},
-	)
-	if err != nil {
-		return err
+	failedStep := ticketsEntity.VipBundleStepInboundFlightBooked
+	if event.FlightID == vb.ReturnFlightID {
+		failedStep = ticketsEntity.VipBundleStepReturnFlightBooked
 	}
 
-	return v.eventBus.Publish(
-		ctx, ticketsEntity.VipBundleFinalized_v1{
-			Header:      ticketsEntity.NewMessageHeader(),
-			VipBundleID: vb.VipBundleID,
-			Success:     false,
-		},
-	)
+	return v.compensate(ctx, vb.VipBundleID, failedStep, event.FailureReason, event.Header.PublishedAt)
 }
 
 func (v VipBundleProcessManager) OnTaxiBooked(ctx context.Context, event *ticketsEntity.TaxiBooked_v1) error {
@@ -282,15 +338,28 @@ func (v VipBundleProcessManager) OnTaxiBooked(ctx context.Context, event *ticket
 		ctx,
 		MustParseBundleID(event.ReferenceID),
 		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if vipBundle.Failed {
+				return vipBundle, nil
+			}
 			vipBundle.TaxiBookedAt = &event.Header.PublishedAt
 			vipBundle.TaxiBookingID = &event.TaxiBookingID
 			vipBundle.IsFinalized = true
+			vipBundle.FinalizedAt = &event.Header.PublishedAt
+			vipBundle.Deadlines = nil
 			return vipBundle, nil
 		},
 	)
 	if err != nil {
 		return err
 	}
+	if vb.Failed {
+		// Taxi bookings can't be canceled yet
+		log.FromContext(ctx).With(
+			"vip_bundle_id", vb.VipBundleID,
+			"taxi_booking_id", event.TaxiBookingID,
+		).Warn("Taxi was booked after the VIP bundle failed")
+		return nil
+	}
 
 	return v.eventBus.Publish(
 		ctx, ticketsEntity.VipBundleFinalized_v1{
@@ -307,27 +376,73 @@ func (v VipBundleProcessManager) OnTaxiBookingFailed(
 ) error {
 	vipBundleID, err := uuid.Parse(event.ReferenceID)
 	if err != nil {
-		return err
+		return ticketsEntity.NewPermanentError(err)
 	}
-	vb, err := v.vipBundleRepository.Get(ctx, ticketsEntity.VipBundleID{vipBundleID})
+
+	return v.compensate(
+		ctx,
+		ticketsEntity.VipBundleID{UUID: vipBundleID},
+		ticketsEntity.VipBundleStepTaxiBooked,
+		event.FailureReason,
+		event.Header.PublishedAt,
+	)
+}
+
+func (v VipBundleProcessManager) OnStepTimedOut(
+	ctx context.Context,
+	event *ticketsEntity.InternalVipBundleStepTimedOut,
+) error {
+	reason := fmt.Sprintf("%s step timed out at %s", event.Step, event.Deadline.Format(time.RFC3339))
+
+	return v.compensate(ctx, event.VipBundleID, event.Step, reason, event.Header.PublishedAt)
+}
+
+// compensate fails the bundle at the step, unless the step was completed or the bundle was finalized before.
+// Then it releases everything booked for the failed bundle, retries only repeat the compensation.
+func (v VipBundleProcessManager) compensate(
+	ctx context.Context,
+	vipBundleID ticketsEntity.VipBundleID,
+	failedStep ticketsEntity.VipBundleStep,
+	reason string,
+	failedAt time.Time,
+) error {
+	vb, err := v.vipBundleRepository.UpdateByID(
+		ctx,
+		vipBundleID,
+		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if !vipBundle.IsFinalized && vipBundle.StepCompletedAt(failedStep) == nil {
+				vipBundle.MarkFailed(failedStep, reason, failedAt)
+			}
+			return vipBundle, nil
+		},
+	)
 	if err != nil {
 		return err
 	}
+	if !vb.Failed || vb.CompensatedAt != nil {
+		return nil
+	}
 
-	// Refund show tickets
-	for i := range vb.TicketIDs {
-		err := v.commandBus.Send(
-			ctx, ticketsEntity.RefundTicket{
-				Header:   ticketsEntity.NewMessageHeader(),
-				TicketID: vb.TicketIDs[i].String(),
-			},
-		)
-		if err != nil {
+	// The canceled booking releases its seats and refunds its tickets whenever they are confirmed,
+	// tickets can't be refunded one by one before Dead Nation confirms them.
+	// A booking made after the bundle failed is canceled by OnBookingMade.
+	if vb.BookingMadeAt != nil {
+		err := v.bookingCanceler.CancelBooking(ctx, vb.BookingID.String())
+		if err != nil && !errors.Is(err, ticketsDB.ErrBookingNotFound) {
 			return err
 		}
 	}
+	if vb.TicketsConfirmedAt != nil {
+		for i := range vb.TicketIDs {
+			// the same refund as of the canceled booking, so the ticket won't be refunded twice
+			err := v.commandBus.Send(ctx, ticketsEntity.NewCanceledBookingRefund(vb.BookingID.String(), vb.TicketIDs[i].String()))
+			if err != nil {
+				return err
+			}
+		}
+	}
 
-	// Cancel inbound flight tickets
+	// Cancel inbound flight tickets if they exist
 	if len(vb.InboundFlightTicketsIDs) > 0 {
 		err := v.commandBus.Send(
 			ctx, ticketsEntity.CancelFlightTickets{
@@ -339,7 +454,7 @@ func (v VipBundleProcessManager) OnTaxiBookingFailed(
 		}
 	}
 
-	// Cancel return flight tickets
+	// Cancel return flight tickets if they exist
 	if len(vb.ReturnFlightTicketsIDs) > 0 {
 		err := v.commandBus.Send(
 			ctx, ticketsEntity.CancelFlightTickets{
@@ -351,24 +466,29 @@ func (v VipBundleProcessManager) OnTaxiBookingFailed(
 		}
 	}
 
-	vb, err = v.vipBundleRepository.UpdateByBookingID(
-		ctx,
-		vb.BookingID,
-		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
-			vipBundle.IsFinalized = true
-			vipBundle.Failed = true
-			return vipBundle, nil
+	err = v.eventBus.Publish(
+		ctx, ticketsEntity.VipBundleFinalized_v1{
+			Header:        ticketsEntity.NewMessageHeader(),
+			VipBundleID:   vb.VipBundleID,
+			Success:       false,
+			FailureReason: vb.FailureReason,
 		},
 	)
 	if err != nil {
 		return err
 	}
 
-	return v.eventBus.Publish(
-		ctx, ticketsEntity.VipBundleFinalized_v1{
-			Header:      ticketsEntity.NewMessageHeader(),
-			VipBundleID: vb.VipBundleID,
-			Success:     false,
+	_, err = v.vipBundleRepository.UpdateByID(
+		ctx,
+		vb.VipBundleID,
+		func(vipBundle ticketsEntity.VipBundle) (ticketsEntity.VipBundle, error) {
+			if vipBundle.CompensatedAt == nil {
+				compensatedAt := time.Now()
+				vipBundle.CompensatedAt = &compensatedAt
+			}
+			return vipBundle, nil
 		},
 	)
+
+	return err
 }
@@ -0,0 +1,321 @@
+package message_test
+
+import (
+	"context"
+	"testing"
+	ticketsMemory "tickets/db/memory"
+	ticketsEntity "tickets/entities"
+	ticketsMessage "tickets/message"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStepTimeout = time.Minute
+
+func TestVipBundleProcessManager_timed_out_before_tickets_confirmed(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+
+	timeouts := pm.timeOut(t)
+	require.Len(t, timeouts, 2, "tickets confirmation and inbound flight booking should time out")
+
+	for _, timeout := range timeouts {
+		require.NoError(t, pm.OnStepTimedOut(ctx, &timeout), "never confirmed tickets must not be retried")
+	}
+
+	vb = pm.get(t, vb.VipBundleID)
+	assert.Equal(t, ticketsEntity.VipBundleStatusFailed, vb.Status())
+	assert.NotNil(t, vb.CompensatedAt)
+	assert.Equal(t, []string{vb.BookingID.String()}, pm.bookings.canceled, "booking should be canceled once")
+	assert.Empty(t, messagesOfType[ticketsEntity.RefundTicket](*pm.commands))
+	assert.Len(t, messagesOfType[ticketsEntity.VipBundleFinalized_v1](*pm.events), 1)
+}
+
+func TestVipBundleProcessManager_timed_out_after_tickets_confirmed(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	ticketID := uuid.New()
+	flightTicketIDs := []uuid.UUID{uuid.New()}
+
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+	require.NoError(t, pm.OnTicketBookingConfirmed(ctx, &ticketsEntity.TicketBookingConfirmed_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+		TicketID:  ticketID.String(),
+	}))
+	require.NoError(t, pm.OnFlightBooked(ctx, &ticketsEntity.FlightBooked_v1{
+		Header:      ticketsEntity.NewMessageHeader(),
+		FlightID:    vb.InboundFlightID,
+		TicketIDs:   flightTicketIDs,
+		ReferenceID: vb.VipBundleID.String(),
+	}))
+
+	timeouts := pm.timeOut(t)
+	require.Len(t, timeouts, 1)
+	assert.Equal(t, ticketsEntity.VipBundleStepReturnFlightBooked, timeouts[0].Step)
+
+	// redelivered timeout must not compensate twice
+	for range 2 {
+		require.NoError(t, pm.OnStepTimedOut(ctx, &timeouts[0]))
+	}
+
+	vb = pm.get(t, vb.VipBundleID)
+	assert.Equal(t, ticketsEntity.VipBundleStepReturnFlightBooked, vb.FailedStep)
+	assert.Contains(t, vb.FailureReason, "return_flight_booked step timed out")
+	assert.Equal(t, []string{vb.BookingID.String()}, pm.bookings.canceled, "booking should be canceled once")
+
+	refunds := messagesOfType[ticketsEntity.RefundTicket](*pm.commands)
+	require.Len(t, refunds, 1)
+	assert.Equal(t, ticketID.String(), refunds[0].TicketID)
+
+	canceledFlights := messagesOfType[ticketsEntity.CancelFlightTickets](*pm.commands)
+	require.Len(t, canceledFlights, 1)
+	assert.Equal(t, flightTicketIDs, canceledFlights[0].FlightTicketIDs)
+
+	assert.Len(t, messagesOfType[ticketsEntity.VipBundleFinalized_v1](*pm.events), 1)
+}
+
+func TestVipBundleProcessManager_return_flight_booking_failed_after_tickets_confirmed(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	ticketID := uuid.New()
+
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+	require.NoError(t, pm.OnTicketBookingConfirmed(ctx, &ticketsEntity.TicketBookingConfirmed_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+		TicketID:  ticketID.String(),
+	}))
+	require.NoError(t, pm.OnFlightBooked(ctx, &ticketsEntity.FlightBooked_v1{
+		Header:      ticketsEntity.NewMessageHeader(),
+		FlightID:    vb.InboundFlightID,
+		TicketIDs:   []uuid.UUID{uuid.New()},
+		ReferenceID: vb.VipBundleID.String(),
+	}))
+	require.NoError(t, pm.OnFlightBookingFailed(ctx, &ticketsEntity.FlightBookingFailed_v1{
+		Header:        ticketsEntity.NewMessageHeader(),
+		FlightID:      vb.ReturnFlightID,
+		FailureReason: "no seats",
+		ReferenceID:   vb.VipBundleID.String(),
+	}))
+
+	vb = pm.get(t, vb.VipBundleID)
+	assert.Equal(t, ticketsEntity.VipBundleStatusFailed, vb.Status())
+	assert.Equal(t, []string{vb.BookingID.String()}, pm.bookings.canceled, "seats of the booking should be released")
+
+	// the canceled booking refunds the ticket with the same key, so it's refunded once
+	refunds := messagesOfType[ticketsEntity.RefundTicket](*pm.commands)
+	require.Len(t, refunds, 1)
+	expectedRefund := ticketsEntity.NewCanceledBookingRefund(vb.BookingID.String(), ticketID.String())
+	assert.Equal(t, expectedRefund.TicketID, refunds[0].TicketID)
+	assert.Equal(t, expectedRefund.Header.IdempotencyKey, refunds[0].Header.IdempotencyKey)
+}
+
+func TestVipBundleProcessManager_taxi_booking_failed_before_tickets_confirmed(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	inboundTicketIDs := []uuid.UUID{uuid.New()}
+	returnTicketIDs := []uuid.UUID{uuid.New()}
+
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+	require.NoError(t, pm.OnFlightBooked(ctx, &ticketsEntity.FlightBooked_v1{
+		Header:      ticketsEntity.NewMessageHeader(),
+		FlightID:    vb.InboundFlightID,
+		TicketIDs:   inboundTicketIDs,
+		ReferenceID: vb.VipBundleID.String(),
+	}))
+	require.NoError(t, pm.OnFlightBooked(ctx, &ticketsEntity.FlightBooked_v1{
+		Header:      ticketsEntity.NewMessageHeader(),
+		FlightID:    vb.ReturnFlightID,
+		TicketIDs:   returnTicketIDs,
+		ReferenceID: vb.VipBundleID.String(),
+	}))
+
+	// compensated right away, without waiting for Dead Nation to confirm the tickets
+	require.NoError(t, pm.OnTaxiBookingFailed(ctx, &ticketsEntity.TaxiBookingFailed_v1{
+		Header:        ticketsEntity.NewMessageHeader(),
+		FailureReason: "no taxis",
+		ReferenceID:   vb.VipBundleID.String(),
+	}))
+
+	vb = pm.get(t, vb.VipBundleID)
+	assert.Equal(t, ticketsEntity.VipBundleStatusFailed, vb.Status())
+	assert.Equal(t, ticketsEntity.VipBundleStepTaxiBooked, vb.FailedStep)
+	assert.Equal(t, "no taxis", vb.FailureReason)
+	assert.Equal(t, []string{vb.BookingID.String()}, pm.bookings.canceled)
+	assert.Empty(t, messagesOfType[ticketsEntity.RefundTicket](*pm.commands))
+	assert.Len(t, messagesOfType[ticketsEntity.CancelFlightTickets](*pm.commands), 2)
+	assert.Len(t, messagesOfType[ticketsEntity.VipBundleFinalized_v1](*pm.events), 1)
+}
+
+func TestVipBundleProcessManager_timed_out_step_completed_before(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	timeouts := pm.timeOut(t)
+	require.Len(t, timeouts, 1)
+	assert.Equal(t, ticketsEntity.VipBundleStepBookingMade, timeouts[0].Step)
+
+	// the booking was made while the timeout was on its way
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+	require.NoError(t, pm.OnStepTimedOut(ctx, &timeouts[0]))
+
+	vb = pm.get(t, vb.VipBundleID)
+	assert.Equal(t, ticketsEntity.VipBundleStatusInProgress, vb.Status())
+	assert.Empty(t, pm.bookings.canceled)
+	assert.Empty(t, messagesOfType[ticketsEntity.VipBundleFinalized_v1](*pm.events))
+}
+
+func TestVipBundleProcessManager_booking_made_after_timeout(t *testing.T) {
+	ctx := context.Background()
+	pm := newVipBundleProcessManagerTest(t)
+
+	vb := pm.startBundle(t)
+	timeouts := pm.timeOut(t)
+	require.Len(t, timeouts, 1)
+	require.NoError(t, pm.OnStepTimedOut(ctx, &timeouts[0]))
+	assert.Empty(t, pm.bookings.canceled, "there is no booking to cancel yet")
+
+	require.NoError(t, pm.OnBookingMade(ctx, &ticketsEntity.BookingMade_v1{
+		Header:    ticketsEntity.NewMessageHeader(),
+		BookingID: vb.BookingID.String(),
+	}))
+
+	assert.Equal(t, []string{vb.BookingID.String()}, pm.bookings.canceled)
+	assert.Empty(t, messagesOfType[ticketsEntity.BookFlight](*pm.commands))
+}
+
+type vipBundleProcessManagerTest struct {
+	*ticketsMessage.VipBundleProcessManager
+
+	repository *ticketsMemory.VipBundleRepository
+	bookings   *bookingCancelerStub
+	commands   *busStub
+	events     *busStub
+}
+
+func newVipBundleProcessManagerTest(t *testing.T) vipBundleProcessManagerTest {
+	t.Helper()
+
+	commands := &busStub{}
+	events := &busStub{}
+	bookings := &bookingCancelerStub{}
+	repository := ticketsMemory.NewVipBundleRepository(ticketsMemory.NewDB(), events)
+
+	return vipBundleProcessManagerTest{
+		VipBundleProcessManager: ticketsMessage.NewVipBundleProcessManager(
+			commands,
+			events,
+			repository,
+			bookings,
+			testStepTimeout,
+		),
+		repository: repository,
+		bookings:   bookings,
+		commands:   commands,
+		events:     events,
+	}
+}
+
+func (p vipBundleProcessManagerTest) startBundle(t *testing.T) ticketsEntity.VipBundle {
+	t.Helper()
+
+	vb := ticketsEntity.VipBundle{
+		VipBundleID:     ticketsEntity.VipBundleID{UUID: uuid.New()},
+		CreatedAt:       time.Now(),
+		BookingID:       uuid.New(),
+		CustomerEmail:   "vip@example.com",
+		NumberOfTickets: 1,
+		ShowID:          uuid.New(),
+		Passengers:      []string{"John Doe"},
+		InboundFlightID: uuid.New(),
+		ReturnFlightID:  uuid.New(),
+	}
+	require.NoError(t, p.repository.Add(context.Background(), vb))
+	require.NoError(t, p.OnVipBundleInitialized(context.Background(), &ticketsEntity.VipBundleInitialized_v1{
+		Header:      ticketsEntity.NewMessageHeader(),
+		VipBundleID: vb.VipBundleID,
+	}))
+
+	return vb
+}
+
+// timeOut times out every pending step and returns the published timeouts.
+func (p vipBundleProcessManagerTest) timeOut(t *testing.T) []ticketsEntity.InternalVipBundleStepTimedOut {
+	t.Helper()
+
+	published := len(*p.events)
+	_, err := p.repository.TimeOutVipBundles(context.Background(), time.Now().Add(testStepTimeout+time.Second), 10)
+	require.NoError(t, err)
+
+	return messagesOfType[ticketsEntity.InternalVipBundleStepTimedOut]((*p.events)[published:])
+}
+
+func (p vipBundleProcessManagerTest) get(t *testing.T, vipBundleID ticketsEntity.VipBundleID) ticketsEntity.VipBundle {
+	t.Helper()
+
+	vb, err := p.repository.Get(context.Background(), vipBundleID)
+	require.NoError(t, err)
+
+	return vb
+}
+
+type busStub []any
+
+func (b *busStub) Send(ctx context.Context, command any) error {
+	*b = append(*b, command)
+	return nil
+}
+
+func (b *busStub) Publish(ctx context.Context, event any) error {
+	*b = append(*b, event)
+	return nil
+}
+
+type bookingCancelerStub struct {
+	canceled []string
+}
+
+func (b *bookingCancelerStub) CancelBooking(ctx context.Context, bookingID string) error {
+	b.canceled = append(b.canceled, bookingID)
+	return nil
+}
+
+func messagesOfType[T any](messages busStub) []T {
+	var result []T
+	for _, msg := range messages {
+		if typed, ok := msg.(T); ok {
+			result = append(result, typed)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,90 @@
+package message
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const vipBundleDeadlinesBatchSize = 100
+
+var (
+	vipBundleTimeoutsCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "vip_bundles",
+			Name:      "step_timeouts_total",
+			Help:      "The total number of VIP bundle steps that didn't complete before their deadline",
+		},
+	)
+	vipBundleDeadlinesFailuresCounter = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "vip_bundles",
+			Name:      "deadlines_check_failures_total",
+			Help:      "The total number of failed checks of VIP bundle deadlines",
+		},
+	)
+)
+
+type VipBundleDeadlinesRepository interface {
+	// TimeOutVipBundles clears the deadlines that passed before now and publishes
+	// InternalVipBundleStepTimedOut for each of them, it returns how many bundles timed out.
+	TimeOutVipBundles(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// VipBundleDeadlines lets the process manager know about bundles stuck in a step past its deadline.
+type VipBundleDeadlines struct {
+	repository VipBundleDeadlinesRepository
+	interval   time.Duration
+}
+
+func NewVipBundleDeadlines(repository VipBundleDeadlinesRepository, interval time.Duration) VipBundleDeadlines {
+	if repository == nil {
+		panic("repository is nil")
+	}
+	if interval <= 0 {
+		panic("interval must be positive")
+	}
+
+	return VipBundleDeadlines{repository: repository, interval: interval}
+}
+
+// Run checks the deadlines periodically until ctx is done.
+func (d VipBundleDeadlines) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		timedOut, err := d.Check(ctx)
+		if err != nil && ctx.Err() == nil {
+			vipBundleDeadlinesFailuresCounter.Inc()
+			log.FromContext(ctx).With("error", err).Error("Could not check VIP bundle deadlines")
+		} else if timedOut > 0 {
+			log.FromContext(ctx).With("timed_out", timedOut).Info("VIP bundles timed out")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check times out all bundles past their deadline, in batches.
+func (d VipBundleDeadlines) Check(ctx context.Context) (int, error) {
+	var total int
+	for {
+		timedOut, err := d.repository.TimeOutVipBundles(ctx, time.Now(), vipBundleDeadlinesBatchSize)
+		total += timedOut
+		vipBundleTimeoutsCounter.Add(float64(timedOut))
+		if err != nil {
+			return total, err
+		}
+		if timedOut < vipBundleDeadlinesBatchSize {
+			return total, nil
+		}
+	}
+}
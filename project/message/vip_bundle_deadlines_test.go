@@ -0,0 +1,62 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	ticketsMessage "tickets/message"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVipBundleDeadlines_Check(t *testing.T) {
+	repository := &vipBundleDeadlinesRepositoryStub{pastDeadline: 250}
+	deadlines := ticketsMessage.NewVipBundleDeadlines(repository, time.Second)
+
+	timedOut, err := deadlines.Check(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 250, timedOut)
+	assert.Equal(t, 3, repository.calls, "bundles should be timed out in batches until there are none left")
+	assert.Equal(t, 0, repository.pastDeadline)
+}
+
+func TestVipBundleDeadlines_Check_error(t *testing.T) {
+	repository := &vipBundleDeadlinesRepositoryStub{pastDeadline: 250, err: errors.New("connection refused")}
+	deadlines := ticketsMessage.NewVipBundleDeadlines(repository, time.Second)
+
+	_, err := deadlines.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, repository.calls)
+}
+
+func TestVipBundleDeadlines_Run(t *testing.T) {
+	repository := &vipBundleDeadlinesRepositoryStub{}
+	deadlines := ticketsMessage.NewVipBundleDeadlines(repository, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, deadlines.Run(ctx))
+	assert.Greater(t, repository.calls, 1, "deadlines should be checked periodically")
+}
+
+type vipBundleDeadlinesRepositoryStub struct {
+	pastDeadline int
+	calls        int
+	err          error
+}
+
+func (r *vipBundleDeadlinesRepositoryStub) TimeOutVipBundles(ctx context.Context, now time.Time, limit int) (int, error) {
+	r.calls++
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	timedOut := min(limit, r.pastDeadline)
+	r.pastDeadline -= timedOut
+
+	return timedOut, nil
+}
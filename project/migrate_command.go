@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	ticketsDB "tickets/db"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/jmoiron/sqlx"
+)
+
+const migrateCommandName = "migrate"
+
+// runMigrateCommand handles `migrate up`, `migrate down [-steps n]` and `migrate status`.
+func runMigrateCommand(ctx context.Context, db *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s up|down|status", migrateCommandName)
+	}
+
+	migrator := ticketsDB.NewMigrator(db)
+	logger := log.FromContext(ctx)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			logger.With("version", migration.Version, "name", migration.Name).Info("Migration applied")
+		}
+		logger.With("applied", len(applied)).Info("Database is up to date")
+	case "down":
+		flags := flag.NewFlagSet(migrateCommandName+" down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be positive")
+		}
+
+		rolledBack, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		for _, migration := range rolledBack {
+			logger.With("version", migration.Version, "name", migration.Name).Info("Migration rolled back")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown %s command %q, expected up, down or status", migrateCommandName, args[0])
+	}
+
+	return nil
+}
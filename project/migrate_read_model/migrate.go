@@ -6,154 +6,76 @@ import (
 	"fmt"
 	ticketsDB "tickets/db"
 	ticketsEntity "tickets/entities"
-	"time"
-
-	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"tickets/upcasting"
 )
 
-type EventsRepository interface {
-	GetEvents(ctx context.Context) ([]ticketsEntity.DataLakeEvent, error)
-}
-
-func unmarshalDataLakeEvent[T any](event ticketsEntity.DataLakeEvent) (*T, error) {
+func unmarshalDataLakeEvent[T any](eventName string, payload []byte) (*T, error) {
 	eventInstance := new(T)
 
-	err := json.Unmarshal(event.EventPayload, &eventInstance)
+	err := json.Unmarshal(payload, &eventInstance)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal event %s: %w", event.EventName, err)
+		return nil, fmt.Errorf("could not unmarshal event %s: %w", eventName, err)
 	}
 
 	return eventInstance, nil
 }
 
-func migrateEvent(ctx context.Context, event ticketsEntity.DataLakeEvent, rm ticketsDB.OpsBookingReadModel) error {
-	switch event.EventName {
-	case "BookingMade_v0":
-		bookingMade, err := unmarshalDataLakeEvent[bookingMade_v0](event)
-		if err != nil {
-			return err
-		}
+func migrateEvent(
+	ctx context.Context,
+	event ticketsEntity.DataLakeEvent,
+	rm ticketsDB.OpsBookingReadModel,
+	upcasters *upcasting.Registry,
+) error {
+	eventName, payload, err := upcasters.Upcast(event.EventName, event.EventPayload)
+	if err != nil {
+		return err
+	}
 
-		return rm.OnBookingMade(
-			ctx, &ticketsEntity.BookingMade_v1{
-				// you should map v0 event to your v1 event here
-				Header:          bookingMade.Header,
-				NumberOfTickets: bookingMade.NumberOfTickets,
-				BookingID:       bookingMade.BookingID.String(),
-				CustomerEmail:   bookingMade.CustomerEmail,
-				ShowID:          bookingMade.ShowID.String(),
-			},
-		)
-	case "TicketBookingConfirmed_v0":
-		bookingConfirmedEvent, err := unmarshalDataLakeEvent[ticketBookingConfirmed_v0](event)
+	switch eventName {
+	case "BookingMade_v1":
+		bookingMade, err := unmarshalDataLakeEvent[ticketsEntity.BookingMade_v1](eventName, payload)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketBookingConfirmed(
-			ctx, &ticketsEntity.TicketBookingConfirmed_v1{
-				// you should map v0 event to your v1 event here
-				Header:        bookingConfirmedEvent.Header,
-				TicketID:      bookingConfirmedEvent.TicketID,
-				CustomerEmail: bookingConfirmedEvent.CustomerEmail,
-				Price:         bookingConfirmedEvent.Price,
-				BookingID:     bookingConfirmedEvent.BookingID,
-			},
-		)
-	case "TicketReceiptIssued_v0":
-		receiptIssuedEvent, err := unmarshalDataLakeEvent[ticketReceiptIssued_v0](event)
+		return rm.OnBookingMade(ctx, bookingMade)
+	case "TicketBookingConfirmed_v1":
+		bookingConfirmed, err := unmarshalDataLakeEvent[ticketsEntity.TicketBookingConfirmed_v1](eventName, payload)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketReceiptIssued(
-			ctx, &ticketsEntity.TicketReceiptIssued_v1{
-				// you should map v0 event to your v1 event here
-				Header:        receiptIssuedEvent.Header,
-				TicketID:      receiptIssuedEvent.TicketID,
-				ReceiptNumber: receiptIssuedEvent.ReceiptNumber,
-				IssuedAt:      receiptIssuedEvent.IssuedAt,
-			},
-		)
-	case "TicketPrinted_v0":
-		ticketPrintedEvent, err := unmarshalDataLakeEvent[ticketPrinted_v0](event)
+		return rm.OnTicketBookingConfirmed(ctx, bookingConfirmed)
+	case "TicketReceiptIssued_v1":
+		receiptIssued, err := unmarshalDataLakeEvent[ticketsEntity.TicketReceiptIssued_v1](eventName, payload)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketPrinted(
-			ctx, &ticketsEntity.TicketPrinted_v1{
-				// you should map v0 event to your v1 event here
-				Header:   ticketPrintedEvent.Header,
-				TicketID: ticketPrintedEvent.TicketID,
-				FileName: ticketPrintedEvent.FileName,
-			},
-		)
-	case "TicketRefunded_v0":
-		ticketRefundedEvent, err := unmarshalDataLakeEvent[ticketRefunded_v0](event)
+		return rm.OnTicketReceiptIssued(ctx, receiptIssued)
+	case "TicketPrinted_v1":
+		ticketPrinted, err := unmarshalDataLakeEvent[ticketsEntity.TicketPrinted_v1](eventName, payload)
 		if err != nil {
 			return err
 		}
 
-		return rm.OnTicketRefunded(
-			ctx, &ticketsEntity.TicketRefunded_v1{
-				// you should map v0 event to your v1 event here
-				Header:   ticketRefundedEvent.Header,
-				TicketID: ticketRefundedEvent.TicketID,
-			},
-		)
-	case "VipBundleInitialized_v1", "VipBundleFinalized_v1", "FlightBooked_v1", "FlightBookingFailed_v1":
-		// VIP bundle events are not relevant to the ops read model, skip them
-		return nil
-	default:
-		return fmt.Errorf("unknown event %s", event.EventName)
-	}
-}
-
-func MigrateReadModel(ctx context.Context, dl ticketsDB.EventsRepository, rm ticketsDB.OpsBookingReadModel) error {
-	var events []ticketsEntity.DataLakeEvent
-
-	logger := log.FromContext(ctx)
-	logger.Info("Migrating read model")
-
-	timeout := time.Now().Add(time.Second * 10)
-
-	// events are not immediately available in the data lake, so we need to wait for them
-	for {
-		var err error
-		events, err = dl.GetEvents(ctx)
+		return rm.OnTicketPrinted(ctx, ticketPrinted)
+	case "TicketRefunded_v1":
+		ticketRefunded, err := unmarshalDataLakeEvent[ticketsEntity.TicketRefunded_v1](eventName, payload)
 		if err != nil {
-			return fmt.Errorf("could not get events from data lake: %w", err)
-		}
-		if len(events) > 0 {
-			break
-		}
-
-		if time.Now().After(timeout) {
-			return fmt.Errorf("timeout while waiting for events in data lake")
+			return err
 		}
 
-		time.Sleep(time.Millisecond * 100)
-	}
-
-	logger.With("events_count", len(events)).Info("Has events to migrate")
-
-	for _, event := range events {
-		start := time.Now()
-
-		logger := log.FromContext(ctx)
-		logger.With(
-			"event_name", event.EventName,
-			"event_id", event.EventID,
-		).Info("Migrating event")
-
-		err := migrateEvent(ctx, event, rm)
+		return rm.OnTicketRefunded(ctx, ticketRefunded)
+	case "BookingCanceled_v1":
+		bookingCanceled, err := unmarshalDataLakeEvent[ticketsEntity.BookingCanceled_v1](eventName, payload)
 		if err != nil {
-			return fmt.Errorf("could not migrate event %s (%s): %w", event.EventID, event.EventName, err)
+			return err
 		}
 
-		logger.With("duration", time.Since(start)).Info("Event migrated")
+		return rm.OnBookingCanceled(ctx, bookingCanceled)
+	default:
+		// like the event processor, events the read model doesn't handle are skipped
+		return nil
 	}
-
-	return nil
 }
@@ -0,0 +1,251 @@
+package migrate_read_model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	ticketsDB "tickets/db"
+	ticketsEntity "tickets/entities"
+	"tickets/upcasting"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
+	"github.com/google/uuid"
+)
+
+const (
+	OpsBookingsProjection = "ops_bookings"
+
+	rebuildBatchSize = 500
+
+	// running rebuild which wasn't checkpointed for that long is considered abandoned and is taken over
+	rebuildStaleAfter      = time.Minute
+	rebuildStaleCheckEvery = 30 * time.Second
+)
+
+type EventsRepository interface {
+	StreamStoredEvents(
+		ctx context.Context,
+		filter ticketsEntity.StoredEventsFilter,
+		batchSize int,
+	) iter.Seq2[ticketsEntity.StoredDataLakeEvent, error]
+}
+
+type ProjectionRebuildRepository interface {
+	Start(ctx context.Context, projection string, liveTable string) (ticketsEntity.ProjectionRebuild, error)
+	Get(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error)
+	List(ctx context.Context, projection string) ([]ticketsEntity.ProjectionRebuild, error)
+	ClaimStale(
+		ctx context.Context,
+		projection string,
+		staleAfter time.Duration,
+	) ([]ticketsEntity.ProjectionRebuild, error)
+	Resume(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error)
+	SaveCheckpoint(
+		ctx context.Context,
+		rebuildID uuid.UUID,
+		checkpoint ticketsEntity.StoredEventsCheckpoint,
+		eventsProcessed int64,
+	) error
+	Fail(ctx context.Context, rebuildID uuid.UUID, reason error) error
+	SwitchOver(
+		ctx context.Context,
+		rebuild ticketsEntity.ProjectionRebuild,
+		liveTable string,
+		catchUp func(ctx context.Context) error,
+	) error
+}
+
+// Rebuilder replays the data lake into a shadow table of the ops bookings read model
+// and replaces the live table with it when all events are applied.
+type Rebuilder struct {
+	eventsRepository  EventsRepository
+	rebuildRepository ProjectionRebuildRepository
+	readModel         ticketsDB.OpsBookingReadModel
+	upcasters         *upcasting.Registry
+
+	queue chan ticketsEntity.ProjectionRebuild
+}
+
+func NewRebuilder(
+	eventsRepository EventsRepository,
+	rebuildRepository ProjectionRebuildRepository,
+	readModel ticketsDB.OpsBookingReadModel,
+	upcasters *upcasting.Registry,
+) *Rebuilder {
+	if eventsRepository == nil {
+		panic("missing eventsRepository")
+	}
+	if rebuildRepository == nil {
+		panic("missing rebuildRepository")
+	}
+	if upcasters == nil {
+		panic("missing upcasters")
+	}
+
+	return &Rebuilder{
+		eventsRepository:  eventsRepository,
+		rebuildRepository: rebuildRepository,
+		readModel:         readModel,
+		upcasters:         upcasters,
+		queue:             make(chan ticketsEntity.ProjectionRebuild, 1),
+	}
+}
+
+func (r *Rebuilder) Start(ctx context.Context) (ticketsEntity.ProjectionRebuild, error) {
+	rebuild, err := r.rebuildRepository.Start(ctx, OpsBookingsProjection, ticketsDB.OpsBookingsTable)
+	if err != nil {
+		return ticketsEntity.ProjectionRebuild{}, err
+	}
+
+	r.schedule(ctx, rebuild)
+
+	return rebuild, nil
+}
+
+func (r *Rebuilder) Resume(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error) {
+	rebuild, err := r.rebuildRepository.Resume(ctx, rebuildID)
+	if err != nil {
+		return ticketsEntity.ProjectionRebuild{}, err
+	}
+
+	r.schedule(ctx, rebuild)
+
+	return rebuild, nil
+}
+
+func (r *Rebuilder) Get(ctx context.Context, rebuildID uuid.UUID) (ticketsEntity.ProjectionRebuild, error) {
+	return r.rebuildRepository.Get(ctx, rebuildID)
+}
+
+func (r *Rebuilder) List(ctx context.Context) ([]ticketsEntity.ProjectionRebuild, error) {
+	return r.rebuildRepository.List(ctx, OpsBookingsProjection)
+}
+
+func (r *Rebuilder) schedule(ctx context.Context, rebuild ticketsEntity.ProjectionRebuild) {
+	select {
+	case r.queue <- rebuild:
+	default:
+		// Run is busy; the rebuild will be picked up as stale, as it's already marked as running
+		log.FromContext(ctx).With("rebuild_id", rebuild.RebuildID).Warn("Rebuild queue is full")
+	}
+}
+
+// Run executes scheduled rebuilds and takes over the ones abandoned by stopped instances.
+func (r *Rebuilder) Run(ctx context.Context) error {
+	ticker := time.NewTicker(rebuildStaleCheckEvery)
+	defer ticker.Stop()
+
+	r.rebuildStale(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case rebuild := <-r.queue:
+			r.rebuild(ctx, rebuild)
+		case <-ticker.C:
+			r.rebuildStale(ctx)
+		}
+	}
+}
+
+func (r *Rebuilder) rebuildStale(ctx context.Context) {
+	stale, err := r.rebuildRepository.ClaimStale(ctx, OpsBookingsProjection, rebuildStaleAfter)
+	if err != nil {
+		log.FromContext(ctx).With("error", err).Error("Failed to claim stale rebuilds")
+		return
+	}
+
+	for _, rebuild := range stale {
+		r.rebuild(ctx, rebuild)
+	}
+}
+
+func (r *Rebuilder) rebuild(ctx context.Context, rebuild ticketsEntity.ProjectionRebuild) {
+	logger := log.FromContext(ctx).With(
+		"rebuild_id", rebuild.RebuildID,
+		"shadow_table", rebuild.ShadowTable,
+	)
+	logger.Info("Rebuilding read model")
+
+	err := r.replay(ctx, rebuild)
+	if errors.Is(err, context.Canceled) {
+		// the instance is stopping, the rebuild will be resumed from the checkpoint by another one
+		return
+	}
+	if err != nil {
+		logger.With("error", err).Error("Failed to rebuild read model")
+
+		if err := r.rebuildRepository.Fail(context.WithoutCancel(ctx), rebuild.RebuildID, err); err != nil {
+			logger.With("error", err).Error("Failed to mark rebuild as failed")
+		}
+		return
+	}
+
+	logger.Info("Read model rebuilt")
+}
+
+func (r *Rebuilder) replay(ctx context.Context, rebuild ticketsEntity.ProjectionRebuild) error {
+	rm := r.readModel.WithTable(rebuild.ShadowTable)
+	checkpoint := rebuild.Checkpoint
+
+	// applies all events stored after the checkpoint, the checkpoint is saved every rebuildBatchSize events;
+	// unsettled events are applied only when no events are being stored, otherwise an event stored
+	// before them by a running transaction would be skipped
+	applyEvents := func(ctx context.Context, unsettled bool) error {
+		var pending int64
+
+		saveCheckpoint := func() error {
+			if pending == 0 {
+				return nil
+			}
+			if err := r.rebuildRepository.SaveCheckpoint(ctx, rebuild.RebuildID, *checkpoint, pending); err != nil {
+				return err
+			}
+			pending = 0
+			return nil
+		}
+
+		events := r.eventsRepository.StreamStoredEvents(
+			ctx,
+			ticketsEntity.StoredEventsFilter{After: checkpoint, Unsettled: unsettled},
+			rebuildBatchSize,
+		)
+		for event, err := range events {
+			if err != nil {
+				return err
+			}
+
+			if err := migrateEvent(ctx, event.DataLakeEvent, rm, r.upcasters); err != nil {
+				return fmt.Errorf("could not migrate event %s (%s): %w", event.EventID, event.EventName, err)
+			}
+
+			checkpoint = &event.StoredEventsCheckpoint
+			pending++
+
+			if pending == rebuildBatchSize {
+				if err := saveCheckpoint(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return saveCheckpoint()
+	}
+
+	if err := applyEvents(ctx, false); err != nil {
+		return err
+	}
+
+	// events stored while the shadow table was being built are applied with the live table and the data lake locked
+	return r.rebuildRepository.SwitchOver(
+		ctx,
+		rebuild,
+		ticketsDB.OpsBookingsTable,
+		func(ctx context.Context) error {
+			return applyEvents(ctx, true)
+		},
+	)
+}
@@ -5,23 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"tickets/config"
 	ticketsDB "tickets/db"
+	ticketsMemory "tickets/db/memory"
 	ticketsHttp "tickets/http"
 	ticketsMessage "tickets/message"
+	"tickets/message/broker"
 	ticketsCommand "tickets/message/command"
 	ticketsEvent "tickets/message/event"
 	ticketsOutbox "tickets/message/outbox"
 	readModelMigration "tickets/migrate_read_model"
+	"tickets/upcasting"
 
 	"github.com/ThreeDotsLabs/go-event-driven/v2/common/log"
 	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq"
-	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
@@ -33,12 +35,18 @@ import (
 )
 
 type Service struct {
-	db            *sqlx.DB
-	echoRouter    *echo.Echo
-	messageRouter *message.Router
-	opsReadModel  ticketsDB.OpsBookingReadModel
-	eventRepo     ticketsDB.EventsRepository
-	traceProvider *tracesdk.TracerProvider
+	httpAddr          string
+	db                *sqlx.DB
+	echoRouter        *echo.Echo
+	messageRouter     *message.Router
+	opsBookingUpdates *ticketsMessage.OpsBookingUpdates
+	workers           []worker
+	traceProvider     *tracesdk.TracerProvider
+}
+
+// worker runs in the background until the service is stopped.
+type worker interface {
+	Run(ctx context.Context) error
 }
 
 type ReceiptService interface {
@@ -46,7 +54,53 @@ type ReceiptService interface {
 	ticketsCommand.ReceiptsService
 }
 
+type TicketsRepository interface {
+	ticketsEvent.TicketsRepository
+	ticketsHttp.TicketsRepository
+}
+
+type BookingRepository interface {
+	ticketsEvent.BookingsRepository
+	ticketsHttp.BookingRepository
+}
+
+type ShowsRepository interface {
+	ticketsEvent.ShowsRepository
+	ticketsHttp.ShowsRepository
+}
+
+type VipBundleRepository interface {
+	ticketsHttp.VipBundleRepository
+	ticketsMessage.VipBundleDeadlinesRepository
+}
+
+type OpsBookingReadModel interface {
+	ticketsMessage.OpsBookingReadModel
+	ticketsHttp.OpsBookingReadModel
+}
+
+// storage is created after the event bus, as some repositories publish events.
+type storage struct {
+	// db is nil when the service runs in memory, like the Postgres-only components below
+	db *sqlx.DB
+
+	tickets         TicketsRepository
+	shows           ShowsRepository
+	bookings        BookingRepository
+	events          ticketsEvent.EventsRepository
+	vipBundles      VipBundleRepository
+	opsReadModel    OpsBookingReadModel
+	inbox           ticketsMessage.Inbox
+	idempotencyKeys ticketsHttp.IdempotencyKeyRepository
+
+	outboxSubscriber message.Subscriber
+	rebuilder        ticketsHttp.ProjectionRebuilder
+	outboxMonitor    ticketsHttp.OutboxMonitor
+	workers          []worker
+}
+
 func New(
+	cfg config.Config,
 	dbConn *sqlx.DB,
 	spreadsheetsAPI ticketsEvent.SpreadsheetsAPI,
 	receiptsService ReceiptService,
@@ -54,112 +108,240 @@ func New(
 	paymentService ticketsCommand.PaymentsService,
 	deadNationService ticketsEvent.DeadNationService,
 	bookFlightService ticketsCommand.BookFlightsService,
-	rdb redis.UniversalClient,
+	messageBroker broker.Broker,
+	circuitBreakers []ticketsHttp.CircuitBreaker,
+) Service {
+	if dbConn == nil {
+		panic("db is nil")
+	}
+
+	newStorage := func(
+		eventBus *cqrs.EventBus,
+		upcasters *upcasting.Registry,
+		watermillLogger watermill.LoggerAdapter,
+	) storage {
+		eventRepo := ticketsDB.NewEventsRepository(dbConn)
+		opsReadModel := ticketsDB.NewOpsBookingReadModel(dbConn, eventBus)
+
+		rebuilder := readModelMigration.NewRebuilder(
+			eventRepo,
+			ticketsDB.NewProjectionRebuildRepository(dbConn),
+			opsReadModel,
+			upcasters,
+		)
+		outboxMonitor := ticketsOutbox.NewMonitor(dbConn)
+
+		return storage{
+			db:              dbConn,
+			tickets:         ticketsDB.NewTicketsRepository(dbConn),
+			shows:           ticketsDB.NewShowsRepository(dbConn),
+			bookings:        ticketsDB.NewBookingRepository(dbConn),
+			events:          eventRepo,
+			vipBundles:      ticketsDB.NewVipBundleRepository(dbConn),
+			opsReadModel:    opsReadModel,
+			inbox:           ticketsDB.NewInboxRepository(dbConn),
+			idempotencyKeys: ticketsDB.NewIdempotencyKeyRepository(dbConn),
+
+			outboxSubscriber: ticketsOutbox.NewPostgresSubscriber(dbConn, watermillLogger),
+			rebuilder:        rebuilder,
+			outboxMonitor:    outboxMonitor,
+			workers: []worker{
+				rebuilder,
+				outboxMonitor,
+				ticketsOutbox.NewJanitor(dbConn, cfg.Outbox.Retention),
+			},
+		}
+	}
+
+	return newService(
+		cfg,
+		newStorage,
+		spreadsheetsAPI,
+		receiptsService,
+		fileService,
+		paymentService,
+		deadNationService,
+		bookFlightService,
+		messageBroker,
+		circuitBreakers,
+	)
+}
+
+// NewInMemory creates the service keeping all state in memory and passing messages through Go channels,
+// so it runs in a single process without Postgres or a message broker.
+// Events are published directly, without the outbox, and read model rebuilds are not available.
+func NewInMemory(
+	cfg config.Config,
+	spreadsheetsAPI ticketsEvent.SpreadsheetsAPI,
+	receiptsService ReceiptService,
+	fileService ticketsEvent.FilesService,
+	paymentService ticketsCommand.PaymentsService,
+	deadNationService ticketsEvent.DeadNationService,
+	bookFlightService ticketsCommand.BookFlightsService,
+	circuitBreakers []ticketsHttp.CircuitBreaker,
 ) Service {
-	traceProvider := ConfigureTraceProvider()
+	db := ticketsMemory.NewDB()
+
+	newStorage := func(
+		eventBus *cqrs.EventBus,
+		upcasters *upcasting.Registry,
+		watermillLogger watermill.LoggerAdapter,
+	) storage {
+		return storage{
+			tickets:         ticketsMemory.NewTicketsRepository(db),
+			shows:           ticketsMemory.NewShowsRepository(db),
+			bookings:        ticketsMemory.NewBookingRepository(db, eventBus),
+			events:          ticketsMemory.NewEventsRepository(db),
+			vipBundles:      ticketsMemory.NewVipBundleRepository(db, eventBus),
+			opsReadModel:    ticketsMemory.NewOpsBookingReadModel(db, eventBus),
+			inbox:           ticketsMemory.NewInboxRepository(db),
+			idempotencyKeys: ticketsMemory.NewIdempotencyKeyRepository(db),
+		}
+	}
+
+	return newService(
+		cfg,
+		newStorage,
+		spreadsheetsAPI,
+		receiptsService,
+		fileService,
+		paymentService,
+		deadNationService,
+		bookFlightService,
+		broker.NewGoChannel(watermill.NewSlogLogger(log.FromContext(context.Background()))),
+		circuitBreakers,
+	)
+}
+
+func newService(
+	cfg config.Config,
+	newStorage func(eventBus *cqrs.EventBus, upcasters *upcasting.Registry, watermillLogger watermill.LoggerAdapter) storage,
+	spreadsheetsAPI ticketsEvent.SpreadsheetsAPI,
+	receiptsService ReceiptService,
+	fileService ticketsEvent.FilesService,
+	paymentService ticketsCommand.PaymentsService,
+	deadNationService ticketsEvent.DeadNationService,
+	bookFlightService ticketsCommand.BookFlightsService,
+	messageBroker broker.Broker,
+	circuitBreakers []ticketsHttp.CircuitBreaker,
+) Service {
+	traceProvider := ConfigureTraceProvider(cfg.TracingEndpoint())
 
 	watermillLogger := watermill.NewSlogLogger(log.FromContext(context.Background()))
-	publisher := ticketsMessage.NewRedisPublisher(rdb, watermillLogger)
+	publisher := messageBroker.Publisher()
 	eventBus := ticketsEvent.NewEventBus(publisher, watermillLogger)
-	redisSubscriber, err := redisstream.NewSubscriber(
-		redisstream.SubscriberConfig{
-			Client:        rdb,
-			ConsumerGroup: "events_splitter",
-		}, watermillLogger,
-	)
+	eventsSplitterSubscriber, err := messageBroker.Subscriber(cfg.ConsumerGroups.EventsSplitter)
 	if err != nil {
 		panic(err)
 	}
 
-	redisSubscriberStore, err := redisstream.NewSubscriber(
-		redisstream.SubscriberConfig{
-			Client:        rdb,
-			ConsumerGroup: "events_store",
-		}, watermillLogger,
-	)
+	eventsStoreSubscriber, err := messageBroker.Subscriber(cfg.ConsumerGroups.EventsStore)
+	if err != nil {
+		panic(err)
+	}
+
+	opsBookingUpdatesSubscriber, err := messageBroker.FanOutSubscriber()
 	if err != nil {
 		panic(err)
 	}
 
-	ticketRepo := ticketsDB.NewTicketsRepository(dbConn)
-	showRepo := ticketsDB.NewShowsRepository(dbConn)
-	bookingRepo := ticketsDB.NewBookingRepository(dbConn)
-	eventRepo := ticketsDB.NewEventsRepository(dbConn)
-	vipBundleRepo := ticketsDB.NewVipBundleRepository(dbConn)
+	upcasters := upcasting.NewEventsRegistry()
+	store := newStorage(eventBus, upcasters, watermillLogger)
 
 	commandBus := ticketsCommand.NewCommandBus(publisher, watermillLogger)
 	commandProcessorConfig := ticketsCommand.NewCommandProcessorConfig(
-		rdb,
+		messageBroker,
 		watermillLogger,
+		cfg.ConsumerGroups.CommandHandlersPrefix,
 	)
 	commandHandler := ticketsCommand.NewCommandHandler(
 		receiptsService,
 		paymentService,
 		bookFlightService,
-		bookingRepo,
+		store.bookings,
 		eventBus,
 	)
 
-	postgresSubscriber := ticketsOutbox.NewPostgresSubscriber(dbConn, watermillLogger)
-
 	eventHandler := ticketsEvent.NewEventHandler(
 		spreadsheetsAPI,
 		receiptsService,
 		fileService,
 		deadNationService,
-		ticketRepo,
-		showRepo,
-		eventRepo,
+		store.tickets,
+		store.bookings,
+		store.shows,
+		store.events,
 		eventBus,
+		commandBus,
 	)
 	eventProcessorConfig := ticketsEvent.NewEventProcessorConfig(
-		rdb,
+		messageBroker,
 		watermillLogger,
+		upcasters,
+		cfg.ConsumerGroups.EventHandlersPrefix,
 	)
-	opsReadModel := ticketsDB.NewOpsBookingReadModel(dbConn, eventBus)
 
 	vipBundleProcessmanager := ticketsMessage.NewVipBundleProcessManager(
 		commandBus,
 		eventBus,
-		vipBundleRepo,
+		store.vipBundles,
+		store.bookings,
+		cfg.VipBundle.StepTimeout,
+	)
+	workers := append(
+		store.workers,
+		ticketsMessage.NewVipBundleDeadlines(store.vipBundles, cfg.VipBundle.DeadlinesCheckInterval),
 	)
 
 	router := ticketsMessage.NewRouter(
-		redisSubscriberStore,
-		redisSubscriber,
-		postgresSubscriber,
+		eventsStoreSubscriber,
+		eventsSplitterSubscriber,
+		store.outboxSubscriber,
 		publisher,
 		*eventProcessorConfig,
 		*commandProcessorConfig,
 		*commandHandler,
-		opsReadModel,
+		store.opsReadModel,
 		eventHandler,
 		watermillLogger,
 		vipBundleProcessmanager,
+		store.inbox,
+		cfg.RetryPolicies,
 	)
 
+	opsBookingUpdates := ticketsMessage.NewOpsBookingUpdates(opsBookingUpdatesSubscriber)
+
 	echoRouter := ticketsHttp.NewHttpRouter(
 		eventBus,
 		commandBus,
-		ticketRepo,
-		showRepo,
-		bookingRepo,
-		opsReadModel,
-		vipBundleRepo,
+		store.tickets,
+		store.shows,
+		store.bookings,
+		store.opsReadModel,
+		store.vipBundles,
+		messageBroker.PoisonQueue(),
+		store.idempotencyKeys,
+		store.rebuilder,
+		opsBookingUpdates,
+		store.outboxMonitor,
+		circuitBreakers,
 	)
 	return Service{
-		db:            dbConn,
-		echoRouter:    echoRouter,
-		messageRouter: router,
-		opsReadModel:  opsReadModel,
-		eventRepo:     eventRepo,
-		traceProvider: traceProvider,
+		httpAddr:          cfg.HTTPAddr,
+		db:                store.db,
+		echoRouter:        echoRouter,
+		messageRouter:     router,
+		opsBookingUpdates: opsBookingUpdates,
+		workers:           workers,
+		traceProvider:     traceProvider,
 	}
 }
 
 func (s Service) Run(ctx context.Context) error {
-	if err := ticketsDB.InitializeDatabaseSchema(s.db); err != nil {
-		return fmt.Errorf("failed to initialize database schema: %w", err)
+	if s.db != nil {
+		if err := ticketsDB.InitializeDatabaseSchema(s.db); err != nil {
+			return fmt.Errorf("failed to initialize database schema: %w", err)
+		}
 	}
 	errGroup, ctx := errgroup.WithContext(ctx)
 	errGroup.Go(
@@ -171,7 +353,7 @@ func (s Service) Run(ctx context.Context) error {
 		func() error {
 			<-s.messageRouter.Running()
 
-			err := s.echoRouter.Start(":8080")
+			err := s.echoRouter.Start(s.httpAddr)
 			if err != nil && !errors.Is(err, http.ErrServerClosed) {
 				return err
 			}
@@ -179,11 +361,19 @@ func (s Service) Run(ctx context.Context) error {
 		},
 	)
 
-	go func() {
-		if err := readModelMigration.MigrateReadModel(ctx, s.eventRepo, s.opsReadModel); err != nil {
-			log.FromContext(ctx).With("error", err).Error("failed to migrate read model")
-		}
-	}()
+	errGroup.Go(
+		func() error {
+			return s.opsBookingUpdates.Run(ctx)
+		},
+	)
+
+	for _, w := range s.workers {
+		errGroup.Go(
+			func() error {
+				return w.Run(ctx)
+			},
+		)
+	}
 
 	errGroup.Go(
 		func() error {
@@ -202,28 +392,29 @@ func (s Service) Run(ctx context.Context) error {
 	return errGroup.Wait()
 }
 
-func ConfigureTraceProvider() *tracesdk.TracerProvider {
-	jaegerEndpoint := os.Getenv("JAEGER_ENDPOINT")
-	if jaegerEndpoint == "" {
-		jaegerEndpoint = fmt.Sprintf("%s/jaeger-api/api/traces", os.Getenv("GATEWAY_ADDR"))
-	}
-
-	exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(jaegerEndpoint))
-	if err != nil {
-		panic(err)
-	}
-
-	tp := tracesdk.NewTracerProvider(
-		// WARNING: `tracesdk.WithSyncer` should be not used in production,
-		// for prod you should use `tracesdk.WithBatcher`
-		tracesdk.WithSyncer(exp),
+// ConfigureTraceProvider exports traces to jaegerEndpoint, they are not exported when it's empty.
+func ConfigureTraceProvider(jaegerEndpoint string) *tracesdk.TracerProvider {
+	opts := []tracesdk.TracerProviderOption{
 		tracesdk.WithResource(
 			resource.NewWithAttributes(
 				semconv.SchemaURL,
 				semconv.ServiceName("tickets"),
 			),
 		),
-	)
+	}
+
+	if jaegerEndpoint != "" {
+		exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(jaegerEndpoint))
+		if err != nil {
+			panic(err)
+		}
+
+		// WARNING: `tracesdk.WithSyncer` should be not used in production,
+		// for prod you should use `tracesdk.WithBatcher`
+		opts = append(opts, tracesdk.WithSyncer(exp))
+	}
+
+	tp := tracesdk.NewTracerProvider(opts...)
 
 	otel.SetTracerProvider(tp)
 
@@ -4,112 +4,551 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/ThreeDotsLabs/watermill"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
 	"github.com/lithammer/shortuuid/v3"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"tickets/adapters"
-	dbAdapters "tickets/db"
+	"tickets/config"
 	"tickets/entities"
 	ticketsHttp "tickets/http"
-	"tickets/message"
+	"tickets/message/broker"
 	"tickets/service"
 )
 
 func TestComponent(t *testing.T) {
 	db, err := sqlx.Open("postgres", os.Getenv("POSTGRES_URL"))
-	if err != nil {
-		panic(err)
-	}
+	require.NoError(t, err)
 	defer db.Close()
 
-	redisClient := message.NewRedisClient(os.Getenv("REDIS_ADDR"))
-	defer redisClient.Close()
+	testComponent(t, func(cfg config.Config, stubs componentStubs) service.Service {
+		return service.New(
+			cfg,
+			db,
+			stubs.spreadsheetsAPI,
+			stubs.receiptsService,
+			stubs.filesAPI,
+			stubs.paymentsService,
+			stubs.deadNationService,
+			stubs.transportationService,
+			broker.NewRedis(os.Getenv("REDIS_ADDR"), watermill.NopLogger{}),
+			nil,
+		)
+	})
+}
 
+// the service runs in memory, so the test doesn't need Postgres or Redis
+func TestComponent_in_memory(t *testing.T) {
+	testComponent(t, func(cfg config.Config, stubs componentStubs) service.Service {
+		cfg.InMemory = true
+
+		return service.NewInMemory(
+			cfg,
+			stubs.spreadsheetsAPI,
+			stubs.receiptsService,
+			stubs.filesAPI,
+			stubs.paymentsService,
+			stubs.deadNationService,
+			stubs.transportationService,
+			nil,
+		)
+	})
+}
+
+type componentStubs struct {
+	spreadsheetsAPI       *adapters.SpreadsheetsAPIStub
+	receiptsService       *adapters.ReceiptsServiceStub
+	filesAPI              *adapters.FilesApiStub
+	paymentsService       *adapters.PaymentsServiceStub
+	deadNationService     *adapters.DeadNationServiceStub
+	transportationService *adapters.TransportationServiceStub
+}
+
+func testComponent(t *testing.T, newService func(cfg config.Config, stubs componentStubs) service.Service) {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	spreadsheetsAPI := &adapters.SpreadsheetsAPIStub{}
-	receiptsService := &adapters.ReceiptsServiceStub{IssuedReceipts: map[string]entities.IssueReceiptRequest{}}
+	receiptsService := &adapters.ReceiptsServiceStub{}
 	filesAPI := &adapters.FilesApiStub{}
+	paymentsService := &adapters.PaymentsServiceStub{}
+	deadNationService := &adapters.DeadNationServiceStub{}
+	transportationService := &adapters.TransportationServiceStub{}
+
+	cfg := config.Default()
+	cfg.VipBundle.StepTimeout = 3 * time.Second
+	cfg.VipBundle.DeadlinesCheckInterval = 100 * time.Millisecond
+
+	svc := newService(cfg, componentStubs{
+		spreadsheetsAPI:       spreadsheetsAPI,
+		receiptsService:       receiptsService,
+		filesAPI:              filesAPI,
+		paymentsService:       paymentsService,
+		deadNationService:     deadNationService,
+		transportationService: transportationService,
+	})
 
+	stopped := make(chan struct{})
 	go func() {
-		svc := service.New(
-			db,
-			spreadsheetsAPI,
-			receiptsService,
-			filesAPI,
-			redisClient,
-		)
+		defer close(stopped)
 		assert.NoError(t, svc.Run(ctx))
 	}()
+	// the next test starts its service on the same port
+	defer func() {
+		cancel()
+		<-stopped
+	}()
 
 	waitForHttpServer(t)
 
-	ticket := ticketsHttp.TicketStatusRequest{
-		TicketID: uuid.NewString(),
-		Status:   "confirmed",
-		Price: entities.Money{
-			Amount:   "50.30",
-			Currency: "GBP",
-		},
-		CustomerEmail: "email@example.com",
-	}
+	t.Run("tickets", func(t *testing.T) {
+		ticket := ticketsHttp.TicketStatusRequest{
+			TicketID: uuid.NewString(),
+			Status:   "confirmed",
+			Price: entities.Money{
+				Amount:   "50.30",
+				Currency: "GBP",
+			},
+			CustomerEmail: "email@example.com",
+		}
 
-	idempotencyKey := uuid.NewString()
+		idempotencyKey := uuid.NewString()
+
+		// check idempotency
+		for i := 0; i < 3; i++ {
+			sendTicketsStatus(
+				t,
+				ticketsHttp.TicketsStatusRequest{
+					Tickets: []ticketsHttp.TicketStatusRequest{ticket},
+				},
+				idempotencyKey,
+			)
+		}
 
-	// check idempotency
-	for i := 0; i < 3; i++ {
-		sendTicketsStatus(
+		assertReceiptForTicketIssued(t, receiptsService, ticket)
+		assertTicketPrinted(t, filesAPI, ticket)
+		assertRowToSheetAdded(
 			t,
-			ticketsHttp.TicketsStatusRequest{
-				Tickets: []ticketsHttp.TicketStatusRequest{ticket},
-			},
-			idempotencyKey,
+			spreadsheetsAPI,
+			ticket,
+			"tickets-to-print",
+		)
+		assertTicketStored(t, ticket)
+
+		ticket.Status = "canceled"
+		sendTicketsStatus(t, ticketsHttp.TicketsStatusRequest{
+			Tickets: []ticketsHttp.TicketStatusRequest{ticket},
+		}, uuid.NewString())
+
+		assertRowToSheetAdded(
+			t,
+			spreadsheetsAPI,
+			ticket,
+			"tickets-to-refund",
+		)
+	})
+
+	t.Run("canceled booking", func(t *testing.T) {
+		var show struct {
+			ShowID string `json:"show_id"`
+		}
+		postJSON(t, "/shows", ticketsHttp.CreateShowRequest{
+			DeadNationID:    uuid.NewString(),
+			NumberOfTickets: 10,
+			StartTime:       time.Now().Add(24 * time.Hour),
+			Title:           "Canceled show",
+			Venue:           "Main Hall",
+		}, &show)
+
+		var booking ticketsHttp.BookingResponse
+		postJSON(t, "/book-tickets", ticketsHttp.CreateBookingRequest{
+			ShowID:          show.ShowID,
+			NumberOfTickets: 2,
+			CustomerEmail:   "email@example.com",
+		}, &booking)
+
+		newTicket := func() ticketsHttp.TicketStatusRequest {
+			return ticketsHttp.TicketStatusRequest{
+				BookingId: booking.BookingID,
+				TicketID:  uuid.NewString(),
+				Status:    "confirmed",
+				Price: entities.Money{
+					Amount:   "30.00",
+					Currency: "EUR",
+				},
+				CustomerEmail: "email@example.com",
+			}
+		}
+
+		confirmedTicket := newTicket()
+		sendTicketsStatus(t, ticketsHttp.TicketsStatusRequest{
+			Tickets: []ticketsHttp.TicketStatusRequest{confirmedTicket},
+		}, uuid.NewString())
+		assertTicketStored(t, confirmedTicket)
+
+		assert.Equal(t, http.StatusAccepted, deleteRequest(t, "/bookings/"+booking.BookingID))
+		assertTicketRefunded(t, receiptsService, paymentsService, confirmedTicket)
+
+		// Dead Nation may confirm a ticket after the booking was canceled
+		lateTicket := newTicket()
+		sendTicketsStatus(t, ticketsHttp.TicketsStatusRequest{
+			Tickets: []ticketsHttp.TicketStatusRequest{lateTicket},
+		}, uuid.NewString())
+		assertTicketRefunded(t, receiptsService, paymentsService, lateTicket)
+
+		assert.Equal(t, http.StatusBadRequest, deleteRequest(t, "/bookings/not-a-uuid"))
+		assert.Equal(t, http.StatusNotFound, deleteRequest(t, "/bookings/"+uuid.NewString()))
+	})
+
+	t.Run("vip bundle", func(t *testing.T) {
+		inboundFlightID := uuid.New()
+		returnFlightID := uuid.New()
+
+		vipBundleID, _ := bookVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+		vipBundle := assertVipBundleFinalized(t, vipBundleID, "succeeded")
+		for _, step := range vipBundle.Steps {
+			assert.Equal(t, "completed", step.Status, "step %s", step.Step)
+			assert.NotNil(t, step.At, "step %s", step.Step)
+		}
+		assert.NotNil(t, vipBundle.TaxiBookingID)
+	})
+
+	t.Run("vip bundle with sold out return flight", func(t *testing.T) {
+		inboundFlightID := uuid.New()
+		returnFlightID := uuid.New()
+		transportationService.SoldOutFlight(returnFlightID)
+
+		// the first attempt fails with a transient error and is retried
+		transportationService.BookFlightFailures.FailCall(
+			transportationService.BookFlightFailures.Calls()+1,
+			errors.New("unexpected status code 503"),
 		)
+
+		vipBundleID, ticket := bookVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+		assertTicketRefunded(t, receiptsService, paymentsService, ticket)
+		assertFlightTicketsCanceled(t, transportationService, inboundFlightID)
+		assert.Empty(t, transportationService.FlightTickets(returnFlightID))
+
+		vipBundle := assertVipBundleFinalized(t, vipBundleID, "failed")
+		assert.Equal(t, adapters.ErrNoFlightTicketsAvailable.Error(), vipBundle.FailureReason)
+		assertVipBundleStep(t, vipBundle, "inbound_flight_booked", "completed")
+		assertVipBundleStep(t, vipBundle, "return_flight_booked", "failed")
+		assertVipBundleStep(t, vipBundle, "taxi_booked", "skipped")
+	})
+
+	t.Run("vip bundle without taxi", func(t *testing.T) {
+		inboundFlightID := uuid.New()
+		returnFlightID := uuid.New()
+		transportationService.NoTaxisAvailable()
+		defer transportationService.BookTaxiFailures.Reset()
+
+		vipBundleID, ticket := bookVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+		assertTicketRefunded(t, receiptsService, paymentsService, ticket)
+		assertFlightTicketsCanceled(t, transportationService, inboundFlightID)
+		assertFlightTicketsCanceled(t, transportationService, returnFlightID)
+
+		vipBundle := assertVipBundleFinalized(t, vipBundleID, "failed")
+		assert.Equal(t, adapters.ErrWhileBookingTaxi.Error(), vipBundle.FailureReason)
+		assertVipBundleStep(t, vipBundle, "return_flight_booked", "completed")
+		assertVipBundleStep(t, vipBundle, "taxi_booked", "failed")
+	})
+
+	t.Run("vip bundle with taxi booking timed out", func(t *testing.T) {
+		inboundFlightID := uuid.New()
+		returnFlightID := uuid.New()
+		// taxi bookings are retried until the step times out
+		transportationService.BookTaxiFailures.FailAllCalls(errors.New("unexpected status code 503"))
+		defer transportationService.BookTaxiFailures.Reset()
+
+		vipBundleID, ticket := bookVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+		assertTicketRefunded(t, receiptsService, paymentsService, ticket)
+		assertFlightTicketsCanceled(t, transportationService, inboundFlightID)
+		assertFlightTicketsCanceled(t, transportationService, returnFlightID)
+
+		vipBundle := assertVipBundleFinalized(t, vipBundleID, "failed")
+		assert.Contains(t, vipBundle.FailureReason, "taxi_booked step timed out")
+		assertVipBundleStep(t, vipBundle, "return_flight_booked", "completed")
+		assertVipBundleStep(t, vipBundle, "taxi_booked", "failed")
+	})
+
+	t.Run("vip bundle timed out before tickets were confirmed", func(t *testing.T) {
+		inboundFlightID := uuid.New()
+		returnFlightID := uuid.New()
+		transportationService.BookTaxiFailures.FailAllCalls(errors.New("unexpected status code 503"))
+		defer transportationService.BookTaxiFailures.Reset()
+
+		bundle := startVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+		// the booking is canceled instead of refunding the tickets one by one
+		assertRemainingSeats(t, bundle.ShowID, 10)
+		assertFlightTicketsCanceled(t, transportationService, inboundFlightID)
+
+		vipBundle := assertVipBundleFinalized(t, bundle.VipBundleID, "failed")
+		assert.Contains(t, vipBundle.FailureReason, "step timed out")
+		// the taxi step may time out in the same check, so the failed step depends on the order of handling
+		for _, step := range vipBundle.Steps {
+			if step.Step == "tickets_confirmed" {
+				assert.NotEqual(t, "completed", step.Status)
+			}
+		}
+
+		// Dead Nation confirms the ticket after the bundle failed
+		ticket := confirmVipBundleTicket(t, bundle)
+		assertTicketRefunded(t, receiptsService, paymentsService, ticket)
+	})
+
+	t.Run("vip bundles list", func(t *testing.T) {
+		var vipBundles []vipBundleResponse
+		getJSON(t, "/vip-bundles?status=failed&customer_email=VIP@example.com", &vipBundles)
+
+		assert.Len(t, vipBundles, 4)
+		for _, vipBundle := range vipBundles {
+			assert.Equal(t, "failed", vipBundle.Status)
+		}
+	})
+}
+
+// bookVipBundle books a bundle with a single ticket and confirms the ticket like Dead Nation does.
+func bookVipBundle(
+	t *testing.T,
+	deadNationService *adapters.DeadNationServiceStub,
+	inboundFlightID uuid.UUID,
+	returnFlightID uuid.UUID,
+) (string, ticketsHttp.TicketStatusRequest) {
+	t.Helper()
+
+	bundle := startVipBundle(t, deadNationService, inboundFlightID, returnFlightID)
+
+	return bundle.VipBundleID, confirmVipBundleTicket(t, bundle)
+}
+
+type startedVipBundle struct {
+	VipBundleID string
+	BookingID   uuid.UUID
+	ShowID      string
+}
+
+// startVipBundle books a bundle with a single ticket, the ticket is not confirmed.
+func startVipBundle(
+	t *testing.T,
+	deadNationService *adapters.DeadNationServiceStub,
+	inboundFlightID uuid.UUID,
+	returnFlightID uuid.UUID,
+) startedVipBundle {
+	t.Helper()
+
+	var show struct {
+		ShowID string `json:"show_id"`
+	}
+	postJSON(t, "/shows", ticketsHttp.CreateShowRequest{
+		DeadNationID:    uuid.NewString(),
+		NumberOfTickets: 10,
+		StartTime:       time.Now().Add(24 * time.Hour),
+		Title:           "VIP show",
+		Venue:           "Main Hall",
+	}, &show)
+
+	var bundle struct {
+		BookingID   uuid.UUID `json:"booking_id"`
+		VipBundleID string    `json:"vip_bundle_id"`
 	}
+	postJSON(t, "/book-vip-bundle", map[string]any{
+		"customer_email":    "vip@example.com",
+		"inbound_flight_id": inboundFlightID,
+		"return_flight_id":  returnFlightID,
+		"number_of_tickets": 1,
+		"passengers":        []string{"John Doe"},
+		"show_id":           show.ShowID,
+	}, &bundle)
 
-	assertReceiptForTicketIssued(t, receiptsService, ticket)
-	assertTicketPrinted(t, filesAPI, ticket)
-	assertRowToSheetAdded(
+	require.EventuallyWithT(
 		t,
-		spreadsheetsAPI,
-		ticket,
-		"tickets-to-print",
+		func(t *assert.CollectT) {
+			_, ok := lo.Find(deadNationService.Bookings(), func(b entities.DeadNationBooking) bool {
+				return b.BookingID == bundle.BookingID
+			})
+			assert.True(t, ok, "booking %s not sent to Dead Nation", bundle.BookingID)
+		},
+		10*time.Second,
+		100*time.Millisecond,
 	)
-	assertTicketStoredInRepository(t, db, ticket)
 
-	ticket.Status = "canceled"
+	return startedVipBundle{
+		VipBundleID: bundle.VipBundleID,
+		BookingID:   bundle.BookingID,
+		ShowID:      show.ShowID,
+	}
+}
+
+func confirmVipBundleTicket(t *testing.T, bundle startedVipBundle) ticketsHttp.TicketStatusRequest {
+	t.Helper()
+
+	ticket := ticketsHttp.TicketStatusRequest{
+		BookingId: bundle.BookingID.String(),
+		TicketID:  uuid.NewString(),
+		Status:    "confirmed",
+		Price: entities.Money{
+			Amount:   "120.00",
+			Currency: "EUR",
+		},
+		CustomerEmail: "vip@example.com",
+	}
 	sendTicketsStatus(t, ticketsHttp.TicketsStatusRequest{
 		Tickets: []ticketsHttp.TicketStatusRequest{ticket},
 	}, uuid.NewString())
 
-	assertRowToSheetAdded(
+	return ticket
+}
+
+func assertRemainingSeats(t *testing.T, showID string, expected int) {
+	t.Helper()
+
+	assert.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			var show entities.ShowAvailability
+			resp, err := http.Get("http://localhost:8080/shows/" + showID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			if !assert.NoError(t, json.NewDecoder(resp.Body).Decode(&show)) {
+				return
+			}
+			assert.Equal(t, expected, show.RemainingSeats)
+		},
+		20*time.Second,
+		100*time.Millisecond,
+	)
+}
+
+type vipBundleResponse struct {
+	Status        string     `json:"status"`
+	FailureReason string     `json:"failure_reason"`
+	TaxiBookingID *uuid.UUID `json:"taxi_booking_id"`
+	Steps         []struct {
+		Step   string     `json:"step"`
+		Status string     `json:"status"`
+		At     *time.Time `json:"at"`
+	} `json:"steps"`
+}
+
+func assertVipBundleFinalized(t *testing.T, vipBundleID string, status string) vipBundleResponse {
+	t.Helper()
+
+	var vipBundle vipBundleResponse
+	require.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			resp, err := http.Get("http://localhost:8080/vip-bundles/" + vipBundleID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+
+			vipBundle = vipBundleResponse{}
+			if !assert.NoError(t, json.NewDecoder(resp.Body).Decode(&vipBundle)) {
+				return
+			}
+			assert.Equal(t, status, vipBundle.Status)
+		},
+		20*time.Second,
+		100*time.Millisecond,
+	)
+
+	return vipBundle
+}
+
+func assertVipBundleStep(t *testing.T, vipBundle vipBundleResponse, step string, status string) {
+	t.Helper()
+
+	for _, s := range vipBundle.Steps {
+		if s.Step == step {
+			assert.Equal(t, status, s.Status, "step %s", step)
+			return
+		}
+	}
+
+	t.Errorf("step %s not found", step)
+}
+
+func assertTicketRefunded(
+	t *testing.T,
+	receiptsService *adapters.ReceiptsServiceStub,
+	paymentsService *adapters.PaymentsServiceStub,
+	ticket ticketsHttp.TicketStatusRequest,
+) {
+	t.Helper()
+
+	assert.EventuallyWithT(
 		t,
-		spreadsheetsAPI,
-		ticket,
-		"tickets-to-refund",
+		func(t *assert.CollectT) {
+			_, ok := lo.Find(receiptsService.RefundedReceipts(), func(r entities.RefundTicket) bool {
+				return r.TicketID == ticket.TicketID
+			})
+			assert.True(t, ok, "receipt for ticket %s not refunded", ticket.TicketID)
+
+			_, ok = lo.Find(paymentsService.Refunds(), func(r entities.PaymentRefund) bool {
+				return r.TicketID == ticket.TicketID
+			})
+			assert.True(t, ok, "payment for ticket %s not refunded", ticket.TicketID)
+		},
+		20*time.Second,
+		100*time.Millisecond,
 	)
 }
 
-func assertTicketStoredInRepository(t *testing.T, db *sqlx.DB, ticket ticketsHttp.TicketStatusRequest) {
-	ticketsRepo := dbAdapters.NewTicketsRepository(db)
+func assertFlightTicketsCanceled(
+	t *testing.T,
+	transportationService *adapters.TransportationServiceStub,
+	flightID uuid.UUID,
+) {
+	t.Helper()
+
+	assert.EventuallyWithT(
+		t,
+		func(t *assert.CollectT) {
+			flightTickets := transportationService.FlightTickets(flightID)
+			if !assert.NotEmpty(t, flightTickets, "flight %s not booked", flightID) {
+				return
+			}
+
+			assert.Subset(t, transportationService.CanceledFlightTickets(), flightTickets)
+		},
+		20*time.Second,
+		100*time.Millisecond,
+	)
+}
 
+func assertTicketStored(t *testing.T, ticket ticketsHttp.TicketStatusRequest) {
 	assert.Eventually(
 		t,
 		func() bool {
-			tickets, err := ticketsRepo.FindAll(context.Background())
+			resp, err := http.Get("http://localhost:8080/tickets")
 			if err != nil {
 				return false
 			}
+			defer resp.Body.Close()
+
+			var tickets []entities.Ticket
+			if err := json.NewDecoder(resp.Body).Decode(&tickets); err != nil {
+				return false
+			}
 
 			for _, t := range tickets {
 				if t.TicketID == ticket.TicketID {
@@ -130,8 +569,8 @@ func assertRowToSheetAdded(t *testing.T, spreadsheetsAPI *adapters.SpreadsheetsA
 	return assert.EventuallyWithT(
 		t,
 		func(t *assert.CollectT) {
-			rows, ok := spreadsheetsAPI.Rows[sheetName]
-			if !assert.True(t, ok, "sheet %s not found", sheetName) {
+			rows := spreadsheetsAPI.Rows(sheetName)
+			if !assert.NotEmpty(t, rows, "sheet %s not found", sheetName) {
 				return
 			}
 
@@ -195,7 +634,9 @@ func assertReceiptForTicketIssued(t *testing.T, receiptsService *adapters.Receip
 	assert.EventuallyWithT(
 		t,
 		func(t *assert.CollectT) {
-			issuedReceipts := len(receiptsService.IssuedReceipts)
+			issuedReceipts := lo.CountBy(receiptsService.IssuedReceipts(), func(r entities.IssueReceiptRequest) bool {
+				return r.TicketID == ticket.TicketID
+			})
 			parentT.Log("issued receipts", issuedReceipts)
 
 			assert.Equal(t, 1, issuedReceipts, "receipt for ticket %s not found", ticket.TicketID)
@@ -204,7 +645,7 @@ func assertReceiptForTicketIssued(t *testing.T, receiptsService *adapters.Receip
 		100*time.Millisecond,
 	)
 
-	receipt, ok := lo.Find(lo.Values(receiptsService.IssuedReceipts), func(r entities.IssueReceiptRequest) bool {
+	receipt, ok := lo.Find(receiptsService.IssuedReceipts(), func(r entities.IssueReceiptRequest) bool {
 		return r.TicketID == ticket.TicketID
 	})
 	require.Truef(t, ok, "receipt for ticket %s not found", ticket.TicketID)
@@ -238,6 +679,54 @@ func sendTicketsStatus(t *testing.T, req ticketsHttp.TicketsStatusRequest, idemp
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func postJSON(t *testing.T, path string, request any, response any) {
+	t.Helper()
+
+	payload, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	httpReq, err := http.NewRequest(
+		http.MethodPost,
+		"http://localhost:8080"+path,
+		bytes.NewBuffer(payload),
+	)
+	require.NoError(t, err)
+
+	httpReq.Header.Set("Idempotency-Key", uuid.NewString())
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+}
+
+func deleteRequest(t *testing.T, path string) int {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(http.MethodDelete, "http://localhost:8080"+path, nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func getJSON(t *testing.T, path string, response any) {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8080" + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(response))
+}
+
 func waitForHttpServer(t *testing.T) {
 	t.Helper()
 
@@ -0,0 +1,52 @@
+package upcasting
+
+import (
+	"tickets/entities"
+)
+
+// NewEventsRegistry returns the registry with upcasters of all events published by svc-tickets.
+func NewEventsRegistry() *Registry {
+	r := NewRegistry()
+
+	r.Register("BookingMade", 0, Typed(func(e bookingMade_v0) entities.BookingMade_v1 {
+		return entities.BookingMade_v1{
+			Header:          e.Header,
+			NumberOfTickets: e.NumberOfTickets,
+			BookingID:       e.BookingID.String(),
+			CustomerEmail:   e.CustomerEmail,
+			ShowID:          e.ShowID.String(),
+		}
+	}))
+	r.Register("TicketBookingConfirmed", 0, Typed(func(e ticketBookingConfirmed_v0) entities.TicketBookingConfirmed_v1 {
+		return entities.TicketBookingConfirmed_v1{
+			Header:        e.Header,
+			TicketID:      e.TicketID,
+			CustomerEmail: e.CustomerEmail,
+			Price:         e.Price,
+			BookingID:     e.BookingID,
+		}
+	}))
+	r.Register("TicketReceiptIssued", 0, Typed(func(e ticketReceiptIssued_v0) entities.TicketReceiptIssued_v1 {
+		return entities.TicketReceiptIssued_v1{
+			Header:        e.Header,
+			TicketID:      e.TicketID,
+			ReceiptNumber: e.ReceiptNumber,
+			IssuedAt:      e.IssuedAt,
+		}
+	}))
+	r.Register("TicketPrinted", 0, Typed(func(e ticketPrinted_v0) entities.TicketPrinted_v1 {
+		return entities.TicketPrinted_v1{
+			Header:   e.Header,
+			TicketID: e.TicketID,
+			FileName: e.FileName,
+		}
+	}))
+	r.Register("TicketRefunded", 0, Typed(func(e ticketRefunded_v0) entities.TicketRefunded_v1 {
+		return entities.TicketRefunded_v1{
+			Header:   e.Header,
+			TicketID: e.TicketID,
+		}
+	}))
+
+	return r
+}
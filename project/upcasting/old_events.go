@@ -1,4 +1,4 @@
-package migrate_read_model
+package upcasting
 
 import (
 	"tickets/entities"
@@ -0,0 +1,126 @@
+package upcasting
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Upcaster converts the payload of an event from one version to the next one.
+type Upcaster func(payload []byte) ([]byte, error)
+
+var versionedNameRegexp = regexp.MustCompile(`^(.+)_v(\d+)$`)
+
+type eventVersion struct {
+	name    string
+	version int
+}
+
+// Registry chains upcasters, so an event stored or published in any old version
+// can be read as the latest one (v0 → v1 → … → vN).
+//
+// Registry is not safe for concurrent registration; all upcasters should be registered before it's used.
+type Registry struct {
+	upcasters map[eventVersion]Upcaster
+	latest    map[string]int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		upcasters: map[eventVersion]Upcaster{},
+		latest:    map[string]int{},
+	}
+}
+
+// Register adds an upcaster from fromVersion to fromVersion+1 of the event.
+func (r *Registry) Register(name string, fromVersion int, upcaster Upcaster) {
+	key := eventVersion{name: name, version: fromVersion}
+	if _, ok := r.upcasters[key]; ok {
+		panic(fmt.Sprintf("upcaster for %s_v%d already registered", name, fromVersion))
+	}
+
+	r.upcasters[key] = upcaster
+	if r.latest[name] < fromVersion+1 {
+		r.latest[name] = fromVersion + 1
+	}
+}
+
+// LatestName returns the name of the latest version of the event, e.g. BookingMade_v1 for BookingMade_v0.
+// Names of events without upcasters are returned unchanged.
+func (r *Registry) LatestName(eventName string) string {
+	event, ok := parseEventName(eventName)
+	if !ok {
+		return eventName
+	}
+
+	latest, ok := r.latest[event.name]
+	if !ok || latest <= event.version {
+		return eventName
+	}
+
+	return formatEventName(event.name, latest)
+}
+
+// Upcast converts the payload to the latest version of the event and returns the new event name.
+func (r *Registry) Upcast(eventName string, payload []byte) (string, []byte, error) {
+	event, ok := parseEventName(eventName)
+	if !ok {
+		return eventName, payload, nil
+	}
+
+	for {
+		upcaster, ok := r.upcasters[event]
+		if !ok {
+			break
+		}
+
+		upcasted, err := upcaster(payload)
+		if err != nil {
+			return "", nil, fmt.Errorf(
+				"could not upcast %s to v%d: %w",
+				formatEventName(event.name, event.version), event.version+1, err,
+			)
+		}
+
+		payload = upcasted
+		event.version++
+	}
+
+	latestName := formatEventName(event.name, event.version)
+	if latestName != r.LatestName(eventName) {
+		return "", nil, fmt.Errorf("missing upcaster from %s to %s", latestName, r.LatestName(eventName))
+	}
+
+	return latestName, payload, nil
+}
+
+// Typed creates an Upcaster from a function mapping the old event struct to the new one.
+func Typed[From, To any](upcast func(From) To) Upcaster {
+	return func(payload []byte) ([]byte, error) {
+		var from From
+		if err := json.Unmarshal(payload, &from); err != nil {
+			return nil, fmt.Errorf("could not unmarshal %T: %w", from, err)
+		}
+
+		return json.Marshal(upcast(from))
+	}
+}
+
+func parseEventName(eventName string) (eventVersion, bool) {
+	matches := versionedNameRegexp.FindStringSubmatch(eventName)
+	if matches == nil {
+		return eventVersion{}, false
+	}
+
+	version, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return eventVersion{}, false
+	}
+
+	return eventVersion{name: matches[1], version: version}, true
+}
+
+func formatEventName(name string, version int) string {
+	return name + "_v" + strconv.Itoa(version)
+}
@@ -0,0 +1,74 @@
+package upcasting_test
+
+import (
+	"encoding/json"
+	"testing"
+	"tickets/upcasting"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Upcast_chain(t *testing.T) {
+	type event_v0 struct {
+		Name string `json:"name"`
+	}
+	type event_v1 struct {
+		FirstName string `json:"first_name"`
+	}
+	type event_v2 struct {
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+
+	r := upcasting.NewRegistry()
+	r.Register("Event", 1, upcasting.Typed(func(e event_v1) event_v2 {
+		return event_v2{FirstName: e.FirstName, LastName: "unknown"}
+	}))
+	r.Register("Event", 0, upcasting.Typed(func(e event_v0) event_v1 {
+		return event_v1{FirstName: e.Name}
+	}))
+
+	assert.Equal(t, "Event_v2", r.LatestName("Event_v0"))
+	assert.Equal(t, "Other_v0", r.LatestName("Other_v0"))
+
+	name, payload, err := r.Upcast("Event_v0", []byte(`{"name":"John"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Event_v2", name)
+	assert.JSONEq(t, `{"first_name":"John","last_name":"unknown"}`, string(payload))
+
+	name, payload, err = r.Upcast("Event_v2", []byte(`{"first_name":"John","last_name":"Doe"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Event_v2", name)
+	assert.JSONEq(t, `{"first_name":"John","last_name":"Doe"}`, string(payload))
+}
+
+func TestRegistry_Upcast_missing_version(t *testing.T) {
+	r := upcasting.NewRegistry()
+	r.Register("Event", 1, func(payload []byte) ([]byte, error) {
+		return payload, nil
+	})
+
+	_, _, err := r.Upcast("Event_v0", []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestNewEventsRegistry_BookingMade(t *testing.T) {
+	name, payload, err := upcasting.NewEventsRegistry().Upcast(
+		"BookingMade_v0",
+		[]byte(`{
+			"header": {"id": "c3a2e5c4-3f1b-4e57-8a53-2b2a3c1f6b0e"},
+			"number_of_tickets": 2,
+			"booking_id": "5e2f4c0a-9b1f-4a1a-9a3e-7d0f6f3a8b11",
+			"customer_email": "email@example.com",
+			"show_id": "0f5a1c6e-2e7d-4b8c-9a1f-3c2d1e0b9a88"
+		}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "BookingMade_v1", name)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(payload, &event))
+	assert.Equal(t, "5e2f4c0a-9b1f-4a1a-9a3e-7d0f6f3a8b11", event["booking_id"])
+	assert.EqualValues(t, 2, event["number_of_tickets"])
+}